@@ -0,0 +1,78 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"math"
+
+	"github.com/hailam/malgoplay/internal/audiofile"
+	"github.com/hailam/malgoplay/internal/fsgdx"
+)
+
+func runAnalyze(args []string) {
+	var (
+		window  int
+		hop     int
+		channel int
+		csv     bool
+		calFile string
+	)
+
+	flags := newFlagSet("analyze")
+	flags.IntVar(&window, "window", 4096, "Analysis window in frames")
+	flags.IntVar(&hop, "hop", 2048, "Frames between analysis windows")
+	flags.IntVar(&channel, "channel", 0, "Channel to analyse")
+	flags.BoolVar(&csv, "csv", false, "Print CSV instead of a table")
+	flags.StringVar(&calFile, "calibration", "", "Microphone calibration profile to correct levels with")
+	files := parseArgs(flags, args)
+	if len(files) != 1 {
+		log.Fatal("Usage: malgoplay analyze [--window n] [--hop n] [--channel n] [--csv] [--calibration mic.txt] file.wav")
+	}
+	if window <= 0 || hop <= 0 {
+		log.Fatal("Window and hop must be positive")
+	}
+
+	buf := openChannel(files[0], channel)
+	profile := loadCalibration(calFile)
+
+	if csv {
+		fmt.Println("time,frequency,level")
+	} else {
+		encoding := "PCM"
+		if buf.Metadata.Encoding == audiofile.EncodingFloat {
+			encoding = "float"
+		}
+		fmt.Printf("%s: %s, %d Hz, %d channels, %d-bit %s, %v\n",
+			files[0], buf.Metadata.Container, buf.SampleRate, buf.Channels(), buf.Metadata.ValidBits, encoding, buf.Duration())
+		fmt.Printf("%10s  %12s  %12s\n", "Time (s)", "Freq (Hz)", "Level (dBFS)")
+	}
+
+	data := buf.Data[channel]
+	block := make([]float32, window)
+	for start := 0; start+window <= len(data); start += hop {
+		for i := range block {
+			block[i] = float32(data[start+i])
+		}
+		freq := fsgdx.DetectFrequencyAt(block, float64(buf.SampleRate))
+		t := float64(start) / float64(buf.SampleRate)
+
+		level := rmsLevel(data[start : start+window])
+		if profile != nil && freq > 0 {
+			level = profile.CorrectLevel(freq, level)
+		}
+		if csv {
+			fmt.Printf("%.4f,%.2f,%.2f\n", t, freq, level)
+		} else {
+			fmt.Printf("%10.3f  %12.2f  %12.2f\n", t, freq, level)
+		}
+	}
+}
+
+// rmsLevel returns the RMS level of samples in dB relative to full scale.
+func rmsLevel(samples []float64) float64 {
+	sum := 0.0
+	for _, v := range samples {
+		sum += v * v
+	}
+	return 10 * math.Log10(sum/float64(len(samples)))
+}
@@ -0,0 +1,89 @@
+package main
+
+import (
+	"fmt"
+	"log"
+
+	"github.com/hailam/malgoplay/internal/audiofile"
+	"github.com/hailam/malgoplay/internal/calibration"
+)
+
+func runCalibrate(args []string) {
+	var (
+		measured        string
+		reference       string
+		output          string
+		name            string
+		minFreq         float64
+		maxFreq         float64
+		pointsPerOctave int
+		normalize       float64
+		channel         int
+	)
+
+	defaults := calibration.DefaultMeasureOptions()
+	flags := newFlagSet("calibrate")
+	flags.StringVar(&measured, "measured", "", "Sweep recorded through the device under test")
+	flags.StringVar(&reference, "reference", "", "The same sweep from a reference microphone, or the played file")
+	flags.StringVar(&output, "out", "calibration.txt", "Profile file to write")
+	flags.StringVar(&name, "name", "", "Profile name written to the file header")
+	flags.Float64Var(&minFreq, "min", defaults.MinFrequency, "Lowest profile frequency")
+	flags.Float64Var(&maxFreq, "max", defaults.MaxFrequency, "Highest profile frequency")
+	flags.IntVar(&pointsPerOctave, "points-per-octave", defaults.PointsPerOctave, "Profile resolution")
+	flags.Float64Var(&normalize, "normalize", 1000, "Frequency shifted to 0 dB (0 keeps absolute gains)")
+	flags.IntVar(&channel, "channel", 0, "Channel of both recordings to use")
+	parseFlags(flags, args)
+	if measured == "" || reference == "" {
+		log.Fatal("Usage: malgoplay calibrate --measured m.wav --reference r.wav [--out mic.txt]")
+	}
+
+	m := openChannel(measured, channel)
+	r := openChannel(reference, channel)
+	if m.SampleRate != r.SampleRate {
+		log.Fatalf("Sample rates differ: %s is %d Hz, %s is %d Hz", measured, m.SampleRate, reference, r.SampleRate)
+	}
+
+	profile, err := calibration.FromMeasurement(m.Data[channel], r.Data[channel], float64(m.SampleRate), calibration.MeasureOptions{
+		MinFrequency:    minFreq,
+		MaxFrequency:    maxFreq,
+		PointsPerOctave: pointsPerOctave,
+	})
+	if err != nil {
+		log.Fatalf("Failed to build profile: %v", err)
+	}
+	if normalize > 0 {
+		profile.Normalize(normalize)
+	}
+	profile.Name = name
+
+	if err := profile.SaveFile(output); err != nil {
+		log.Fatalf("Failed to write %s: %v", output, err)
+	}
+	fmt.Printf("Wrote %d points from %.0f Hz to %.0f Hz to %s\n",
+		len(profile.Points), profile.Points[0].Frequency, profile.Points[len(profile.Points)-1].Frequency, output)
+}
+
+// openChannel decodes an audio file and checks that it has the channel.
+func openChannel(path string, channel int) *audiofile.Buffer {
+	buf, err := audiofile.Open(path)
+	if err != nil {
+		log.Fatalf("Failed to read %s: %v", path, err)
+	}
+	if channel < 0 || channel >= buf.Channels() {
+		log.Fatalf("Channel %d out of range, %s has %d channels", channel, path, buf.Channels())
+	}
+	return buf
+}
+
+// loadCalibration reads the profile named by a --calibration flag, or
+// returns nil when the flag is empty.
+func loadCalibration(path string) *calibration.Profile {
+	if path == "" {
+		return nil
+	}
+	profile, err := calibration.LoadFile(path)
+	if err != nil {
+		log.Fatalf("Failed to load calibration: %v", err)
+	}
+	return profile
+}
@@ -0,0 +1,146 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+
+	"github.com/hailam/malgoplay/internal/config"
+)
+
+// envPrefix starts the environment variable of every flag, e.g.
+// MALGOPLAY_LOG_LEVEL for --log-level.
+const envPrefix = "MALGOPLAY_"
+
+// showConfig makes applyConfig print the effective configuration of the
+// command instead of running it; set by "config show".
+var showConfig bool
+
+const configUsage = `Usage: malgoplay config <command>
+
+Commands:
+  show [COMMAND] [flags]   Print the effective settings of a command (default: play)
+  path                     Print the config file used
+
+Settings come from, in order of precedence: flags, MALGOPLAY_* environment
+variables, the [COMMAND] table of the config file and its top-level settings.`
+
+func runConfig(args []string) {
+	if len(args) == 0 {
+		fmt.Fprintln(os.Stderr, configUsage)
+		os.Exit(2)
+	}
+	switch args[0] {
+	case "show":
+		args = args[1:]
+		name := "play"
+		if len(args) > 0 && args[0] != "" && args[0][0] != '-' {
+			name, args = args[0], args[1:]
+		}
+		run, ok := command(name)
+		if !ok || name == "config" || name == "preset" {
+			log.Fatalf("No settings for command %q", name)
+		}
+		showConfig = true
+		run(args)
+	case "path":
+		path, _ := configPath(nil)
+		fmt.Println(path)
+	default:
+		fmt.Fprintln(os.Stderr, configUsage)
+		os.Exit(2)
+	}
+}
+
+// newFlagSet returns a flag set for a command, with the --config flag that
+// applyConfig reads.
+func newFlagSet(name string) *flag.FlagSet {
+	flags := flag.NewFlagSet(name, flag.ExitOnError)
+	flags.String("config", "", "Config file, TOML or YAML by extension (default: $MALGOPLAY_CONFIG or config.toml/.yaml in the user configuration directory)")
+	return flags
+}
+
+// alias adds short as another name for the flag long.
+func alias(flags *flag.FlagSet, short, long string) {
+	flags.Var(flags.Lookup(long).Value, short, "Shorthand for --"+long)
+}
+
+// isSet reports whether the flag name was given on the command line, in
+// the environment or in the config file, even if at its default value.
+func isSet(flags *flag.FlagSet, name string) bool {
+	set := false
+	flags.Visit(func(f *flag.Flag) {
+		if f.Name == name {
+			set = true
+		}
+	})
+	return set
+}
+
+func parseFlags(flags *flag.FlagSet, args []string) {
+	_ = flags.Parse(args)
+	applyConfig(flags)
+}
+
+// configPath returns the config file named by --config, MALGOPLAY_CONFIG
+// or found in the default location, and whether it was named explicitly.
+// The default location holds config.toml, config.yaml or config.yml.
+func configPath(flags *flag.FlagSet) (string, bool) {
+	if flags != nil {
+		if f := flags.Lookup("config"); f != nil && f.Value.String() != "" {
+			return f.Value.String(), true
+		}
+	}
+	if path, ok := os.LookupEnv(envPrefix + "CONFIG"); ok && path != "" {
+		return path, true
+	}
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", false
+	}
+	for _, name := range []string{"config.toml", "config.yaml", "config.yml"} {
+		path := filepath.Join(dir, "malgoplay", name)
+		if _, err := os.Stat(path); err == nil {
+			return path, false
+		}
+	}
+	return filepath.Join(dir, "malgoplay", "config.toml"), false
+}
+
+// applyConfig fills in the flags not given on the command line from the
+// environment, the config file and a --preset. A missing default config
+// file is not an error.
+func applyConfig(flags *flag.FlagSet) {
+	path, explicit := configPath(flags)
+	var file *config.File
+	if path != "" {
+		var err error
+		file, err = config.Load(path)
+		if errors.Is(err, os.ErrNotExist) && !explicit {
+			file = nil
+		} else if err != nil {
+			log.Fatalf("Failed to load config: %v", err)
+		}
+	}
+
+	sources, err := config.Apply(flags, file, flags.Name(), envPrefix, os.LookupEnv)
+	if err != nil {
+		log.Fatalf("Invalid configuration: %v", err)
+	}
+	applyPreset(flags, file, sources)
+
+	if showConfig {
+		status := ""
+		if file == nil {
+			status = " (not found)"
+		}
+		fmt.Printf("# Config file: %s%s\n", path, status)
+		if err := config.Write(os.Stdout, flags, flags.Name(), sources, "config"); err != nil {
+			log.Fatal(err)
+		}
+		os.Exit(0)
+	}
+}
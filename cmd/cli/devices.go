@@ -0,0 +1,60 @@
+package main
+
+import (
+	"fmt"
+	"log"
+
+	"github.com/gen2brain/malgo"
+)
+
+func runDevices(args []string) {
+	var (
+		logLevel string
+		capture  bool
+		playback bool
+	)
+	flags := newFlagSet("devices")
+	flags.BoolVar(&playback, "playback", false, "List only playback devices")
+	flags.BoolVar(&capture, "capture", false, "List only capture devices")
+	flags.StringVar(&logLevel, "log-level", "warn", "Log level (debug, info, warn, error)")
+	parseFlags(flags, args)
+	if !playback && !capture {
+		playback, capture = true, true
+	}
+
+	logger := newLogger(logLevel)
+	ctx, err := malgo.InitContext(nil, malgo.ContextConfig{}, func(message string) {
+		logger.Debug("malgo", "message", message)
+	})
+	if err != nil {
+		log.Fatalf("Failed to initialize context: %v", err)
+	}
+	defer func() {
+		_ = ctx.Uninit()
+		ctx.Free()
+	}()
+
+	list := func(title string, deviceType malgo.DeviceType) {
+		devices, err := ctx.Devices(deviceType)
+		if err != nil {
+			log.Fatalf("Failed to list devices: %v", err)
+		}
+		fmt.Printf("%s devices:\n", title)
+		if len(devices) == 0 {
+			fmt.Println("  (none)")
+		}
+		for _, device := range devices {
+			marker := " "
+			if device.IsDefault != 0 {
+				marker = "*"
+			}
+			fmt.Printf("%s %s\n", marker, device.Name())
+		}
+	}
+	if playback {
+		list("Playback", malgo.Playback)
+	}
+	if capture {
+		list("Capture", malgo.Capture)
+	}
+}
@@ -0,0 +1,54 @@
+package main
+
+import (
+	"fmt"
+	"log"
+
+	"github.com/hailam/malgoplay/internal/fsgdx"
+)
+
+func runLatency(args []string) {
+	config := fsgdx.DefaultLatencyConfig()
+
+	var (
+		sampleRate uint
+		channels   uint
+		stimulus   string
+	)
+
+	flags := newFlagSet("latency")
+	flags.UintVar(&sampleRate, "rate", uint(config.SampleRate), "Sample rate")
+	flags.UintVar(&channels, "channels", uint(config.Channels), "Number of channels")
+	flags.StringVar(&stimulus, "stimulus", "chirp", "Burst signal (chirp, mls)")
+	flags.IntVar(&config.MLSOrder, "order", config.MLSOrder, "MLS order when --stimulus=mls")
+	flags.IntVar(&config.Repetitions, "reps", config.Repetitions, "Number of bursts to measure")
+	flags.DurationVar(&config.BurstDuration, "burst", config.BurstDuration, "Length of each chirp burst")
+	flags.DurationVar(&config.Interval, "interval", config.Interval, "Time between burst starts (bounds the measurable latency)")
+	flags.Float64Var(&config.Amplitude, "amplitude", config.Amplitude, "Burst amplitude (0-1)")
+	flags.Float64Var(&config.MinConfidence, "min-confidence", config.MinConfidence, "Minimum correlation for a repetition to count")
+	parseFlags(flags, args)
+
+	config.SampleRate = uint32(sampleRate)
+	config.Channels = uint32(channels)
+
+	switch stimulus {
+	case "chirp":
+		config.Stimulus = fsgdx.LatencyStimulusChirp
+	case "mls":
+		config.Stimulus = fsgdx.LatencyStimulusMLS
+	default:
+		log.Fatalf("Unknown stimulus: %s", stimulus)
+	}
+
+	fmt.Printf("Measuring round-trip latency with %d bursts...\n", config.Repetitions)
+	result, err := fsgdx.MeasureLatency(config)
+	for _, m := range result.Measurements {
+		fmt.Printf("  #%d: %8.3f ms (confidence %.2f)\n", m.Repetition+1, m.LatencyMs, m.Confidence)
+	}
+	if err != nil {
+		log.Fatalf("Latency measurement failed: %v", err)
+	}
+
+	fmt.Printf("Latency: %.3f ms ± %.3f ms (min %.3f, max %.3f, %d/%d accepted, confidence %.2f)\n",
+		result.MeanMs, result.StdDevMs, result.MinMs, result.MaxMs, result.Accepted, len(result.Measurements), result.Confidence)
+}
@@ -4,8 +4,10 @@ import (
 	"flag"
 	"fmt"
 	"log"
+	"log/slog"
 	"os"
 	"os/signal"
+	"strings"
 	"syscall"
 	"time"
 
@@ -14,61 +16,193 @@ import (
 	"github.com/gen2brain/malgo"
 )
 
+// modeUsage is the help of the --mode flags.
+var modeUsage = "Sweep mode (" + strings.Join(audio.SweepModeNames(), ", ") + ")"
+
+// command returns the subcommand called name.
+func command(name string) (func(args []string), bool) {
+	switch name {
+	case "analyze":
+		return runAnalyze, true
+	case "calibrate":
+		return runCalibrate, true
+	case "config":
+		return runConfig, true
+	case "devices":
+		return runDevices, true
+	case "latency":
+		return runLatency, true
+	case "pitch":
+		return runPitch, true
+	case "play":
+		return runPlay, true
+	case "preset":
+		return runPreset, true
+	case "render":
+		return runRender, true
+	case "room":
+		return runRoom, true
+	case "serve":
+		return runServe, true
+	case "verify":
+		return runVerify, true
+	}
+	return nil, false
+}
+
+const usage = `Usage: malgoplay [COMMAND] [flags]
+
+Commands:
+  play       Play a sweep on the default output device (default)
+  render     Render a sweep or signal to a WAV file
+  analyze    Measure the spectrum of a recording
+  devices    List the playback and capture devices
+  serve      Control playback over an HTTP API
+  config     Show the effective settings of a command
+  preset     List, save and delete presets
+  calibrate, latency, pitch, room, verify
+
+Run malgoplay COMMAND -h for the flags of a command.`
+
 func main() {
+	args := os.Args[1:]
+	if len(args) > 0 && !strings.HasPrefix(args[0], "-") {
+		if args[0] == "help" {
+			fmt.Println(usage)
+			return
+		}
+		run, ok := command(args[0])
+		if !ok {
+			fmt.Fprintf(os.Stderr, "Unknown command %q\n\n%s\n", args[0], usage)
+			os.Exit(2)
+		}
+		run(args[1:])
+		return
+	}
+
+	runPlay(args)
+}
+
+func runPlay(args []string) {
 	var (
-		minFreq    float64
-		maxFreq    float64
-		sampleRate uint
-		channels   uint
-		duration   int
-		sweepRate  float64
-		sweepMode  string
+		minFreq       float64
+		maxFreq       float64
+		sampleRate    uint
+		channels      uint
+		duration      int
+		sweepRate     float64
+		sweepTime     time.Duration
+		octavesPerSec float64
+		hzPerSec      float64
+		direction     string
+		loops         int
+		endAction     string
+		oneShot       bool
+		seed          int64
+		hold          time.Duration
+		glide         time.Duration
+		distribution  string
+		fromNote      string
+		toNote        string
+		scaleSpec     string
+		tuningName    string
+		a4            float64
+		noteStep      time.Duration
+		curveFile     string
+		curveLoop     bool
+		wavetable     string
+		harmonics     string
+		logLevel      string
+		amplitude     float64
+		sweepMode     string
+		calFile       string
+		adsrSpec      string
+		burstSpec     string
+		amSpec        string
+		fmSpec        string
+		interactive   bool
 	)
 
-	flag.Float64Var(&minFreq, "min", 220, "Minimum frequency")
-	flag.Float64Var(&minFreq, "m", 220, "Minimum frequency (shorthand)")
-	flag.Float64Var(&maxFreq, "max", 880, "Maximum frequency")
-	flag.Float64Var(&maxFreq, "M", 880, "Maximum frequency (shorthand)")
-	flag.UintVar(&sampleRate, "rate", 44100, "Sample rate")
-	flag.UintVar(&sampleRate, "r", 44100, "Sample rate (shorthand)")
-	flag.UintVar(&channels, "channels", 2, "Number of channels")
-	flag.UintVar(&channels, "c", 2, "Number of channels (shorthand)")
-	flag.IntVar(&duration, "duration", 10, "Duration in seconds")
-	flag.IntVar(&duration, "d", 10, "Duration in seconds (shorthand)")
-	flag.Float64Var(&sweepRate, "sweep", 1, "Sweep rate in Hz")
-	flag.Float64Var(&sweepRate, "s", 1, "Sweep rate in Hz (shorthand)")
-	flag.StringVar(&sweepMode, "mode", "linear", "Sweep mode (linear, sine, triangle, exponential, logarithmic, square, sawtooth, random)")
-	flag.StringVar(&sweepMode, "o", "linear", "Sweep mode (shorthand)")
-	flag.Parse()
-
-	gen := audio.NewFrequencySweepGenerator(minFreq, maxFreq, uint32(sampleRate), uint32(channels))
-	defer gen.Close()
+	flags := newFlagSet("play")
 
-	gen.SetSweepRate(sweepRate)
+	flags.Float64Var(&minFreq, "min", 220, "Minimum frequency")
+	flags.Float64Var(&maxFreq, "max", 880, "Maximum frequency")
+	flags.UintVar(&sampleRate, "rate", 44100, "Sample rate")
+	flags.UintVar(&channels, "channels", 2, "Number of channels")
+	flags.IntVar(&duration, "duration", 10, "Duration in seconds")
+	flags.Float64Var(&sweepRate, "sweep", 1, "Sweep rate in Hz")
+	flags.StringVar(&sweepMode, "mode", "linear", modeUsage)
+	flags.DurationVar(&sweepTime, "sweep-duration", 0, "Time of a one-way sweep from min to max (overrides --sweep)")
+	flags.Float64Var(&octavesPerSec, "octaves-per-sec", 0, "Sweep speed in octaves per second for --mode octaves (overrides --sweep)")
+	flags.Float64Var(&hzPerSec, "hz-per-sec", 0, "Sweep speed in Hz per second (overrides --sweep)")
+	flags.StringVar(&direction, "direction", "", "Sweep direction (up, down, updown); enables --loops and --end")
+	flags.IntVar(&loops, "loops", 0, "Number of sweep passes, 0 for endless")
+	flags.StringVar(&endAction, "end", "hold", "After the last pass: hold the final frequency or stop")
+	flags.BoolVar(&oneShot, "one-shot", false, "Sweep once, then fade out and stop (same as --loops 1 --end stop)")
+	flags.Int64Var(&seed, "seed", 0, "Seed of the random mode and random LFOs (default: time-based, printed)")
+	flags.DurationVar(&hold, "hold", 100*time.Millisecond, "Time each random frequency plays")
+	flags.DurationVar(&glide, "glide", 0, "Portamento into each new random frequency or note")
+	flags.StringVar(&distribution, "distribution", "uniform", "Random frequency distribution (uniform, log)")
+	flags.StringVar(&fromNote, "from", "", "First note of a note sweep, e.g. A2 (switches to the notes mode)")
+	flags.StringVar(&toNote, "to", "", "Last note of a note sweep, e.g. A5")
+	flags.StringVar(&scaleSpec, "scale", "chromatic", "Scale name or comma-separated cents within the octave")
+	flags.StringVar(&tuningName, "tuning", "equal", "Tuning of the scale (equal, just)")
+	flags.Float64Var(&a4, "a4", 440, "Reference frequency of A4")
+	flags.DurationVar(&noteStep, "step", 500*time.Millisecond, "Duration of each note")
+	flags.StringVar(&curveFile, "curve", "", "Breakpoint curve file, JSON or CSV (switches to the custom mode)")
+	flags.StringVar(&wavetable, "wavetable", "", "WAV file holding one cycle of the carrier waveform (default: sine)")
+	flags.StringVar(&harmonics, "harmonics", "", "Additive carrier as number:amplitude[@phase] harmonics, e.g. 1:1.0,2:0.01,3:0.003")
+	flags.BoolVar(&curveLoop, "curve-loop", false, "Restart the breakpoint curve after its last point")
+	flags.StringVar(&calFile, "calibration", "", "Speaker calibration profile to pre-emphasise the output with")
+	flags.StringVar(&adsrSpec, "adsr", "", "ADSR envelope as attack,decay,sustain,release[:linear|exp|cosine]")
+	flags.StringVar(&burstSpec, "burst", "", "Tone burst as on,off cycles[:hann]")
+	flags.StringVar(&amSpec, "am", "", "Amplitude modulation as rate,depth[:shape]")
+	flags.StringVar(&fmSpec, "fm", "", "Frequency modulation as rate,deviation[:shape]")
+	flags.StringVar(&logLevel, "log-level", "warn", "Log level (debug, info, warn, error)")
+	flags.Float64Var(&amplitude, "amplitude", 1, "Amplitude (0-1)")
+	flags.String("preset", "", "Start from a saved or built-in preset (see malgoplay preset list)")
+	flags.String("preset-dir", "", "Preset directory (default: the user configuration directory)")
+	flags.BoolVar(&interactive, "tui", false, "Adjust the sweep from the keyboard while it plays")
+	alias(flags, "m", "min")
+	alias(flags, "M", "max")
+	alias(flags, "r", "rate")
+	alias(flags, "c", "channels")
+	alias(flags, "d", "duration")
+	alias(flags, "s", "sweep")
+	alias(flags, "o", "mode")
+	parseFlags(flags, args)
 
-	switch sweepMode {
-	case "linear":
-		gen.SetSweepMode(audio.SweepModeLinear)
-	case "sine":
-		gen.SetSweepMode(audio.SweepModeSine)
-	case "triangle":
-		gen.SetSweepMode(audio.SweepModeTriangle)
-	case "exponential":
-		gen.SetSweepMode(audio.SweepModeExponential)
-	case "logarithmic":
-		gen.SetSweepMode(audio.SweepModeLogarithmic)
-	case "square":
-		gen.SetSweepMode(audio.SweepModeSquare)
-	case "sawtooth":
-		gen.SetSweepMode(audio.SweepModeSawtooth)
-	case "random":
-		gen.SetSweepMode(audio.SweepModeRandom)
-	default:
-		log.Fatalf("Unknown sweep mode: %s", sweepMode)
+	logger := newLogger(logLevel)
+	gen, err := audio.New(audio.Config{
+		MinFrequency: minFreq,
+		MaxFrequency: maxFreq,
+		SampleRate:   uint32(sampleRate),
+		Channels:     uint32(channels),
+	}, audio.WithLogger(logger))
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer gen.Close()
+
+	mode, err := audio.ParseSweepMode(sweepMode)
+	if err != nil {
+		log.Fatal(err)
 	}
+	gen.SetSweepMode(mode)
+	gen.SetSweepRate(sweepRate)
+	gen.SetAmplitude(amplitude)
+	applyTiming(gen, mode, sweepTime, octavesPerSec, hzPerSec)
+	applySweepOptions(gen, direction, loops, endAction, oneShot)
+	gen.SetCalibration(loadCalibration(calFile))
+	applyShaping(gen, adsrSpec, burstSpec)
+	applyModulation(gen, amSpec, fmSpec)
+	applyRandom(gen, mode, seed, isSet(flags, "seed"), hold, glide, distribution)
+	applyNotes(gen, fromNote, toNote, scaleSpec, tuningName, a4, noteStep, glide)
+	applyCurve(gen, curveFile, curveLoop)
+	applyCarrier(gen, wavetable, harmonics)
 
 	ctx, err := malgo.InitContext(nil, malgo.ContextConfig{}, func(message string) {
-		fmt.Printf("Log: %v\n", message)
+		logger.Debug("malgo", "message", message)
 	})
 	if err != nil {
 		log.Fatalf("Failed to initialize context: %v", err)
@@ -86,21 +220,45 @@ func main() {
 	// Set the device configuration for the generator
 	gen.SetDeviceConfig(deviceConfig)
 
+	if interactive {
+		restore, err := rawTerminal()
+		if err != nil {
+			log.Fatalf("Interactive mode needs a terminal: %v", err)
+		}
+		defer restore()
+	}
+
 	// Start the generator, which starts the device internally
 	if err := gen.Start(); err != nil {
 		log.Fatalf("Failed to start frequency sweep generator: %v", err)
 	}
 
-	if duration == 0 {
+	// Set up channel to listen for interrupt signal
+	c := make(chan os.Signal, 1)
+	signal.Notify(c, os.Interrupt, syscall.SIGTERM)
+
+	var timeout <-chan time.Time
+	switch {
+	case duration != 0:
+		fmt.Printf("Playing sweep for %d seconds...\n", duration)
+		timeout = time.After(time.Duration(duration) * time.Second)
+	case interactive:
+		fmt.Println("Playing sweep indefinitely. Press q to stop.")
+	default:
 		fmt.Println("Playing sweep indefinitely. Press Ctrl+C to stop.")
-		// Set up channel to listen for interrupt signal
-		c := make(chan os.Signal, 1)
-		signal.Notify(c, os.Interrupt, syscall.SIGTERM)
-		// Block until we receive an interrupt signal
-		<-c
+	}
+
+	// Block until the duration ends, a one-shot sweep completes or we
+	// receive an interrupt signal
+	if interactive {
+		runInteractive(gen, timeout, c)
 	} else {
-		fmt.Printf("Playing sweep for %d seconds...\n", duration)
-		time.Sleep(time.Duration(duration) * time.Second)
+		select {
+		case <-timeout:
+		case <-gen.Done():
+			fmt.Println("Sweep complete.")
+		case <-c:
+		}
 	}
 
 	fmt.Println("Stopping playback...")
@@ -110,3 +268,218 @@ func main() {
 
 	time.Sleep(500 * time.Millisecond)
 }
+
+// parseArgs parses flags that may appear before or after the positional
+// arguments and returns the positional arguments.
+func parseArgs(flags *flag.FlagSet, args []string) []string {
+	var positional []string
+	for {
+		_ = flags.Parse(args)
+		args = flags.Args()
+		if len(args) == 0 {
+			applyConfig(flags)
+			return positional
+		}
+		positional = append(positional, args[0])
+		args = args[1:]
+	}
+}
+
+// applyShaping sets the envelope and tone burst given by the --adsr and
+// --burst flags.
+func applyShaping(gen *audio.FrequencySweepGenerator, adsrSpec, burstSpec string) {
+	if adsrSpec != "" {
+		adsr, err := audio.ParseADSR(adsrSpec)
+		if err != nil {
+			log.Fatal(err)
+		}
+		gen.SetEnvelope(&adsr)
+	}
+	if burstSpec != "" {
+		burst, err := audio.ParseToneBurst(burstSpec)
+		if err != nil {
+			log.Fatal(err)
+		}
+		gen.SetToneBurst(&burst)
+	}
+}
+
+// applyModulation sets the modulators given by the --am and --fm flags.
+func applyModulation(gen *audio.FrequencySweepGenerator, amSpec, fmSpec string) {
+	if amSpec != "" {
+		mod, err := audio.ParseModulator(amSpec)
+		if err != nil {
+			log.Fatal(err)
+		}
+		if err := gen.SetAM(&mod); err != nil {
+			log.Fatal(err)
+		}
+	}
+	if fmSpec != "" {
+		mod, err := audio.ParseModulator(fmSpec)
+		if err != nil {
+			log.Fatal(err)
+		}
+		if err := gen.SetFM(&mod); err != nil {
+			log.Fatal(err)
+		}
+	}
+}
+
+// applyTiming replaces the raw --sweep rate with the physical sweep speed
+// given by at most one of --sweep-duration, --octaves-per-sec and
+// --hz-per-sec. Only the octaves mode sweeps at a constant number of
+// octaves per second, so --octaves-per-sec needs it.
+func applyTiming(gen *audio.FrequencySweepGenerator, mode audio.SweepMode, sweepTime time.Duration, octavesPerSec, hzPerSec float64) {
+	set := 0
+	for _, v := range []float64{sweepTime.Seconds(), octavesPerSec, hzPerSec} {
+		if v != 0 {
+			set++
+		}
+	}
+	if set > 1 {
+		log.Fatal("Use only one of --sweep-duration, --octaves-per-sec and --hz-per-sec")
+	}
+	if octavesPerSec != 0 && mode != audio.SweepModeOctaves {
+		log.Fatalf("--octaves-per-sec needs --mode octaves, the only mode with a constant octave rate, not %v", mode)
+	}
+
+	var err error
+	switch {
+	case sweepTime != 0:
+		err = gen.SetSweepDuration(sweepTime)
+	case octavesPerSec != 0:
+		err = gen.SetOctavesPerSecond(octavesPerSec)
+	case hzPerSec != 0:
+		err = gen.SetHzPerSecond(hzPerSec)
+	}
+	if err != nil {
+		log.Fatal(err)
+	}
+}
+
+// applySweepOptions sets the sweep policy given by --direction, --loops,
+// --end and --one-shot. Without any of them the generator keeps its
+// default of sweeping up once and holding.
+func applySweepOptions(gen *audio.FrequencySweepGenerator, direction string, loops int, endAction string, oneShot bool) {
+	if direction == "" && loops == 0 && endAction == "hold" && !oneShot {
+		return
+	}
+	if direction == "" {
+		direction = "up"
+	}
+
+	options := &audio.SweepOptions{Loops: loops}
+	var err error
+	if options.Direction, err = audio.ParseDirection(direction); err != nil {
+		log.Fatal(err)
+	}
+	if options.End, err = audio.ParseEndAction(endAction); err != nil {
+		log.Fatal(err)
+	}
+	if oneShot {
+		options.Loops, options.End = 1, audio.EndStop
+	}
+	if err := gen.SetSweepOptions(options); err != nil {
+		log.Fatal(err)
+	}
+}
+
+// applyRandom seeds the generator when --seed was given, 0 included, and
+// sets the random mode options. The seed is printed for random sweeps so
+// that a run can be replayed.
+func applyRandom(gen *audio.FrequencySweepGenerator, mode audio.SweepMode, seed int64, seedSet bool, hold, glide time.Duration, distribution string) {
+	if seedSet {
+		gen.SetSeed(seed)
+	}
+	dist, err := audio.ParseDistribution(distribution)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if err := gen.SetRandomOptions(audio.RandomOptions{Hold: hold, Glide: glide, Distribution: dist}); err != nil {
+		log.Fatal(err)
+	}
+	if mode == audio.SweepModeRandom {
+		fmt.Printf("Random seed: %d\n", gen.Seed())
+	}
+}
+
+// applyNotes switches to a note sweep when --from and --to are given.
+func applyNotes(gen *audio.FrequencySweepGenerator, fromNote, toNote, scaleSpec, tuningName string, a4 float64, step, glide time.Duration) {
+	if fromNote == "" && toNote == "" {
+		return
+	}
+	if fromNote == "" || toNote == "" {
+		log.Fatal("A note sweep needs both --from and --to")
+	}
+
+	from, err := audio.ParseNote(fromNote)
+	if err != nil {
+		log.Fatal(err)
+	}
+	to, err := audio.ParseNote(toNote)
+	if err != nil {
+		log.Fatal(err)
+	}
+	scale, err := audio.ParseScale(scaleSpec)
+	if err != nil {
+		log.Fatal(err)
+	}
+	tuning, err := audio.ParseTuning(tuningName)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	frequencies, err := audio.ScaleFrequencies(from, to, scale, tuning, a4)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if err := gen.SetNoteSweep(frequencies, step, glide); err != nil {
+		log.Fatal(err)
+	}
+}
+
+// applyCurve switches to a custom sweep along the breakpoints in path.
+func applyCurve(gen *audio.FrequencySweepGenerator, path string, loop bool) {
+	if path == "" {
+		return
+	}
+	curve, err := audio.LoadBreakpoints(path)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if err := gen.SetBreakpoints(curve, loop); err != nil {
+		log.Fatal(err)
+	}
+}
+
+// applyCarrier replaces the sine carrier with the cycle in a --wavetable
+// file or the --harmonics profile.
+func applyCarrier(gen *audio.FrequencySweepGenerator, wavetable, harmonics string) {
+	switch {
+	case wavetable != "" && harmonics != "":
+		log.Fatal("Only one of --wavetable and --harmonics may be given")
+	case wavetable != "":
+		table, err := audio.LoadWavetable(wavetable)
+		if err != nil {
+			log.Fatalf("Failed to load wavetable: %v", err)
+		}
+		gen.SetWaveform(table)
+	case harmonics != "":
+		profile, err := audio.ParseHarmonics(harmonics)
+		if err != nil {
+			log.Fatal(err)
+		}
+		gen.SetWaveform(profile)
+	}
+}
+
+// newLogger returns a text logger on stderr at the level of a --log-level
+// flag.
+func newLogger(level string) *slog.Logger {
+	var l slog.Level
+	if err := l.UnmarshalText([]byte(level)); err != nil {
+		log.Fatalf("Invalid log level: %s", level)
+	}
+	return slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: l}))
+}
@@ -0,0 +1,32 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	audio "github.com/hailam/malgoplay/internal/fsg"
+)
+
+func TestApplyRandomSeed(t *testing.T) {
+	tests := []struct {
+		args []string
+		want int64
+	}{
+		{[]string{"--seed", "0"}, 0},
+		{[]string{"--seed", "7"}, 7},
+		{nil, 42},
+	}
+	for _, tc := range tests {
+		flags := newFlagSet("play")
+		seed := flags.Int64("seed", 0, "")
+		if err := flags.Parse(tc.args); err != nil {
+			t.Fatal(err)
+		}
+		gen := audio.NewFrequencySweepGenerator(100, 1000, 48000, 1)
+		gen.SetSeed(42)
+		applyRandom(gen, audio.SweepModeLinear, *seed, isSet(flags, "seed"), 100*time.Millisecond, 0, "uniform")
+		if got := gen.Seed(); got != tc.want {
+			t.Errorf("%v: incorrect seed: got %d, want %d", tc.args, got, tc.want)
+		}
+	}
+}
@@ -0,0 +1,74 @@
+package main
+
+import (
+	"fmt"
+	"log"
+
+	"github.com/hailam/malgoplay/internal/audiofile"
+	"github.com/hailam/malgoplay/internal/pitch"
+)
+
+func runPitch(args []string) {
+	var (
+		method  string
+		channel int
+		csv     bool
+	)
+
+	config := pitch.DefaultConfig(0)
+	flags := newFlagSet("pitch")
+	flags.StringVar(&method, "method", "yin", "Pitch estimator (yin, mcleod)")
+	flags.IntVar(&config.WindowSize, "window", config.WindowSize, "Analysis window in frames")
+	flags.IntVar(&config.HopSize, "hop", config.HopSize, "Frames between estimates")
+	flags.Float64Var(&config.MinFrequency, "min", config.MinFrequency, "Lowest frequency to detect")
+	flags.Float64Var(&config.MaxFrequency, "max", config.MaxFrequency, "Highest frequency to detect")
+	flags.Float64Var(&config.Threshold, "threshold", -1, "YIN threshold (default 0.15) or McLeod key-maximum ratio (default 0.9)")
+	flags.IntVar(&channel, "channel", 0, "Channel to analyse")
+	flags.BoolVar(&csv, "csv", false, "Print CSV instead of a table")
+	files := parseArgs(flags, args)
+	if len(files) != 1 {
+		log.Fatal("Usage: malgoplay pitch [--method yin|mcleod] [--csv] file.wav")
+	}
+
+	switch method {
+	case "yin":
+		config.Method = pitch.MethodYIN
+		if config.Threshold < 0 {
+			config.Threshold = 0.15
+		}
+	case "mcleod":
+		config.Method = pitch.MethodMcLeod
+		if config.Threshold < 0 {
+			config.Threshold = 0.9
+		}
+	default:
+		log.Fatalf("Unknown pitch method: %s", method)
+	}
+
+	buf, err := audiofile.Open(files[0])
+	if err != nil {
+		log.Fatalf("Failed to read %s: %v", files[0], err)
+	}
+	if channel < 0 || channel >= buf.Channels() {
+		log.Fatalf("Channel %d out of range, %s has %d channels", channel, files[0], buf.Channels())
+	}
+	config.SampleRate = float64(buf.SampleRate)
+
+	track, err := pitch.Track(buf.Data[channel], config)
+	if err != nil {
+		log.Fatalf("Pitch tracking failed: %v", err)
+	}
+
+	if csv {
+		fmt.Println("time,f0,confidence")
+	} else {
+		fmt.Printf("%10s  %10s  %10s\n", "Time (s)", "F0 (Hz)", "Confidence")
+	}
+	for _, e := range track {
+		if csv {
+			fmt.Printf("%.4f,%.3f,%.3f\n", e.Time, e.Frequency, e.Confidence)
+		} else {
+			fmt.Printf("%10.3f  %10.2f  %10.2f\n", e.Time, e.Frequency, e.Confidence)
+		}
+	}
+}
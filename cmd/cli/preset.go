@@ -0,0 +1,190 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+	"text/tabwriter"
+
+	"github.com/hailam/malgoplay/internal/config"
+	"github.com/hailam/malgoplay/internal/preset"
+)
+
+const presetUsage = `Usage: malgoplay preset <command> [flags]
+
+Commands:
+  list                 List built-in and saved presets
+  load NAME            Print a preset as JSON
+  save NAME [flags]    Save a preset from --min, --max, --mode, --sweep and --amplitude
+  delete NAME          Delete a saved preset
+
+Play or render a preset with --preset NAME; flags given alongside override it.`
+
+func runPreset(args []string) {
+	if len(args) == 0 {
+		fmt.Fprintln(os.Stderr, presetUsage)
+		os.Exit(2)
+	}
+	command, args := args[0], args[1:]
+
+	var (
+		dir         string
+		minFreq     float64
+		maxFreq     float64
+		sweepMode   string
+		sweepRate   float64
+		amplitude   float64
+		description string
+	)
+	flags := flag.NewFlagSet("preset "+command, flag.ExitOnError)
+	flags.StringVar(&dir, "dir", "", "Preset directory (default: the user configuration directory)")
+	if command == "save" {
+		flags.Float64Var(&minFreq, "min", 220, "Minimum frequency")
+		flags.Float64Var(&maxFreq, "max", 880, "Maximum frequency")
+		flags.StringVar(&sweepMode, "mode", "linear", "Sweep mode")
+		flags.Float64Var(&sweepRate, "sweep", 1, "Sweep rate in Hz")
+		flags.Float64Var(&amplitude, "amplitude", 1, "Amplitude (0-1)")
+		flags.StringVar(&description, "description", "", "Description shown by list")
+	}
+
+	// The name comes first, as in "preset save NAME --min 20".
+	var name string
+	if len(args) > 0 && len(args[0]) > 0 && args[0][0] != '-' {
+		name, args = args[0], args[1:]
+	}
+	_ = flags.Parse(args)
+	if name == "" && flags.NArg() > 0 {
+		name = flags.Arg(0)
+	}
+	store := presetStore(dir)
+
+	switch command {
+	case "list":
+		presets, err := store.List()
+		if err != nil {
+			log.Fatal(err)
+		}
+		tw := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(tw, "Name\tRange (Hz)\tMode\tSweep\tAmplitude\tDescription")
+		for _, p := range presets {
+			label := p.Name
+			if p.Builtin {
+				label += " (built-in)"
+			}
+			fmt.Fprintf(tw, "%s\t%g-%g\t%s\t%g\t%g\t%s\n", label, p.MinFrequency, p.MaxFrequency, p.Mode, p.SweepRate, p.Amplitude, p.Description)
+		}
+		tw.Flush()
+	case "load":
+		p, err := store.Load(requireName(name))
+		if err != nil {
+			log.Fatal(err)
+		}
+		data, _ := json.MarshalIndent(p, "", "  ")
+		fmt.Println(string(data))
+	case "save":
+		p := preset.Preset{
+			Name:         requireName(name),
+			Description:  description,
+			MinFrequency: minFreq,
+			MaxFrequency: maxFreq,
+			Mode:         sweepMode,
+			SweepRate:    sweepRate,
+			Amplitude:    amplitude,
+		}
+		if err := store.Save(p); err != nil {
+			log.Fatalf("Failed to save preset: %v", err)
+		}
+		fmt.Printf("Saved preset %q\n", p.Name)
+	case "delete":
+		if err := store.Delete(requireName(name)); err != nil {
+			log.Fatal(err)
+		}
+		fmt.Printf("Deleted preset %q\n", name)
+	default:
+		fmt.Fprintln(os.Stderr, presetUsage)
+		os.Exit(2)
+	}
+}
+
+func requireName(name string) string {
+	if name == "" {
+		log.Fatal("A preset name is required")
+	}
+	return name
+}
+
+func presetStore(dir string) *preset.Store {
+	if dir == "" {
+		var err error
+		if dir, err = preset.DefaultDir(); err != nil {
+			log.Fatalf("No preset directory: %v", err)
+		}
+	}
+	return preset.NewStore(dir)
+}
+
+// sourcePreset marks the settings that config show reports as coming from
+// a --preset.
+const sourcePreset config.Source = "preset"
+
+// applyPreset loads the preset named by the --preset flag, if the command
+// has one, into the sweep settings. A preset overrides the defaults and the
+// top-level settings of the config file, which apply to every command, but
+// not the command's own table, the environment or the command line.
+func applyPreset(flags *flag.FlagSet, file *config.File, sources map[string]config.Source) {
+	name := flags.Lookup("preset")
+	if name == nil || name.Value.String() == "" {
+		return
+	}
+	dir := ""
+	if f := flags.Lookup("preset-dir"); f != nil {
+		dir = f.Value.String()
+	}
+	p, err := presetStore(dir).Load(name.Value.String())
+	if errors.Is(err, preset.ErrNotFound) {
+		log.Fatalf("%v (see malgoplay preset list)", err)
+	} else if err != nil {
+		log.Fatal(err)
+	}
+
+	for setting, value := range map[string]string{
+		"min":       strconv.FormatFloat(p.MinFrequency, 'g', -1, 64),
+		"max":       strconv.FormatFloat(p.MaxFrequency, 'g', -1, 64),
+		"mode":      p.Mode,
+		"sweep":     strconv.FormatFloat(p.SweepRate, 'g', -1, 64),
+		"amplitude": strconv.FormatFloat(p.Amplitude, 'g', -1, 64),
+	} {
+		switch sources[setting] {
+		case config.SourceDefault:
+		case config.SourceFile:
+			if inTable(flags, file, setting) {
+				continue
+			}
+		default:
+			continue
+		}
+		if err := flags.Set(setting, value); err != nil {
+			log.Fatalf("Invalid preset %s: %v", p.Name, err)
+		}
+		sources[setting] = sourcePreset
+	}
+}
+
+// inTable reports whether the command's table in file sets setting, under
+// its own name or a shorthand.
+func inTable(flags *flag.FlagSet, file *config.File, setting string) bool {
+	if file == nil {
+		return false
+	}
+	target := flags.Lookup(setting).Value
+	for key := range file.Section(flags.Name()) {
+		if f := flags.Lookup(key); f != nil && f.Value == target {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,175 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/hailam/malgoplay/internal/audiofile"
+	audio "github.com/hailam/malgoplay/internal/fsg"
+)
+
+func runRender(args []string) {
+	var (
+		signal        string
+		output        string
+		format        string
+		minFreq       float64
+		maxFreq       float64
+		sampleRate    uint
+		channels      uint
+		duration      time.Duration
+		sweepRate     float64
+		sweepTime     time.Duration
+		octavesPerSec float64
+		hzPerSec      float64
+		direction     string
+		loops         int
+		endAction     string
+		oneShot       bool
+		seed          int64
+		hold          time.Duration
+		glide         time.Duration
+		distribution  string
+		fromNote      string
+		toNote        string
+		scaleSpec     string
+		tuningName    string
+		a4            float64
+		noteStep      time.Duration
+		curveFile     string
+		curveLoop     bool
+		wavetable     string
+		harmonics     string
+		logLevel      string
+		sweepMode     string
+		amplitude     float64
+		order         int
+		periods       int
+		calFile       string
+		adsrSpec      string
+		burstSpec     string
+		amSpec        string
+		fmSpec        string
+	)
+
+	flags := newFlagSet("render")
+	flags.StringVar(&signal, "signal", "sweep", "Signal to render (sweep, mls)")
+	flags.StringVar(&output, "out", "malgoplay.wav", "Output WAV file")
+	flags.StringVar(&format, "format", "float32", "Sample format (float32, pcm16)")
+	flags.Float64Var(&minFreq, "min", 220, "Minimum frequency")
+	flags.Float64Var(&maxFreq, "max", 880, "Maximum frequency")
+	flags.UintVar(&sampleRate, "rate", 44100, "Sample rate")
+	flags.UintVar(&channels, "channels", 1, "Number of channels")
+	flags.DurationVar(&duration, "duration", 10*time.Second, "Length of a rendered sweep")
+	flags.Float64Var(&sweepRate, "sweep", 1, "Sweep rate in Hz")
+	flags.DurationVar(&sweepTime, "sweep-duration", 0, "Time of a one-way sweep from min to max (overrides --sweep)")
+	flags.Float64Var(&octavesPerSec, "octaves-per-sec", 0, "Sweep speed in octaves per second for --mode octaves (overrides --sweep)")
+	flags.Float64Var(&hzPerSec, "hz-per-sec", 0, "Sweep speed in Hz per second (overrides --sweep)")
+	flags.StringVar(&direction, "direction", "", "Sweep direction (up, down, updown); enables --loops and --end")
+	flags.IntVar(&loops, "loops", 0, "Number of sweep passes, 0 for endless")
+	flags.StringVar(&endAction, "end", "hold", "After the last pass: hold the final frequency or stop")
+	flags.BoolVar(&oneShot, "one-shot", false, "Sweep once, then fade out and stop (same as --loops 1 --end stop)")
+	flags.Int64Var(&seed, "seed", 0, "Seed of the random mode and random LFOs (default: time-based, printed)")
+	flags.DurationVar(&hold, "hold", 100*time.Millisecond, "Time each random frequency plays")
+	flags.DurationVar(&glide, "glide", 0, "Portamento into each new random frequency or note")
+	flags.StringVar(&distribution, "distribution", "uniform", "Random frequency distribution (uniform, log)")
+	flags.StringVar(&fromNote, "from", "", "First note of a note sweep, e.g. A2 (switches to the notes mode)")
+	flags.StringVar(&toNote, "to", "", "Last note of a note sweep, e.g. A5")
+	flags.StringVar(&scaleSpec, "scale", "chromatic", "Scale name or comma-separated cents within the octave")
+	flags.StringVar(&tuningName, "tuning", "equal", "Tuning of the scale (equal, just)")
+	flags.Float64Var(&a4, "a4", 440, "Reference frequency of A4")
+	flags.DurationVar(&noteStep, "step", 500*time.Millisecond, "Duration of each note")
+	flags.StringVar(&curveFile, "curve", "", "Breakpoint curve file, JSON or CSV (switches to the custom mode)")
+	flags.StringVar(&wavetable, "wavetable", "", "WAV file holding one cycle of the carrier waveform (default: sine)")
+	flags.StringVar(&harmonics, "harmonics", "", "Additive carrier as number:amplitude[@phase] harmonics, e.g. 1:1.0,2:0.01,3:0.003")
+	flags.BoolVar(&curveLoop, "curve-loop", false, "Restart the breakpoint curve after its last point")
+	flags.StringVar(&sweepMode, "mode", "linear", modeUsage)
+	flags.Float64Var(&amplitude, "amplitude", 1, "Amplitude (0-1)")
+	flags.IntVar(&order, "order", 16, fmt.Sprintf("MLS order (%d-%d)", audio.MinMLSOrder, audio.MaxMLSOrder))
+	flags.IntVar(&periods, "periods", 2, "Number of MLS periods to render")
+	flags.StringVar(&calFile, "calibration", "", "Speaker calibration profile to pre-emphasise the sweep with")
+	flags.StringVar(&adsrSpec, "adsr", "", "ADSR envelope as attack,decay,sustain,release[:linear|exp|cosine]; the release ends the file")
+	flags.StringVar(&burstSpec, "burst", "", "Tone burst as on,off cycles[:hann]")
+	flags.StringVar(&amSpec, "am", "", "Amplitude modulation as rate,depth[:shape]")
+	flags.StringVar(&fmSpec, "fm", "", "Frequency modulation as rate,deviation[:shape]")
+	flags.StringVar(&logLevel, "log-level", "warn", "Log level (debug, info, warn, error)")
+	flags.String("preset", "", "Start from a saved or built-in preset (see malgoplay preset list)")
+	flags.String("preset-dir", "", "Preset directory (default: the user configuration directory)")
+	parseFlags(flags, args)
+	logger := newLogger(logLevel)
+
+	var sampleFormat audiofile.Format
+	switch format {
+	case "float32":
+		sampleFormat = audiofile.FormatFloat32
+	case "pcm16":
+		sampleFormat = audiofile.FormatPCM16
+	default:
+		log.Fatalf("Unknown sample format: %s", format)
+	}
+
+	var (
+		source audio.Source
+		frames uint32
+		tail   uint32
+		gen    *audio.FrequencySweepGenerator
+	)
+	switch signal {
+	case "sweep":
+		var err error
+		gen, err = audio.New(audio.Config{
+			MinFrequency: minFreq,
+			MaxFrequency: maxFreq,
+			SampleRate:   uint32(sampleRate),
+			Channels:     uint32(channels),
+		}, audio.WithLogger(logger))
+		if err != nil {
+			log.Fatal(err)
+		}
+		mode, err := audio.ParseSweepMode(sweepMode)
+		if err != nil {
+			log.Fatal(err)
+		}
+		gen.SetSweepMode(mode)
+		gen.SetSweepRate(sweepRate)
+		applyTiming(gen, mode, sweepTime, octavesPerSec, hzPerSec)
+		applySweepOptions(gen, direction, loops, endAction, oneShot)
+		applyRandom(gen, mode, seed, isSet(flags, "seed"), hold, glide, distribution)
+		applyNotes(gen, fromNote, toNote, scaleSpec, tuningName, a4, noteStep, glide)
+		applyCurve(gen, curveFile, curveLoop)
+		applyCarrier(gen, wavetable, harmonics)
+		gen.SetAmplitude(amplitude)
+		gen.SetCalibration(loadCalibration(calFile))
+		applyShaping(gen, adsrSpec, burstSpec)
+		applyModulation(gen, amSpec, fmSpec)
+		source = gen
+		frames = uint32(duration.Seconds() * float64(sampleRate))
+		if adsrSpec != "" {
+			adsr, _ := audio.ParseADSR(adsrSpec)
+			tail = min(frames, uint32(adsr.Release.Seconds()*float64(sampleRate)))
+		}
+	case "mls":
+		mls, err := audio.NewMLSSource(order, uint32(channels))
+		if err != nil {
+			log.Fatalf("Failed to create MLS source: %v", err)
+		}
+		mls.SetAmplitude(amplitude)
+		source = mls
+		frames = uint32(mls.Len() * periods)
+	default:
+		log.Fatalf("Unknown signal: %s", signal)
+	}
+
+	samples := source.Render(frames - tail)
+	if tail > 0 {
+		gen.ReleaseEnvelope()
+		samples = append(samples, source.Render(tail)...)
+	}
+	buf := audiofile.FromInterleaved(samples, int(channels), uint32(sampleRate))
+	if err := audiofile.WriteWAVFile(output, buf, sampleFormat); err != nil {
+		log.Fatalf("Failed to write %s: %v", output, err)
+	}
+
+	fmt.Printf("Rendered %d frames (%.2f s) of %s to %s\n", frames, float64(frames)/float64(sampleRate), signal, output)
+}
@@ -0,0 +1,51 @@
+package main
+
+import (
+	"log"
+	"os"
+
+	"github.com/hailam/malgoplay/internal/audiofile"
+	"github.com/hailam/malgoplay/internal/room"
+)
+
+func runRoom(args []string) {
+	var (
+		format  string
+		channel int
+	)
+
+	flags := newFlagSet("room")
+	flags.StringVar(&format, "format", "text", "Output format (text, csv, json)")
+	flags.IntVar(&channel, "channel", 0, "Channel of the impulse response to analyse")
+	files := parseArgs(flags, args)
+	if len(files) != 1 {
+		log.Fatal("Usage: malgoplay room [--format text|csv|json] [--channel n] ir.wav")
+	}
+
+	buf, err := audiofile.Open(files[0])
+	if err != nil {
+		log.Fatalf("Failed to read %s: %v", files[0], err)
+	}
+	if channel < 0 || channel >= buf.Channels() {
+		log.Fatalf("Channel %d out of range, %s has %d channels", channel, files[0], buf.Channels())
+	}
+
+	result, err := room.Analyze(buf.Data[channel], buf.SampleRate)
+	if err != nil {
+		log.Fatalf("Failed to analyse %s: %v", files[0], err)
+	}
+
+	switch format {
+	case "text":
+		err = result.WriteText(os.Stdout)
+	case "csv":
+		err = result.WriteCSV(os.Stdout)
+	case "json":
+		err = result.WriteJSON(os.Stdout)
+	default:
+		log.Fatalf("Unknown output format: %s", format)
+	}
+	if err != nil {
+		log.Fatal(err)
+	}
+}
@@ -0,0 +1,165 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	audio "github.com/hailam/malgoplay/internal/fsg"
+)
+
+// settingsRequest is the body of PUT /settings. Omitted fields keep their
+// value; a range change glides over GlideMs milliseconds.
+type settingsRequest struct {
+	MinFrequency *float64         `json:"min_frequency"`
+	MaxFrequency *float64         `json:"max_frequency"`
+	GlideMs      float64          `json:"glide_ms"`
+	Mode         *audio.SweepMode `json:"mode"`
+	SweepRate    *float64         `json:"sweep_rate"`
+	Amplitude    *float64         `json:"amplitude"`
+}
+
+func runServe(args []string) {
+	var (
+		addr       string
+		minFreq    float64
+		maxFreq    float64
+		sampleRate uint
+		channels   uint
+		sweepRate  float64
+		sweepMode  string
+		amplitude  float64
+		logLevel   string
+	)
+	flags := newFlagSet("serve")
+	flags.StringVar(&addr, "addr", "127.0.0.1:8080", "Address to listen on")
+	flags.Float64Var(&minFreq, "min", 220, "Minimum frequency")
+	flags.Float64Var(&maxFreq, "max", 880, "Maximum frequency")
+	flags.UintVar(&sampleRate, "rate", 44100, "Sample rate")
+	flags.UintVar(&channels, "channels", 2, "Number of channels")
+	flags.Float64Var(&sweepRate, "sweep", 1, "Sweep rate in Hz")
+	flags.StringVar(&sweepMode, "mode", "linear", modeUsage)
+	flags.Float64Var(&amplitude, "amplitude", 1, "Amplitude (0-1)")
+	flags.String("preset", "", "Start from a saved or built-in preset (see malgoplay preset list)")
+	flags.String("preset-dir", "", "Preset directory (default: the user configuration directory)")
+	flags.StringVar(&logLevel, "log-level", "info", "Log level (debug, info, warn, error)")
+	parseFlags(flags, args)
+
+	logger := newLogger(logLevel)
+	gen, err := audio.New(audio.Config{
+		MinFrequency: minFreq,
+		MaxFrequency: maxFreq,
+		SampleRate:   uint32(sampleRate),
+		Channels:     uint32(channels),
+	}, audio.WithLogger(logger))
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer gen.Close()
+
+	mode, err := audio.ParseSweepMode(sweepMode)
+	if err != nil {
+		log.Fatal(err)
+	}
+	gen.SetSweepMode(mode)
+	gen.SetSweepRate(sweepRate)
+	gen.SetAmplitude(amplitude)
+
+	server := &http.Server{Addr: addr, Handler: serveHandler(gen)}
+	go func() {
+		c := make(chan os.Signal, 1)
+		signal.Notify(c, os.Interrupt, syscall.SIGTERM)
+		<-c
+		shutdown, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		_ = server.Shutdown(shutdown)
+	}()
+
+	fmt.Printf("Serving on http://%s (GET /status, POST /start, POST /stop, PUT /settings). Press Ctrl+C to stop.\n", addr)
+	if err := server.ListenAndServe(); !errors.Is(err, http.ErrServerClosed) {
+		log.Fatalf("Server failed: %v", err)
+	}
+	if err := gen.Stop(); err != nil {
+		log.Fatalf("Failed to stop frequency sweep generator: %v", err)
+	}
+}
+
+// serveHandler returns the HTTP API of serve. Every endpoint answers with
+// the generator's status as JSON, or an {"error": ...} object.
+func serveHandler(gen *audio.FrequencySweepGenerator) http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /status", func(w http.ResponseWriter, r *http.Request) {
+		writeStatus(w, gen)
+	})
+	mux.HandleFunc("POST /start", func(w http.ResponseWriter, r *http.Request) {
+		if err := gen.Start(); err != nil {
+			writeError(w, http.StatusInternalServerError, err)
+			return
+		}
+		writeStatus(w, gen)
+	})
+	mux.HandleFunc("POST /stop", func(w http.ResponseWriter, r *http.Request) {
+		if err := gen.Stop(); err != nil {
+			writeError(w, http.StatusInternalServerError, err)
+			return
+		}
+		writeStatus(w, gen)
+	})
+	mux.HandleFunc("PUT /settings", func(w http.ResponseWriter, r *http.Request) {
+		var req settingsRequest
+		decoder := json.NewDecoder(r.Body)
+		decoder.DisallowUnknownFields()
+		if err := decoder.Decode(&req); err != nil {
+			writeError(w, http.StatusBadRequest, err)
+			return
+		}
+		if req.Amplitude != nil && (*req.Amplitude < 0 || *req.Amplitude > 1) {
+			writeError(w, http.StatusBadRequest, fmt.Errorf("amplitude must be between 0 and 1"))
+			return
+		}
+		if req.MinFrequency != nil || req.MaxFrequency != nil {
+			status := gen.Status()
+			minFreq, maxFreq := status.MinFrequency, status.MaxFrequency
+			if req.MinFrequency != nil {
+				minFreq = *req.MinFrequency
+			}
+			if req.MaxFrequency != nil {
+				maxFreq = *req.MaxFrequency
+			}
+			glide := time.Duration(req.GlideMs * float64(time.Millisecond))
+			if err := gen.SetFrequencyRange(minFreq, maxFreq, glide); err != nil {
+				writeError(w, http.StatusBadRequest, err)
+				return
+			}
+		}
+		if req.Mode != nil {
+			gen.SetSweepMode(*req.Mode)
+		}
+		if req.SweepRate != nil {
+			gen.SetSweepRate(*req.SweepRate)
+		}
+		if req.Amplitude != nil {
+			gen.SetAmplitude(*req.Amplitude)
+		}
+		writeStatus(w, gen)
+	})
+	return mux
+}
+
+func writeStatus(w http.ResponseWriter, gen *audio.FrequencySweepGenerator) {
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(gen.Status())
+}
+
+func writeError(w http.ResponseWriter, code int, err error) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	_ = json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+}
@@ -0,0 +1,106 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	audio "github.com/hailam/malgoplay/internal/fsg"
+)
+
+func newServeTest(t *testing.T) (*audio.FrequencySweepGenerator, http.Handler) {
+	t.Helper()
+	gen, err := audio.New(audio.Config{MinFrequency: 220, MaxFrequency: 880, SampleRate: 48000, Channels: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	gen.SetMockDevice(audio.NewMockDevice(48000, 2))
+	gen.SetFadeDurations(time.Millisecond, time.Millisecond)
+	return gen, serveHandler(gen)
+}
+
+func serveRequest(t *testing.T, handler http.Handler, method, path, body string) (int, map[string]any) {
+	t.Helper()
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(method, path, strings.NewReader(body)))
+	var response map[string]any
+	if err := json.Unmarshal(rec.Body.Bytes(), &response); err != nil {
+		t.Fatalf("%s %s: invalid JSON response %q", method, path, rec.Body.String())
+	}
+	return rec.Code, response
+}
+
+func TestServeSettings(t *testing.T) {
+	gen, handler := newServeTest(t)
+
+	code, status := serveRequest(t, handler, http.MethodPut, "/settings",
+		`{"min_frequency": 100, "max_frequency": 1000, "mode": "octaves", "sweep_rate": 0.5, "amplitude": 0.25}`)
+	if code != http.StatusOK {
+		t.Fatalf("Incorrect status code: got %d, want 200: %v", code, status)
+	}
+	want := map[string]any{"min_frequency": 100.0, "max_frequency": 1000.0, "mode": "octaves", "sweep_rate": 0.5, "amplitude": 0.25}
+	for key, value := range want {
+		if status[key] != value {
+			t.Errorf("Incorrect %s in the response: got %v, want %v", key, status[key], value)
+		}
+	}
+	if s := gen.Status(); s.MinFrequency != 100 || s.Mode != audio.SweepModeOctaves {
+		t.Errorf("Settings were not applied to the generator: %+v", s)
+	}
+
+	// A glide reports the range being glided to straight away.
+	if code, status := serveRequest(t, handler, http.MethodPut, "/settings", `{"max_frequency": 2000, "glide_ms": 500}`); code != http.StatusOK || status["max_frequency"] != 2000.0 {
+		t.Errorf("Glide failed: %d %v", code, status)
+	}
+}
+
+func TestServeSettingsErrors(t *testing.T) {
+	gen, handler := newServeTest(t)
+	before := gen.Status()
+
+	tests := []struct{ name, body string }{
+		{"invalid JSON", `{"amplitude":`},
+		{"unknown field", `{"volume": 1}`},
+		{"unknown mode", `{"mode": "wobble"}`},
+		{"amplitude above 1", `{"amplitude": 1.5}`},
+		{"negative amplitude", `{"amplitude": -0.1}`},
+		{"inverted range", `{"min_frequency": 900}`},
+		{"above Nyquist", `{"max_frequency": 30000}`},
+		{"invalid range with valid amplitude", `{"min_frequency": 900, "amplitude": 0.1}`},
+	}
+	for _, tc := range tests {
+		code, response := serveRequest(t, handler, http.MethodPut, "/settings", tc.body)
+		if code != http.StatusBadRequest {
+			t.Errorf("%s: incorrect status code: got %d, want 400", tc.name, code)
+		}
+		if msg, _ := response["error"].(string); msg == "" {
+			t.Errorf("%s: missing error message: %v", tc.name, response)
+		}
+	}
+	if after := gen.Status(); after != before {
+		t.Errorf("Rejected settings changed the generator: %+v, was %+v", after, before)
+	}
+}
+
+func TestServeStartStop(t *testing.T) {
+	_, handler := newServeTest(t)
+
+	if code, status := serveRequest(t, handler, http.MethodPost, "/start", ""); code != http.StatusOK || status["playing"] != true {
+		t.Errorf("Start failed: %d %v", code, status)
+	}
+	if code, status := serveRequest(t, handler, http.MethodGet, "/status", ""); code != http.StatusOK || status["playing"] != true {
+		t.Errorf("Incorrect status: %d %v", code, status)
+	}
+	if code, status := serveRequest(t, handler, http.MethodPost, "/stop", ""); code != http.StatusOK || status["playing"] != false {
+		t.Errorf("Stop failed: %d %v", code, status)
+	}
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/start", nil))
+	if rec.Code != http.StatusMethodNotAllowed {
+		t.Errorf("GET /start: got %d, want 405", rec.Code)
+	}
+}
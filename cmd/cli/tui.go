@@ -0,0 +1,60 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+
+	audio "github.com/hailam/malgoplay/internal/fsg"
+	"github.com/hailam/malgoplay/internal/tui"
+)
+
+// rawTerminal switches the terminal on stdin to non-canonical mode without
+// echo, so that key presses arrive without Enter, and returns a function
+// that restores it. Ctrl+C still interrupts.
+func rawTerminal() (restore func(), err error) {
+	if info, err := os.Stdin.Stat(); err != nil || info.Mode()&os.ModeCharDevice == 0 {
+		return nil, fmt.Errorf("stdin is not a terminal")
+	}
+	state, err := stty("-g")
+	if err != nil {
+		return nil, err
+	}
+	if _, err := stty("-icanon", "-echo", "min", "1"); err != nil {
+		return nil, err
+	}
+	return func() { _, _ = stty(strings.TrimSpace(state)) }, nil
+}
+
+func stty(args ...string) (string, error) {
+	cmd := exec.Command("stty", args...)
+	cmd.Stdin = os.Stdin
+	out, err := cmd.Output()
+	if err != nil {
+		return "", fmt.Errorf("stty %s: %w", strings.Join(args, " "), err)
+	}
+	return string(out), nil
+}
+
+// runInteractive drives the playing generator from the keyboard until the
+// user quits, the duration ends, a one-shot sweep completes or an interrupt
+// arrives.
+func runInteractive(gen *audio.FrequencySweepGenerator, timeout <-chan time.Time, interrupt <-chan os.Signal) {
+	done := make(chan struct{})
+	go func() {
+		select {
+		case <-timeout:
+		case <-gen.Done():
+		case <-interrupt:
+		}
+		close(done)
+	}()
+
+	ui := tui.New(gen, tui.NewTerminalKeys(os.Stdin), os.Stdout)
+	if err := ui.Run(done); err != nil {
+		log.Printf("Keyboard input failed: %v", err)
+	}
+}
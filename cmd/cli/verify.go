@@ -0,0 +1,144 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/hailam/malgoplay/internal/fsgdx"
+)
+
+// exitBelowThreshold is the exit status of verify when the sweep ran but
+// matched less often than --threshold.
+const exitBelowThreshold = 2
+
+func runVerify(args []string) {
+	var (
+		minFreq    float64
+		maxFreq    float64
+		sweepRate  float64
+		duration   time.Duration
+		report     string
+		threshold  float64
+		quiet      bool
+		tolerance  string
+		smoothing  string
+		hysteresis float64
+		calFile    string
+		outCalFile string
+	)
+
+	flags := newFlagSet("verify")
+	flags.Float64Var(&minFreq, "min", 220, "Minimum frequency")
+	flags.Float64Var(&maxFreq, "max", 880, "Maximum frequency")
+	flags.Float64Var(&sweepRate, "sweep", 50, "Sweep rate in Hz per second")
+	flags.DurationVar(&duration, "duration", 0, "Run time (default: one sweep up and back down)")
+	flags.StringVar(&report, "report", "", "Write the report to this .json or .csv file")
+	flags.Float64Var(&threshold, "threshold", 90, "Minimum match percentage for a zero exit status")
+	flags.BoolVar(&quiet, "quiet", false, "Do not print the live status line")
+	flags.StringVar(&tolerance, "tolerance", "5%", "Match tolerance (e.g. 5%, 10Hz, 20c)")
+	flags.StringVar(&smoothing, "smoothing", "average:10", "Detection smoothing (none, average:N, median:N, ema:ALPHA, kalman:Q,R)")
+	flags.Float64Var(&hysteresis, "hysteresis", fsgdx.DefaultHysteresis, "Extra tolerance fraction before a MATCH turns into a MISMATCH")
+	flags.StringVar(&calFile, "calibration", "", "Microphone calibration profile to correct the captured levels with")
+	flags.StringVar(&outCalFile, "output-calibration", "", "Speaker calibration profile to pre-emphasise the output with")
+	parseFlags(flags, args)
+
+	tol, err := fsgdx.ParseTolerance(tolerance)
+	if err != nil {
+		log.Fatal(err)
+	}
+	smoother, err := fsgdx.ParseSmoother(smoothing)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if hysteresis < 0 {
+		log.Fatal("Hysteresis must not be negative")
+	}
+	fsgdx.SetTolerance(tol, hysteresis)
+	fsgdx.SetSmoothing(smoother)
+
+	if minFreq <= 0 || maxFreq <= minFreq || sweepRate <= 0 {
+		log.Fatal("Need 0 < --min < --max and a positive --sweep")
+	}
+	if duration == 0 {
+		duration = time.Duration(2 * (maxFreq - minFreq) / sweepRate * float64(time.Second))
+	}
+
+	const step = 50 * time.Millisecond
+	fsgdx.MinFrequency = minFreq
+	fsgdx.MaxFrequency = maxFreq
+	fsgdx.SweepRate = sweepRate * step.Seconds()
+	fsgdx.SweepDirection = 1
+	fsgdx.SetFrequency(minFreq)
+	fsgdx.Quiet = quiet
+	fsgdx.InputCalibration = loadCalibration(calFile)
+	fsgdx.OutputCalibration = loadCalibration(outCalFile)
+	fsgdx.Recorder = fsgdx.NewVerificationRecorder(fsgdx.SampleRate)
+
+	device, err := fsgdx.InitDevice()
+	if err != nil {
+		log.Fatalf("Failed to initialize duplex device: %v", err)
+	}
+
+	if err := device.Start(); err != nil {
+		fsgdx.CleanupDevice(device)
+		log.Fatalf("Failed to start duplex device: %v", err)
+	}
+
+	fmt.Printf("Verifying %.0f-%.0f Hz at %.1f Hz/s for %v...\n", minFreq, maxFreq, sweepRate, duration)
+	ticker := time.NewTicker(step)
+	deadline := time.After(duration)
+loop:
+	for {
+		select {
+		case <-ticker.C:
+			fsgdx.UpdateFrequency()
+		case <-deadline:
+			break loop
+		}
+	}
+	ticker.Stop()
+	// Release the device before reporting, which may exit without running
+	// deferred calls.
+	_ = device.Stop()
+	fsgdx.CleanupDevice(device)
+
+	result := fsgdx.Recorder.Report()
+	fmt.Printf("\nMatched %d/%d samples (%.1f%%), max deviation %.2f Hz (%.1f%%), mean |deviation| %.2f Hz\n",
+		result.Matches, result.Samples, result.MatchPercent, result.MaxDeviation, result.MaxDeviationPercent, result.MeanAbsDeviation)
+	for _, rng := range result.FailedRanges {
+		fmt.Printf("  Failed: %.1f-%.1f Hz\n", rng.From, rng.To)
+	}
+
+	if report != "" {
+		if err := writeVerificationReport(report, result); err != nil {
+			log.Fatalf("Failed to write report: %v", err)
+		}
+	}
+
+	if result.MatchPercent < threshold {
+		fmt.Printf("Match rate %.1f%% is below the %.1f%% threshold\n", result.MatchPercent, threshold)
+		os.Exit(exitBelowThreshold)
+	}
+}
+
+func writeVerificationReport(path string, report fsgdx.VerificationReport) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+
+	if strings.EqualFold(filepath.Ext(path), ".csv") {
+		err = report.WriteCSV(f)
+	} else {
+		err = report.WriteJSON(f)
+	}
+	if err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
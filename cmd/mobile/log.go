@@ -0,0 +1,51 @@
+//go:build (linux && cgo) || (darwin && cgo) || windows
+
+package mobile_fsg_main
+
+import (
+	"log/slog"
+	"strings"
+	"sync"
+)
+
+// LogSink receives the generator's log output, one formatted line per
+// call, e.g. to forward it to Logcat or os_log. Log may be called from any
+// thread, but never from the audio callback.
+type LogSink interface {
+	Log(line string)
+}
+
+var (
+	sink      LogSink
+	sinkMutex sync.Mutex
+	logLevel  slog.LevelVar
+	logger    = slog.New(slog.NewTextHandler(sinkWriter{}, &slog.HandlerOptions{Level: &logLevel}))
+)
+
+// sinkWriter passes the lines of the text handler to the sink. It has its
+// own lock because the generator logs while the mobile API holds mutex.
+type sinkWriter struct{}
+
+func (sinkWriter) Write(p []byte) (int, error) {
+	sinkMutex.Lock()
+	defer sinkMutex.Unlock()
+	if sink != nil {
+		sink.Log(strings.TrimRight(string(p), "\n"))
+	}
+	return len(p), nil
+}
+
+// SetLogSink sends log lines at or above level ("debug", "info", "warn" or
+// "error") to s. A nil sink turns logging off. It may be called before or
+// after InitializeAudio.
+func SetLogSink(s LogSink, level string) error {
+	var l slog.Level
+	if err := l.UnmarshalText([]byte(level)); err != nil {
+		return err
+	}
+	sinkMutex.Lock()
+	defer sinkMutex.Unlock()
+	sink = s
+	logLevel.Set(l)
+	return nil
+}
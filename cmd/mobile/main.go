@@ -5,6 +5,7 @@ package mobile_fsg_main
 import (
 	"errors"
 	"fmt"
+	"strings"
 	"sync"
 	"time"
 
@@ -23,10 +24,19 @@ func InitializeAudio(minFreq, maxFreq float64, sampleRate, channels int) error {
 	mutex.Lock()
 	defer mutex.Unlock()
 
-	sampleRate32 := uint32(sampleRate)
-	channels32 := uint32(channels)
-
-	gen = audio.NewFrequencySweepGenerator(minFreq, maxFreq, sampleRate32, channels32)
+	if sampleRate < 0 || channels < 0 {
+		return fmt.Errorf("sample rate and channels must not be negative, got %d and %d", sampleRate, channels)
+	}
+	g, err := audio.New(audio.Config{
+		MinFrequency: minFreq,
+		MaxFrequency: maxFreq,
+		SampleRate:   uint32(sampleRate),
+		Channels:     uint32(channels),
+	}, audio.WithLogger(logger))
+	if err != nil {
+		return err
+	}
+	gen = g
 
 	if err := gen.Initialize(); err != nil {
 		return fmt.Errorf("failed to initialize audio: %w", err)
@@ -50,7 +60,7 @@ func StartAudio(durationSeconds int) error {
 	}
 
 	if err := gen.Start(); err != nil {
-		fmt.Println("Failed to start device", err)
+		logger.Error("audio device failed to start", "error", err)
 		return fmt.Errorf("failed to start device: %w", err)
 	}
 
@@ -91,6 +101,50 @@ func StopAudio() error {
 	return gen.Stop()
 }
 
+// PauseAudio fades out and pauses playback, keeping the sweep position.
+// The duration given to StartAudio keeps running while paused.
+func PauseAudio() error {
+	mutex.Lock()
+	defer mutex.Unlock()
+
+	if gen == nil {
+		return errors.New("audio not initialized")
+	}
+	if !isPlaying {
+		return nil
+	}
+	return gen.Pause()
+}
+
+// ResumeAudio fades playback back in from where it was paused.
+func ResumeAudio() error {
+	mutex.Lock()
+	defer mutex.Unlock()
+
+	if gen == nil {
+		return errors.New("audio not initialized")
+	}
+	return gen.Resume()
+}
+
+// IsPaused reports whether playback is paused.
+func IsPaused() bool {
+	mutex.Lock()
+	defer mutex.Unlock()
+	return gen != nil && gen.Paused()
+}
+
+// Seek jumps to a point of the sweep, from 0 (start) to 1 (end).
+func Seek(fraction float64) error {
+	mutex.Lock()
+	defer mutex.Unlock()
+
+	if gen == nil {
+		return errors.New("audio not initialized")
+	}
+	return gen.Seek(fraction)
+}
+
 func SetAmplitude(amplitude float64) {
 	mutex.Lock()
 	defer mutex.Unlock()
@@ -100,6 +154,34 @@ func SetAmplitude(amplitude float64) {
 	}
 }
 
+// SetFrequencyRange changes the sweep range while playing, gliding to the
+// new limits over glideMs milliseconds, so range sliders can update live.
+func SetFrequencyRange(minFreq, maxFreq, glideMs float64) error {
+	mutex.Lock()
+	defer mutex.Unlock()
+
+	if gen == nil {
+		return errors.New("audio not initialized")
+	}
+	return gen.SetFrequencyRange(minFreq, maxFreq, time.Duration(glideMs*float64(time.Millisecond)))
+}
+
+// Reconfigure changes the sample rate and channel count without
+// re-initializing the generator; a playing sweep carries on in the new
+// format.
+func Reconfigure(sampleRate, channels int) error {
+	mutex.Lock()
+	defer mutex.Unlock()
+
+	if gen == nil {
+		return errors.New("audio not initialized")
+	}
+	if sampleRate < 0 || channels < 0 {
+		return fmt.Errorf("sample rate and channels must not be negative, got %d and %d", sampleRate, channels)
+	}
+	return gen.Reconfigure(uint32(sampleRate), uint32(channels))
+}
+
 // SetSweepRate sets the sweep rate
 func SetSweepRate(rate float64) {
 	mutex.Lock()
@@ -110,6 +192,40 @@ func SetSweepRate(rate float64) {
 	}
 }
 
+// SetSweepDuration sets the time of a one-way sweep in seconds
+func SetSweepDuration(seconds float64) error {
+	mutex.Lock()
+	defer mutex.Unlock()
+
+	if gen == nil {
+		return errors.New("audio not initialized")
+	}
+	return gen.SetSweepDuration(time.Duration(seconds * float64(time.Second)))
+}
+
+// SetOctavesPerSecond sets the sweep speed in octaves per second, which
+// is constant in the octaves mode (SetSweepMode(8)) only
+func SetOctavesPerSecond(octaves float64) error {
+	mutex.Lock()
+	defer mutex.Unlock()
+
+	if gen == nil {
+		return errors.New("audio not initialized")
+	}
+	return gen.SetOctavesPerSecond(octaves)
+}
+
+// SetHzPerSecond sets a constant linear sweep speed
+func SetHzPerSecond(hz float64) error {
+	mutex.Lock()
+	defer mutex.Unlock()
+
+	if gen == nil {
+		return errors.New("audio not initialized")
+	}
+	return gen.SetHzPerSecond(hz)
+}
+
 // SetSweepMode sets the sweep mode
 func SetSweepMode(mode int) {
 	mutex.Lock()
@@ -120,6 +236,175 @@ func SetSweepMode(mode int) {
 	}
 }
 
+// AddFilter appends a biquad to one channel's filter chain, or to every
+// channel when channel is -1. filterType follows audio.FilterType:
+// 0 peaking, 1 low shelf, 2 high shelf, 3 low-pass, 4 high-pass, 5 notch,
+// 6 all-pass.
+func AddFilter(channel, filterType int, freq, q, gainDB float64) error {
+	mutex.Lock()
+	defer mutex.Unlock()
+
+	if gen == nil {
+		return errors.New("audio not initialized")
+	}
+	return gen.AddFilter(channel, audio.FilterType(filterType), freq, q, gainDB)
+}
+
+// ClearFilters removes one channel's filters, or all of them when channel
+// is -1.
+func ClearFilters(channel int) {
+	mutex.Lock()
+	defer mutex.Unlock()
+
+	if gen != nil {
+		gen.ClearFilters(channel)
+	}
+}
+
+// SetAM modulates the amplitude with an LFO of the given sweep mode shape,
+// rate in Hz and depth (0-1). A depth of 0 turns AM off.
+func SetAM(shape int, rate, depth float64) error {
+	mutex.Lock()
+	defer mutex.Unlock()
+
+	if gen == nil {
+		return errors.New("audio not initialized")
+	}
+	return gen.SetAM(&audio.Modulator{Shape: audio.SweepMode(shape), Rate: rate, Depth: depth})
+}
+
+// SetFM modulates the frequency with an LFO of the given sweep mode shape,
+// rate in Hz and deviation in Hz. A deviation of 0 turns FM off.
+func SetFM(shape int, rate, deviation float64) error {
+	mutex.Lock()
+	defer mutex.Unlock()
+
+	if gen == nil {
+		return errors.New("audio not initialized")
+	}
+	return gen.SetFM(&audio.Modulator{Shape: audio.SweepMode(shape), Rate: rate, Depth: deviation})
+}
+
+// SetSweepOptions sets the sweep direction (0 up, 1 down, 2 up-down), the
+// number of passes (0 for endless) and the end action (0 hold, 1 stop).
+// A sweep that stops on its own also ends playback.
+func SetSweepOptions(direction, loops, endAction int) error {
+	mutex.Lock()
+	defer mutex.Unlock()
+
+	if gen == nil {
+		return errors.New("audio not initialized")
+	}
+	return gen.SetSweepOptions(&audio.SweepOptions{
+		Direction: audio.Direction(direction),
+		Loops:     loops,
+		End:       audio.EndAction(endAction),
+		OnComplete: func() {
+			if audio.EndAction(endAction) == audio.EndStop {
+				_ = StopAudio()
+			}
+		},
+	})
+}
+
+// SetSeed makes the random sweep mode reproducible
+func SetSeed(seed int64) {
+	mutex.Lock()
+	defer mutex.Unlock()
+
+	if gen != nil {
+		gen.SetSeed(seed)
+	}
+}
+
+// SetRandomOptions sets how long each random frequency is held and glided
+// to, in milliseconds, and the distribution (0 uniform in Hz, 1 uniform in
+// log frequency).
+func SetRandomOptions(holdMs, glideMs float64, distribution int) error {
+	mutex.Lock()
+	defer mutex.Unlock()
+
+	if gen == nil {
+		return errors.New("audio not initialized")
+	}
+	return gen.SetRandomOptions(audio.RandomOptions{
+		Hold:         time.Duration(holdMs * float64(time.Millisecond)),
+		Glide:        time.Duration(glideMs * float64(time.Millisecond)),
+		Distribution: audio.RandomDistribution(distribution),
+	})
+}
+
+// SetNoteSweep steps through a scale from one note to another, e.g. "A2"
+// to "A5" over "major" or a comma-separated cents list. tuning is 0 for
+// equal temperament and 1 for just intonation; step and glide are in
+// milliseconds.
+func SetNoteSweep(from, to, scale string, tuning int, a4, stepMs, glideMs float64) error {
+	mutex.Lock()
+	defer mutex.Unlock()
+
+	if gen == nil {
+		return errors.New("audio not initialized")
+	}
+	fromNote, err := audio.ParseNote(from)
+	if err != nil {
+		return err
+	}
+	toNote, err := audio.ParseNote(to)
+	if err != nil {
+		return err
+	}
+	steps, err := audio.ParseScale(scale)
+	if err != nil {
+		return err
+	}
+	frequencies, err := audio.ScaleFrequencies(fromNote, toNote, steps, audio.Tuning(tuning), a4)
+	if err != nil {
+		return err
+	}
+	return gen.SetNoteSweep(frequencies,
+		time.Duration(stepMs*float64(time.Millisecond)),
+		time.Duration(glideMs*float64(time.Millisecond)))
+}
+
+// SetBreakpoints sweeps along a custom curve given as JSON or CSV text,
+// in the format of the CLI's --curve files. With loop set the curve
+// restarts after its last point.
+func SetBreakpoints(spec string, loop bool) error {
+	mutex.Lock()
+	defer mutex.Unlock()
+
+	if gen == nil {
+		return errors.New("audio not initialized")
+	}
+	curve, err := audio.ParseBreakpoints(strings.NewReader(spec))
+	if err != nil {
+		return err
+	}
+	return gen.SetBreakpoints(curve, loop)
+}
+
+// SetHarmonics replaces the sine carrier with the sum of harmonics given as
+// "number:amplitude[@phase]" entries, e.g. "1:1.0,2:0.01,3:0.003". An empty
+// profile restores the sine.
+func SetHarmonics(profile string) error {
+	mutex.Lock()
+	defer mutex.Unlock()
+
+	if gen == nil {
+		return errors.New("audio not initialized")
+	}
+	if profile == "" {
+		gen.SetWaveform(nil)
+		return nil
+	}
+	harmonics, err := audio.ParseHarmonics(profile)
+	if err != nil {
+		return err
+	}
+	gen.SetWaveform(harmonics)
+	return nil
+}
+
 // IsPlaying returns the current playback state
 func IsPlaying() bool {
 	mutex.Lock()
@@ -0,0 +1,67 @@
+//go:build (linux && cgo) || (darwin && cgo) || windows
+
+package mobile_fsg_main
+
+import (
+	"encoding/json"
+	"errors"
+
+	"github.com/hailam/malgoplay/internal/preset"
+)
+
+// Presets are kept in a presets.json file in dir, which on Android should
+// be the app's files directory (Context.getFilesDir).
+
+// ListPresets returns the built-in and saved presets as a JSON array.
+func ListPresets(dir string) (string, error) {
+	presets, err := preset.NewStore(dir).List()
+	if err != nil {
+		return "", err
+	}
+	data, err := json.Marshal(presets)
+	return string(data), err
+}
+
+// LoadPreset returns a preset as a JSON object, e.g. to fill in sliders.
+func LoadPreset(dir, name string) (string, error) {
+	p, err := preset.NewStore(dir).Load(name)
+	if err != nil {
+		return "", err
+	}
+	data, err := json.Marshal(p)
+	return string(data), err
+}
+
+// ApplyPreset sets the range, mode, sweep rate and amplitude of the
+// initialized generator from a preset.
+func ApplyPreset(dir, name string) error {
+	p, err := preset.NewStore(dir).Load(name)
+	if err != nil {
+		return err
+	}
+
+	mutex.Lock()
+	defer mutex.Unlock()
+	if gen == nil {
+		return errors.New("audio not initialized")
+	}
+	return p.Apply(gen)
+}
+
+// SavePreset stores the given settings under name, replacing a saved
+// preset of the same name. mode is a sweep mode name such as "octaves".
+func SavePreset(dir, name, description string, minFreq, maxFreq float64, mode string, sweepRate, amplitude float64) error {
+	return preset.NewStore(dir).Save(preset.Preset{
+		Name:         name,
+		Description:  description,
+		MinFrequency: minFreq,
+		MaxFrequency: maxFreq,
+		Mode:         mode,
+		SweepRate:    sweepRate,
+		Amplitude:    amplitude,
+	})
+}
+
+func DeletePreset(dir, name string) error {
+	return preset.NewStore(dir).Delete(name)
+}
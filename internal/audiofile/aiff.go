@@ -0,0 +1,124 @@
+package audiofile
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+	"strings"
+)
+
+// ReadAIFF decodes an AIFF or AIFC stream. AIFC files may be uncompressed
+// big-endian ("NONE"), little-endian ("sowt") or float ("fl32", "fl64").
+func ReadAIFF(r io.Reader) (*Buffer, error) {
+	var form struct {
+		ID   [4]byte
+		Size uint32
+		Type [4]byte
+	}
+	if err := binary.Read(r, binary.BigEndian, &form); err != nil {
+		return nil, err
+	}
+	if string(form.ID[:]) != "FORM" {
+		return nil, fmt.Errorf("audiofile: not an IFF stream")
+	}
+
+	meta := Metadata{Compression: "NONE"}
+	switch string(form.Type[:]) {
+	case "AIFF":
+		meta.Container = "aiff"
+	case "AIFC":
+		meta.Container = "aifc"
+	default:
+		return nil, fmt.Errorf("audiofile: unsupported IFF form type %q", form.Type[:])
+	}
+
+	var (
+		channels   int
+		sampleRate uint32
+		haveCommon bool
+	)
+	for {
+		var chunk struct {
+			ID   [4]byte
+			Size uint32
+		}
+		if err := binary.Read(r, binary.BigEndian, &chunk); err != nil {
+			return nil, fmt.Errorf("audiofile: missing SSND chunk: %w", err)
+		}
+		isSound := string(chunk.ID[:]) == "SSND"
+		body, err := readChunk(r, chunk.Size, isSound)
+		if err != nil {
+			return nil, err
+		}
+
+		switch string(chunk.ID[:]) {
+		case "COMM":
+			if len(body) < 18 {
+				return nil, fmt.Errorf("audiofile: COMM chunk too short")
+			}
+			channels = int(binary.BigEndian.Uint16(body[0:2]))
+			meta.BitDepth = int(binary.BigEndian.Uint16(body[6:8]))
+			meta.ValidBits = meta.BitDepth
+			sampleRate = uint32(math.Round(extendedToFloat64(body[8:18])))
+			if meta.Container == "aifc" && len(body) >= 22 {
+				meta.Compression = string(body[18:22])
+			}
+			haveCommon = true
+		case "SSND":
+			if !haveCommon {
+				return nil, fmt.Errorf("audiofile: SSND chunk before COMM chunk")
+			}
+			if channels == 0 {
+				return nil, fmt.Errorf("audiofile: stream has no channels")
+			}
+			if len(body) < 8 {
+				return nil, fmt.Errorf("audiofile: SSND chunk too short")
+			}
+			offset := 8 + int(binary.BigEndian.Uint32(body[0:4]))
+			if offset > len(body) {
+				return nil, fmt.Errorf("audiofile: SSND offset beyond chunk")
+			}
+
+			var order binary.ByteOrder = binary.BigEndian
+			switch strings.ToLower(meta.Compression) {
+			case "none":
+				meta.Encoding = EncodingPCM
+			case "sowt":
+				meta.Encoding = EncodingPCM
+				order = binary.LittleEndian
+			case "fl32":
+				meta.Encoding, meta.BitDepth = EncodingFloat, 32
+			case "fl64":
+				meta.Encoding, meta.BitDepth = EncodingFloat, 64
+			default:
+				return nil, fmt.Errorf("audiofile: unsupported AIFC compression %q", meta.Compression)
+			}
+
+			width := (meta.BitDepth + 7) / 8
+			decode, err := sampleDecoder(meta.Encoding, width*8, order, false)
+			if err != nil {
+				return nil, err
+			}
+			buf := deinterleave(body[offset:], channels, width, decode, sampleRate)
+			buf.Metadata = meta
+			return buf, nil
+		}
+	}
+}
+
+// extendedToFloat64 converts an 80-bit IEEE 754 extended precision number,
+// used for the AIFF sample rate.
+func extendedToFloat64(b []byte) float64 {
+	exponent := int(binary.BigEndian.Uint16(b[0:2]))
+	mantissa := binary.BigEndian.Uint64(b[2:10])
+	sign := 1.0
+	if exponent&0x8000 != 0 {
+		sign = -1
+		exponent &= 0x7fff
+	}
+	if exponent == 0 && mantissa == 0 {
+		return 0
+	}
+	return sign * math.Ldexp(float64(mantissa), exponent-16383-63)
+}
@@ -0,0 +1,75 @@
+package audiofile
+
+import "time"
+
+type Encoding int
+
+const (
+	EncodingPCM Encoding = iota
+	EncodingFloat
+)
+
+// Metadata describes how a decoded file was stored.
+type Metadata struct {
+	Container   string
+	Encoding    Encoding
+	BitDepth    int
+	ValidBits   int
+	ChannelMask uint32
+	Compression string
+}
+
+// Buffer holds deinterleaved samples, nominally in the range [-1, 1].
+type Buffer struct {
+	SampleRate uint32
+	Data       [][]float64
+	Metadata   Metadata
+}
+
+func NewBuffer(sampleRate uint32, channels, frames int) *Buffer {
+	data := make([][]float64, channels)
+	for i := range data {
+		data[i] = make([]float64, frames)
+	}
+	return &Buffer{SampleRate: sampleRate, Data: data}
+}
+
+// FromInterleaved splits interleaved float32 samples, as produced by the
+// generator callbacks, into a Buffer.
+func FromInterleaved(samples []float32, channels int, sampleRate uint32) *Buffer {
+	buf := NewBuffer(sampleRate, channels, len(samples)/channels)
+	for i, sample := range samples[:buf.Frames()*channels] {
+		buf.Data[i%channels][i/channels] = float64(sample)
+	}
+	return buf
+}
+
+func (b *Buffer) Channels() int {
+	return len(b.Data)
+}
+
+func (b *Buffer) Frames() int {
+	if len(b.Data) == 0 {
+		return 0
+	}
+	return len(b.Data[0])
+}
+
+func (b *Buffer) Duration() time.Duration {
+	if b.SampleRate == 0 {
+		return 0
+	}
+	return time.Duration(float64(b.Frames()) / float64(b.SampleRate) * float64(time.Second))
+}
+
+// Interleaved returns the samples interleaved frame by frame.
+func (b *Buffer) Interleaved() []float32 {
+	channels := b.Channels()
+	out := make([]float32, b.Frames()*channels)
+	for c, data := range b.Data {
+		for i, sample := range data {
+			out[i*channels+c] = float32(sample)
+		}
+	}
+	return out
+}
@@ -0,0 +1,219 @@
+package audiofile
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"math"
+	"testing"
+)
+
+var testSamples = []float64{0, 0.5, -0.5, 0.25, -1, 0.75}
+
+func wavStream(fmtChunk, data []byte) []byte {
+	var b bytes.Buffer
+	b.WriteString("RIFF")
+	binary.Write(&b, binary.LittleEndian, uint32(20+len(fmtChunk)+len(data)))
+	b.WriteString("WAVEfmt ")
+	binary.Write(&b, binary.LittleEndian, uint32(len(fmtChunk)))
+	b.Write(fmtChunk)
+	b.WriteString("data")
+	binary.Write(&b, binary.LittleEndian, uint32(len(data)))
+	b.Write(data)
+	return b.Bytes()
+}
+
+func wavFormat(tag, channels uint16, sampleRate uint32, bits uint16) []byte {
+	var b bytes.Buffer
+	blockAlign := channels * bits / 8
+	for _, field := range []any{tag, channels, sampleRate, sampleRate * uint32(blockAlign), blockAlign, bits} {
+		binary.Write(&b, binary.LittleEndian, field)
+	}
+	return b.Bytes()
+}
+
+// aiffStream builds an AIFF (compression == "") or AIFC stream at 44.1 kHz.
+func aiffStream(channels, bits uint16, compression string, data []byte) []byte {
+	var comm bytes.Buffer
+	binary.Write(&comm, binary.BigEndian, channels)
+	binary.Write(&comm, binary.BigEndian, uint32(len(data)/int(channels*((bits+7)/8))))
+	binary.Write(&comm, binary.BigEndian, bits)
+	comm.Write([]byte{0x40, 0x0E, 0xAC, 0x44, 0, 0, 0, 0, 0, 0}) // 44100 as 80-bit extended
+	formType := "AIFF"
+	if compression != "" {
+		formType = "AIFC"
+		comm.WriteString(compression)
+		comm.Write([]byte{0, 0})
+	}
+
+	var b bytes.Buffer
+	b.WriteString("FORM")
+	binary.Write(&b, binary.BigEndian, uint32(4+8+comm.Len()+8+8+len(data)))
+	b.WriteString(formType)
+	b.WriteString("COMM")
+	binary.Write(&b, binary.BigEndian, uint32(comm.Len()))
+	b.Write(comm.Bytes())
+	b.WriteString("SSND")
+	binary.Write(&b, binary.BigEndian, uint32(8+len(data)))
+	binary.Write(&b, binary.BigEndian, [2]uint32{0, 0})
+	b.Write(data)
+	return b.Bytes()
+}
+
+func checkSamples(t *testing.T, name string, buf *Buffer, tolerance float64) {
+	t.Helper()
+	got := buf.Interleaved()
+	if len(got) != len(testSamples) {
+		t.Fatalf("%s: got %d samples, want %d", name, len(got), len(testSamples))
+	}
+	for i, want := range testSamples {
+		if math.Abs(float64(got[i])-want) > tolerance {
+			t.Errorf("%s: sample %d = %v, want %v", name, i, got[i], want)
+		}
+	}
+}
+
+func TestWAVRoundTrip(t *testing.T) {
+	for _, format := range []Format{FormatPCM16, FormatFloat32} {
+		src := FromInterleaved([]float32{0, 0.5, -0.5, 0.25, -1, 0.75}, 2, 48000)
+		var b bytes.Buffer
+		if err := WriteWAV(&b, src, format); err != nil {
+			t.Fatalf("WriteWAV failed: %v", err)
+		}
+		buf, err := Decode(&b)
+		if err != nil {
+			t.Fatalf("Decode failed: %v", err)
+		}
+		if buf.SampleRate != 48000 || buf.Channels() != 2 || buf.Frames() != 3 {
+			t.Errorf("Unexpected layout: %d Hz, %d channels, %d frames", buf.SampleRate, buf.Channels(), buf.Frames())
+		}
+		checkSamples(t, "round trip", buf, 1.0/32768)
+	}
+}
+
+func TestReadWAVEncodings(t *testing.T) {
+	pcm8 := make([]byte, len(testSamples))
+	pcm24 := make([]byte, 3*len(testSamples))
+	pcm32 := make([]byte, 4*len(testSamples))
+	float64s := make([]byte, 8*len(testSamples))
+	for i, v := range testSamples {
+		pcm8[i] = byte(int(v*128) + 128)
+		s24 := int32(v * (1 << 23))
+		pcm24[3*i], pcm24[3*i+1], pcm24[3*i+2] = byte(s24), byte(s24>>8), byte(s24>>16)
+		binary.LittleEndian.PutUint32(pcm32[4*i:], uint32(int32(v*(1<<31-1))))
+		binary.LittleEndian.PutUint64(float64s[8*i:], math.Float64bits(v))
+	}
+
+	extensible := wavFormat(wavFormatExtensible, 1, 44100, 32)
+	extensible = binary.LittleEndian.AppendUint16(extensible, 22)
+	extensible = binary.LittleEndian.AppendUint16(extensible, 24)
+	extensible = binary.LittleEndian.AppendUint32(extensible, 0x4)
+	extensible = append(extensible, 0x01, 0x00, 0x00, 0x00, 0x00, 0x00, 0x10, 0x00, 0x80, 0x00, 0x00, 0xAA, 0x00, 0x38, 0x9B, 0x71)
+
+	tests := []struct {
+		name      string
+		stream    []byte
+		tolerance float64
+		encoding  Encoding
+		validBits int
+	}{
+		{"pcm8", wavStream(wavFormat(wavFormatPCM, 1, 44100, 8), pcm8), 1.0 / 128, EncodingPCM, 8},
+		{"pcm24", wavStream(wavFormat(wavFormatPCM, 1, 44100, 24), pcm24), 1e-6, EncodingPCM, 24},
+		{"float64", wavStream(wavFormat(wavFormatFloat, 1, 44100, 64), float64s), 0, EncodingFloat, 64},
+		{"extensible", wavStream(extensible, pcm32), 1e-6, EncodingPCM, 24},
+	}
+	for _, tc := range tests {
+		buf, err := Decode(bytes.NewReader(tc.stream))
+		if err != nil {
+			t.Fatalf("%s: Decode failed: %v", tc.name, err)
+		}
+		checkSamples(t, tc.name, buf, tc.tolerance)
+		if buf.Metadata.Container != "wav" || buf.Metadata.Encoding != tc.encoding || buf.Metadata.ValidBits != tc.validBits {
+			t.Errorf("%s: unexpected metadata %+v", tc.name, buf.Metadata)
+		}
+	}
+}
+
+func TestReadAIFF(t *testing.T) {
+	be16 := make([]byte, 2*len(testSamples))
+	le16 := make([]byte, 2*len(testSamples))
+	fl32 := make([]byte, 4*len(testSamples))
+	for i, v := range testSamples {
+		binary.BigEndian.PutUint16(be16[2*i:], uint16(int16(v*32767)))
+		binary.LittleEndian.PutUint16(le16[2*i:], uint16(int16(v*32767)))
+		binary.BigEndian.PutUint32(fl32[4*i:], math.Float32bits(float32(v)))
+	}
+
+	tests := []struct {
+		name      string
+		stream    []byte
+		container string
+	}{
+		{"aiff", aiffStream(2, 16, "", be16), "aiff"},
+		{"aifc sowt", aiffStream(2, 16, "sowt", le16), "aifc"},
+		{"aifc fl32", aiffStream(2, 32, "fl32", fl32), "aifc"},
+	}
+	for _, tc := range tests {
+		buf, err := Decode(bytes.NewReader(tc.stream))
+		if err != nil {
+			t.Fatalf("%s: Decode failed: %v", tc.name, err)
+		}
+		if buf.SampleRate != 44100 || buf.Channels() != 2 {
+			t.Errorf("%s: got %d Hz, %d channels, want 44100 Hz, 2 channels", tc.name, buf.SampleRate, buf.Channels())
+		}
+		if buf.Metadata.Container != tc.container {
+			t.Errorf("%s: container %q, want %q", tc.name, buf.Metadata.Container, tc.container)
+		}
+		checkSamples(t, tc.name, buf, 1.0/16384)
+	}
+}
+
+func TestDecodeErrors(t *testing.T) {
+	if _, err := Decode(bytes.NewReader([]byte("OggS0000"))); !errors.Is(err, ErrUnknownContainer) {
+		t.Errorf("Expected ErrUnknownContainer, got %v", err)
+	}
+
+	unsupported := wavStream(wavFormat(2, 1, 44100, 4), []byte{0, 0})
+	if _, err := Decode(bytes.NewReader(unsupported)); err == nil {
+		t.Error("Expected an error for ADPCM data")
+	}
+
+	// A recording cut off mid-chunk still yields its whole frames.
+	stream := wavStream(wavFormat(wavFormatPCM, 1, 8000, 16), make([]byte, 100))
+	buf, err := Decode(bytes.NewReader(stream[:len(stream)-51]))
+	if err != nil {
+		t.Fatalf("Truncated stream should decode: %v", err)
+	}
+	if buf.Frames() != 24 {
+		t.Errorf("Truncated stream: got %d frames, want 24", buf.Frames())
+	}
+}
+
+func TestDecodeStreamedSizes(t *testing.T) {
+	data := make([]byte, 100)
+	for _, size := range []uint32{0, 0xFFFFFFFF} {
+		stream := wavStream(wavFormat(wavFormatPCM, 1, 8000, 16), data)
+		binary.LittleEndian.PutUint32(stream[len(stream)-len(data)-4:], size)
+		buf, err := Decode(bytes.NewReader(stream))
+		if err != nil {
+			t.Fatalf("Data size %#x: %v", size, err)
+		}
+		if buf.Frames() != 50 {
+			t.Errorf("Data size %#x: got %d frames, want 50", size, buf.Frames())
+		}
+	}
+
+	// A huge declared size must neither overflow nor allocate it up front.
+	stream := wavStream(wavFormat(wavFormatPCM, 1, 8000, 16), data)
+	binary.LittleEndian.PutUint32(stream[len(stream)-len(data)-4:], 0xFFFFFFFE)
+	if buf, err := Decode(bytes.NewReader(stream)); err != nil || buf.Frames() != 50 {
+		t.Errorf("Oversized data chunk should read what is present: %v", err)
+	}
+
+	fmtChunk := wavFormat(wavFormatPCM, 1, 8000, 16)
+	bad := wavStream(fmtChunk, data)
+	binary.LittleEndian.PutUint32(bad[16:], 0xFFFFFFFF)
+	if _, err := Decode(bytes.NewReader(bad)); err == nil {
+		t.Error("Expected an error for a fmt chunk larger than the stream")
+	}
+}
@@ -0,0 +1,111 @@
+package audiofile
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"os"
+)
+
+var ErrUnknownContainer = errors.New("audiofile: unknown container, expected WAV or AIFF")
+
+// Decode reads a WAV, AIFF or AIFC stream, detected from its header.
+func Decode(r io.Reader) (*Buffer, error) {
+	br := bufio.NewReader(r)
+	magic, err := br.Peek(4)
+	if err != nil {
+		return nil, fmt.Errorf("audiofile: reading header: %w", err)
+	}
+	switch string(magic) {
+	case "RIFF":
+		return ReadWAV(br)
+	case "FORM":
+		return ReadAIFF(br)
+	default:
+		return nil, ErrUnknownContainer
+	}
+}
+
+func Open(path string) (*Buffer, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return Decode(f)
+}
+
+// sampleDecoder returns a function converting one stored sample to a float
+// in [-1, 1). 8-bit PCM is unsigned in WAV and signed in AIFF.
+func sampleDecoder(encoding Encoding, bits int, order binary.ByteOrder, unsigned8 bool) (func([]byte) float64, error) {
+	if encoding == EncodingFloat {
+		switch bits {
+		case 32:
+			return func(b []byte) float64 { return float64(math.Float32frombits(order.Uint32(b))) }, nil
+		case 64:
+			return func(b []byte) float64 { return math.Float64frombits(order.Uint64(b)) }, nil
+		}
+		return nil, fmt.Errorf("audiofile: unsupported float sample size %d", bits)
+	}
+
+	switch bits {
+	case 8:
+		if unsigned8 {
+			return func(b []byte) float64 { return (float64(b[0]) - 128) / 128 }, nil
+		}
+		return func(b []byte) float64 { return float64(int8(b[0])) / 128 }, nil
+	case 16:
+		return func(b []byte) float64 { return float64(int16(order.Uint16(b))) / (1 << 15) }, nil
+	case 24:
+		return func(b []byte) float64 {
+			var v int32
+			if order == binary.BigEndian {
+				v = int32(b[0])<<24 | int32(b[1])<<16 | int32(b[2])<<8
+			} else {
+				v = int32(b[2])<<24 | int32(b[1])<<16 | int32(b[0])<<8
+			}
+			return float64(v>>8) / (1 << 23)
+		}, nil
+	case 32:
+		return func(b []byte) float64 { return float64(int32(order.Uint32(b))) / (1 << 31) }, nil
+	}
+	return nil, fmt.Errorf("audiofile: unsupported PCM sample size %d", bits)
+}
+
+// deinterleave decodes whole frames from data; a trailing partial frame is
+// ignored.
+func deinterleave(data []byte, channels, width int, decode func([]byte) float64, sampleRate uint32) *Buffer {
+	frames := len(data) / (width * channels)
+	buf := NewBuffer(sampleRate, channels, frames)
+	for i := 0; i < frames*channels; i++ {
+		buf.Data[i%channels][i/channels] = decode(data[i*width:])
+	}
+	return buf
+}
+
+// readChunk reads a chunk body including its pad byte. Bodies are read
+// through io.LimitReader, so a declared size only costs memory for the
+// bytes actually present. A short read of the final audio chunk is
+// tolerated so that truncated recordings still decode, and an audio chunk
+// size of 0 or 0xFFFFFFFF, as written by streaming recorders, reads to the
+// end of the stream.
+func readChunk(r io.Reader, size uint32, audio bool) ([]byte, error) {
+	if audio && (size == 0 || size == math.MaxUint32) {
+		return io.ReadAll(r)
+	}
+	padded := int64(size) + int64(size%2)
+	body, err := io.ReadAll(io.LimitReader(r, padded))
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(body)) < padded && !audio {
+		return nil, io.ErrUnexpectedEOF
+	}
+	if int64(len(body)) > int64(size) {
+		body = body[:size]
+	}
+	return body, nil
+}
@@ -0,0 +1,186 @@
+package audiofile
+
+import (
+	"bufio"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+	"os"
+)
+
+type Format int
+
+const (
+	FormatPCM16 Format = iota
+	FormatFloat32
+)
+
+const (
+	wavFormatPCM   = 1
+	wavFormatFloat = 3
+)
+
+// WriteWAV encodes buf as a RIFF/WAVE stream. PCM samples are clipped to
+// [-1, 1] before quantisation.
+func WriteWAV(w io.Writer, buf *Buffer, format Format) error {
+	var (
+		tag           uint16
+		bitsPerSample uint16
+	)
+	switch format {
+	case FormatPCM16:
+		tag, bitsPerSample = wavFormatPCM, 16
+	case FormatFloat32:
+		tag, bitsPerSample = wavFormatFloat, 32
+	default:
+		return fmt.Errorf("audiofile: unsupported output format %d", format)
+	}
+
+	channels := uint16(buf.Channels())
+	if channels == 0 {
+		return fmt.Errorf("audiofile: buffer has no channels")
+	}
+	blockAlign := channels * bitsPerSample / 8
+	dataSize := uint32(buf.Frames()) * uint32(blockAlign)
+
+	bw := bufio.NewWriter(w)
+	header := []any{
+		[4]byte{'R', 'I', 'F', 'F'},
+		uint32(36 + dataSize),
+		[4]byte{'W', 'A', 'V', 'E'},
+		[4]byte{'f', 'm', 't', ' '},
+		uint32(16),
+		tag,
+		channels,
+		buf.SampleRate,
+		buf.SampleRate * uint32(blockAlign),
+		blockAlign,
+		bitsPerSample,
+		[4]byte{'d', 'a', 't', 'a'},
+		dataSize,
+	}
+	for _, field := range header {
+		if err := binary.Write(bw, binary.LittleEndian, field); err != nil {
+			return err
+		}
+	}
+
+	var sample [4]byte
+	for i := 0; i < buf.Frames(); i++ {
+		for _, data := range buf.Data {
+			switch format {
+			case FormatPCM16:
+				v := math.Max(-1, math.Min(1, data[i]))
+				binary.LittleEndian.PutUint16(sample[:2], uint16(int16(math.Round(v*math.MaxInt16))))
+				if _, err := bw.Write(sample[:2]); err != nil {
+					return err
+				}
+			case FormatFloat32:
+				binary.LittleEndian.PutUint32(sample[:], math.Float32bits(float32(data[i])))
+				if _, err := bw.Write(sample[:]); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	return bw.Flush()
+}
+
+func WriteWAVFile(path string, buf *Buffer, format Format) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := WriteWAV(f, buf, format); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+const wavFormatExtensible = 0xFFFE
+
+// ReadWAV decodes a RIFF/WAVE stream holding 8, 16, 24 or 32-bit PCM or
+// 32/64-bit float samples, including WAVE_FORMAT_EXTENSIBLE headers.
+func ReadWAV(r io.Reader) (*Buffer, error) {
+	var riff struct {
+		ID   [4]byte
+		Size uint32
+		Wave [4]byte
+	}
+	if err := binary.Read(r, binary.LittleEndian, &riff); err != nil {
+		return nil, err
+	}
+	if string(riff.ID[:]) != "RIFF" || string(riff.Wave[:]) != "WAVE" {
+		return nil, fmt.Errorf("audiofile: not a RIFF/WAVE stream")
+	}
+
+	var (
+		channels   int
+		sampleRate uint32
+		meta       = Metadata{Container: "wav"}
+		haveFormat bool
+	)
+	for {
+		var chunk struct {
+			ID   [4]byte
+			Size uint32
+		}
+		if err := binary.Read(r, binary.LittleEndian, &chunk); err != nil {
+			return nil, fmt.Errorf("audiofile: missing data chunk: %w", err)
+		}
+		isData := string(chunk.ID[:]) == "data"
+		body, err := readChunk(r, chunk.Size, isData)
+		if err != nil {
+			return nil, err
+		}
+
+		switch string(chunk.ID[:]) {
+		case "fmt ":
+			if len(body) < 16 {
+				return nil, fmt.Errorf("audiofile: fmt chunk too short")
+			}
+			tag := binary.LittleEndian.Uint16(body[0:2])
+			channels = int(binary.LittleEndian.Uint16(body[2:4]))
+			sampleRate = binary.LittleEndian.Uint32(body[4:8])
+			meta.BitDepth = int(binary.LittleEndian.Uint16(body[14:16]))
+			meta.ValidBits = meta.BitDepth
+
+			if tag == wavFormatExtensible {
+				if len(body) < 40 {
+					return nil, fmt.Errorf("audiofile: extensible fmt chunk too short")
+				}
+				if valid := int(binary.LittleEndian.Uint16(body[18:20])); valid > 0 {
+					meta.ValidBits = valid
+				}
+				meta.ChannelMask = binary.LittleEndian.Uint32(body[20:24])
+				tag = binary.LittleEndian.Uint16(body[24:26])
+			}
+
+			switch tag {
+			case wavFormatPCM:
+				meta.Encoding = EncodingPCM
+			case wavFormatFloat:
+				meta.Encoding = EncodingFloat
+			default:
+				return nil, fmt.Errorf("audiofile: unsupported WAV format tag %#x", tag)
+			}
+			haveFormat = true
+		case "data":
+			if !haveFormat {
+				return nil, fmt.Errorf("audiofile: data chunk before fmt chunk")
+			}
+			if channels == 0 {
+				return nil, fmt.Errorf("audiofile: stream has no channels")
+			}
+			decode, err := sampleDecoder(meta.Encoding, meta.BitDepth, binary.LittleEndian, true)
+			if err != nil {
+				return nil, err
+			}
+			buf := deinterleave(body, channels, meta.BitDepth/8, decode, sampleRate)
+			buf.Metadata = meta
+			return buf, nil
+		}
+	}
+}
@@ -0,0 +1,203 @@
+package calibration
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Point is one frequency of a correction curve. GainDB is the deviation
+// of the device from flat, so a measurement is corrected by subtracting
+// it and an output is pre-emphasised by its inverse.
+type Point struct {
+	Frequency float64
+	GainDB    float64
+	PhaseDeg  float64
+}
+
+// Profile is the correction curve of one microphone or speaker.
+// Sensitivity is the "Sens Factor" some calibration files carry in their
+// header: the deviation of the whole curve from nominal in dB, which
+// CorrectLevel removes along with the gains.
+type Profile struct {
+	Name        string
+	Sensitivity float64
+	Points      []Point
+}
+
+var (
+	ErrEmptyProfile = errors.New("calibration: profile has no points")
+
+	sensFactor = regexp.MustCompile(`(?i)sens\s*factor\s*=\s*([-+]?[0-9]*\.?[0-9]+)`)
+)
+
+// Load reads the common microphone calibration text format: one
+// "frequency gain [phase]" line per point, separated by spaces, tabs,
+// commas or semicolons. Lines starting with '*', '#', ';', '"' or any
+// other non-numeric text are treated as comments; a "Sens Factor =xdB"
+// comment sets Sensitivity.
+func Load(r io.Reader) (*Profile, error) {
+	profile := &Profile{}
+	scanner := bufio.NewScanner(r)
+	line := 0
+	for scanner.Scan() {
+		line++
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" {
+			continue
+		}
+		if m := sensFactor.FindStringSubmatch(text); m != nil {
+			profile.Sensitivity, _ = strconv.ParseFloat(m[1], 64)
+			continue
+		}
+
+		fields := strings.FieldsFunc(text, func(r rune) bool {
+			return r == ' ' || r == '\t' || r == ',' || r == ';'
+		})
+		if len(fields) < 2 {
+			continue
+		}
+		freq, err := strconv.ParseFloat(fields[0], 64)
+		if err != nil {
+			continue
+		}
+		gain, err := strconv.ParseFloat(fields[1], 64)
+		if err != nil {
+			return nil, fmt.Errorf("calibration: line %d: invalid gain %q", line, fields[1])
+		}
+		point := Point{Frequency: freq, GainDB: gain}
+		if len(fields) > 2 {
+			if point.PhaseDeg, err = strconv.ParseFloat(fields[2], 64); err != nil {
+				return nil, fmt.Errorf("calibration: line %d: invalid phase %q", line, fields[2])
+			}
+		}
+		if freq <= 0 {
+			return nil, fmt.Errorf("calibration: line %d: frequency must be positive", line)
+		}
+		profile.Points = append(profile.Points, point)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	if len(profile.Points) == 0 {
+		return nil, ErrEmptyProfile
+	}
+
+	sort.Slice(profile.Points, func(i, j int) bool { return profile.Points[i].Frequency < profile.Points[j].Frequency })
+	return profile, nil
+}
+
+func LoadFile(path string) (*Profile, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	profile, err := Load(f)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	if profile.Name == "" {
+		profile.Name = strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+	}
+	return profile, nil
+}
+
+// Save writes the profile in the format read by Load.
+func (p *Profile) Save(w io.Writer) error {
+	bw := bufio.NewWriter(w)
+	if p.Name != "" {
+		fmt.Fprintf(bw, "* %s\n", p.Name)
+	}
+	if p.Sensitivity != 0 {
+		fmt.Fprintf(bw, "\"Sens Factor =%gdB\"\n", p.Sensitivity)
+	}
+	fmt.Fprintln(bw, "* Freq(Hz)\tGain(dB)\tPhase(deg)")
+	for _, point := range p.Points {
+		fmt.Fprintf(bw, "%.3f\t%.3f\t%.2f\n", point.Frequency, point.GainDB, point.PhaseDeg)
+	}
+	return bw.Flush()
+}
+
+func (p *Profile) SaveFile(path string) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := p.Save(f); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// interpolate returns the value of field at freq, linear in log frequency
+// between points and held constant beyond the ends of the curve.
+func (p *Profile) interpolate(freq float64, field func(Point) float64) float64 {
+	points := p.Points
+	if len(points) == 0 {
+		return 0
+	}
+	if freq <= points[0].Frequency {
+		return field(points[0])
+	}
+	if freq >= points[len(points)-1].Frequency {
+		return field(points[len(points)-1])
+	}
+
+	i := sort.Search(len(points), func(i int) bool { return points[i].Frequency > freq })
+	lo, hi := points[i-1], points[i]
+	t := math.Log(freq/lo.Frequency) / math.Log(hi.Frequency/lo.Frequency)
+	return field(lo)*(1-t) + field(hi)*t
+}
+
+// Gain returns the device deviation in dB at freq.
+func (p *Profile) Gain(freq float64) float64 {
+	return p.interpolate(freq, func(pt Point) float64 { return pt.GainDB })
+}
+
+func (p *Profile) Phase(freq float64) float64 {
+	return p.interpolate(freq, func(pt Point) float64 { return pt.PhaseDeg })
+}
+
+// CorrectLevel removes the device response from a level measured at freq:
+// the gain of the curve and the Sensitivity, which like the gains is the
+// deviation of the device from nominal.
+func (p *Profile) CorrectLevel(freq, levelDB float64) float64 {
+	return levelDB - p.Gain(freq) - p.Sensitivity
+}
+
+// PreEmphasis returns the linear output gain that compensates the device
+// at freq.
+func (p *Profile) PreEmphasis(freq float64) float64 {
+	return math.Pow(10, -p.Gain(freq)/20)
+}
+
+// MaxPreEmphasis returns the largest PreEmphasis between minFreq and
+// maxFreq, for normalising an output so that it never clips.
+func (p *Profile) MaxPreEmphasis(minFreq, maxFreq float64) float64 {
+	peak := math.Max(p.PreEmphasis(minFreq), p.PreEmphasis(maxFreq))
+	for _, point := range p.Points {
+		if point.Frequency > minFreq && point.Frequency < maxFreq {
+			peak = math.Max(peak, p.PreEmphasis(point.Frequency))
+		}
+	}
+	return peak
+}
+
+// Normalize shifts the curve so that its gain at freq is 0 dB.
+func (p *Profile) Normalize(freq float64) {
+	offset := p.Gain(freq)
+	for i := range p.Points {
+		p.Points[i].GainDB -= offset
+	}
+}
@@ -0,0 +1,134 @@
+package calibration
+
+import (
+	"bytes"
+	"math"
+	"math/rand"
+	"strings"
+	"testing"
+)
+
+const umikFile = `"Sens Factor =-1.378dB, AGain =18dB, SERNO: 7001234"
+* Freq(Hz)	dB	Phase
+10.054	-3.12	0.0
+100	0.5	12
+1000	0.0	3.5
+10000,2.5,-20
+20000 -1.0
+`
+
+func TestLoad(t *testing.T) {
+	profile, err := Load(strings.NewReader(umikFile))
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	if profile.Sensitivity != -1.378 {
+		t.Errorf("Sensitivity: got %v, want -1.378", profile.Sensitivity)
+	}
+	if len(profile.Points) != 5 {
+		t.Fatalf("Got %d points, want 5", len(profile.Points))
+	}
+	if p := profile.Points[3]; p.Frequency != 10000 || p.GainDB != 2.5 || p.PhaseDeg != -20 {
+		t.Errorf("Comma-separated point parsed as %+v", p)
+	}
+
+	if _, err := Load(strings.NewReader("* only comments\n")); err != ErrEmptyProfile {
+		t.Errorf("Expected ErrEmptyProfile, got %v", err)
+	}
+	if _, err := Load(strings.NewReader("100 loud\n")); err == nil {
+		t.Error("Expected an error for a non-numeric gain")
+	}
+}
+
+func TestLogFrequencyInterpolation(t *testing.T) {
+	profile, _ := Load(strings.NewReader(umikFile))
+
+	// 316.2 Hz is halfway between 100 Hz and 1 kHz on a log axis.
+	if g := profile.Gain(math.Sqrt(100 * 1000)); math.Abs(g-0.25) > 1e-9 {
+		t.Errorf("Gain at 316 Hz: got %v, want 0.25", g)
+	}
+	if g := profile.Gain(5); g != -3.12 {
+		t.Errorf("Gain below the curve should hold the first point: got %v", g)
+	}
+	if g := profile.Gain(30000); g != -1 {
+		t.Errorf("Gain above the curve should hold the last point: got %v", g)
+	}
+	if p := profile.Phase(math.Sqrt(1000 * 10000)); math.Abs(p-(-8.25)) > 1e-9 {
+		t.Errorf("Phase at 3.16 kHz: got %v, want -8.25", p)
+	}
+
+	// The 1.378 dB insensitive microphone reads low across the band.
+	if l := profile.CorrectLevel(10000, 80); math.Abs(l-78.878) > 1e-9 {
+		t.Errorf("Corrected level: got %v, want 78.878", l)
+	}
+	if e := profile.PreEmphasis(10000); math.Abs(e-math.Pow(10, -2.5/20)) > 1e-12 {
+		t.Errorf("Pre-emphasis at 10 kHz: got %v", e)
+	}
+	// The curve is lowest at the 20 Hz edge of the range, so that end needs the most boost.
+	if e := profile.MaxPreEmphasis(20, 20000); e != profile.PreEmphasis(20) {
+		t.Errorf("Max pre-emphasis: got %v, want %v", e, profile.PreEmphasis(20))
+	}
+	if e := profile.MaxPreEmphasis(1000, 20000); e != profile.PreEmphasis(20000) {
+		t.Errorf("Max pre-emphasis above 1 kHz: got %v, want %v", e, profile.PreEmphasis(20000))
+	}
+}
+
+func TestSaveLoadRoundTrip(t *testing.T) {
+	profile, _ := Load(strings.NewReader(umikFile))
+	profile.Name = "umik-1"
+
+	var buf bytes.Buffer
+	if err := profile.Save(&buf); err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+	loaded, err := Load(&buf)
+	if err != nil {
+		t.Fatalf("Load of saved profile failed: %v", err)
+	}
+	if loaded.Sensitivity != profile.Sensitivity || len(loaded.Points) != len(profile.Points) {
+		t.Fatalf("Round trip changed the profile: %+v", loaded)
+	}
+	for i := range profile.Points {
+		if math.Abs(loaded.Points[i].GainDB-profile.Points[i].GainDB) > 1e-3 {
+			t.Errorf("Point %d: got %+v, want %+v", i, loaded.Points[i], profile.Points[i])
+		}
+	}
+}
+
+func TestFromMeasurement(t *testing.T) {
+	const sampleRate = 48000
+	random := rand.New(rand.NewSource(1))
+	reference := make([]float64, sampleRate)
+	for i := range reference {
+		reference[i] = random.NormFloat64()
+	}
+
+	// The device is 6 dB quieter and 25 samples late.
+	measured := make([]float64, len(reference)+25)
+	for i, v := range reference {
+		measured[i+25] = 0.5 * v
+	}
+
+	options := DefaultMeasureOptions()
+	options.PointsPerOctave = 3
+	profile, err := FromMeasurement(measured, reference, sampleRate, options)
+	if err != nil {
+		t.Fatalf("FromMeasurement failed: %v", err)
+	}
+	if len(profile.Points) != 30 {
+		t.Errorf("Got %d points, want 30 third-octave points from 20 Hz to 20 kHz", len(profile.Points))
+	}
+	for _, p := range profile.Points {
+		if math.Abs(p.GainDB-20*math.Log10(0.5)) > 0.1 {
+			t.Errorf("%.0f Hz: gain %.2f dB, want -6.02 dB", p.Frequency, p.GainDB)
+		}
+		if math.Abs(p.PhaseDeg) > 1 {
+			t.Errorf("%.0f Hz: phase %.2f°, want 0 once the delay is removed", p.Frequency, p.PhaseDeg)
+		}
+	}
+
+	profile.Normalize(1000)
+	if g := profile.Gain(1000); math.Abs(g) > 1e-9 {
+		t.Errorf("Gain at 1 kHz after Normalize: got %v", g)
+	}
+}
@@ -0,0 +1,98 @@
+package calibration
+
+import (
+	"errors"
+	"math"
+	"math/cmplx"
+
+	"gonum.org/v1/gonum/dsp/fourier"
+)
+
+// MeasureOptions controls FromMeasurement. Points are spaced
+// PointsPerOctave per octave from MinFrequency to MaxFrequency, and each
+// point averages the spectrum over its own fraction of an octave.
+type MeasureOptions struct {
+	MinFrequency    float64
+	MaxFrequency    float64
+	PointsPerOctave int
+}
+
+func DefaultMeasureOptions() MeasureOptions {
+	return MeasureOptions{MinFrequency: 20, MaxFrequency: 20000, PointsPerOctave: 12}
+}
+
+// FromMeasurement builds a profile from the same sweep recorded by the
+// device under test (measured) and by a reference (either the reference
+// microphone's recording or the played signal itself). The delay between
+// the two is removed before the phase is computed.
+func FromMeasurement(measured, reference []float64, sampleRate float64, options MeasureOptions) (*Profile, error) {
+	if len(measured) == 0 || len(reference) == 0 {
+		return nil, errors.New("calibration: empty recording")
+	}
+	if options.PointsPerOctave <= 0 || options.MinFrequency <= 0 || options.MaxFrequency <= options.MinFrequency {
+		return nil, errors.New("calibration: invalid measurement options")
+	}
+	maxFreq := math.Min(options.MaxFrequency, sampleRate/2)
+
+	n := 1
+	for n < 2*max(len(measured), len(reference)) {
+		n <<= 1
+	}
+	fft := fourier.NewFFT(n)
+	padded := make([]float64, n)
+	copy(padded, measured)
+	m := fft.Coefficients(nil, padded)
+	for i := range padded {
+		padded[i] = 0
+	}
+	copy(padded, reference)
+	r := fft.Coefficients(nil, padded)
+
+	// The cross-correlation peak gives the delay of measured behind
+	// reference; compensating it keeps only the device's own phase.
+	cross := make([]complex128, len(m))
+	for i := range cross {
+		cross[i] = m[i] * cmplx.Conj(r[i])
+	}
+	corr := fft.Sequence(nil, cross)
+	lag := 0
+	for i := range corr {
+		if math.Abs(corr[i]) > math.Abs(corr[lag]) {
+			lag = i
+		}
+	}
+	if lag > n/2 {
+		lag -= n
+	}
+
+	profile := &Profile{}
+	step := math.Pow(2, 1/float64(options.PointsPerOctave))
+	halfBand := math.Sqrt(step)
+	for freq := options.MinFrequency; freq <= maxFreq*(1+1e-9); freq *= step {
+		lo := int(math.Floor(freq / halfBand * float64(n) / sampleRate))
+		hi := int(math.Ceil(freq * halfBand * float64(n) / sampleRate))
+		lo = max(lo, 1)
+		hi = min(hi, len(m)-1)
+
+		var measuredPower, referencePower float64
+		var crossSum complex128
+		for i := lo; i <= hi; i++ {
+			measuredPower += real(m[i])*real(m[i]) + imag(m[i])*imag(m[i])
+			referencePower += real(r[i])*real(r[i]) + imag(r[i])*imag(r[i])
+			shift := cmplx.Exp(complex(0, 2*math.Pi*float64(i)*float64(lag)/float64(n)))
+			crossSum += cross[i] * shift
+		}
+		if referencePower == 0 || measuredPower == 0 {
+			continue
+		}
+		profile.Points = append(profile.Points, Point{
+			Frequency: freq,
+			GainDB:    10 * math.Log10(measuredPower/referencePower),
+			PhaseDeg:  cmplx.Phase(crossSum) * 180 / math.Pi,
+		})
+	}
+	if len(profile.Points) == 0 {
+		return nil, ErrEmptyProfile
+	}
+	return profile, nil
+}
@@ -0,0 +1,309 @@
+// Package config merges settings from a config file and the environment
+// into a flag.FlagSet. Flags given on the command line take precedence over
+// environment variables, which take precedence over the file, whose
+// command sections take precedence over its top-level settings.
+//
+// The file format is a subset of TOML: "key = value" lines, optionally in
+// [section] tables, with # comments. Values are quoted strings, numbers or
+// booleans, and keys are flag names, with - or _ between words. Files
+// ending in .yaml or .yml are read as the equivalent YAML instead, see
+// ParseYAML.
+package config
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Source is where the effective value of a setting came from.
+type Source string
+
+const (
+	SourceDefault Source = "default"
+	SourceFile    Source = "file"
+	SourceEnv     Source = "env"
+	SourceFlag    Source = "flag"
+)
+
+// File holds the settings of a config file by section; top-level settings
+// are in the section "".
+type File struct {
+	sections map[string]map[string]string
+}
+
+func Parse(r io.Reader) (*File, error) {
+	f := &File{sections: map[string]map[string]string{"": {}}}
+	section := ""
+	scanner := bufio.NewScanner(r)
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(stripComment(scanner.Text()))
+		if text == "" {
+			continue
+		}
+
+		if strings.HasPrefix(text, "[") {
+			if !strings.HasSuffix(text, "]") {
+				return nil, fmt.Errorf("line %d: unterminated section header", line)
+			}
+			section = strings.TrimSpace(text[1 : len(text)-1])
+			if !validKey(section) {
+				return nil, fmt.Errorf("line %d: invalid section name %q", line, section)
+			}
+			if f.sections[section] == nil {
+				f.sections[section] = make(map[string]string)
+			}
+			continue
+		}
+
+		key, raw, ok := strings.Cut(text, "=")
+		if !ok {
+			return nil, fmt.Errorf("line %d: expected key = value", line)
+		}
+		key = normalizeKey(strings.TrimSpace(key))
+		if !validKey(key) {
+			return nil, fmt.Errorf("line %d: invalid key %q", line, key)
+		}
+		value, err := parseValue(strings.TrimSpace(raw))
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %s: %w", line, key, err)
+		}
+		if _, dup := f.sections[section][key]; dup {
+			return nil, fmt.Errorf("line %d: %s is set twice", line, key)
+		}
+		f.sections[section][key] = value
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+// Load reads a config file, as YAML when its extension is .yaml or .yml
+// and as TOML otherwise.
+func Load(path string) (*File, error) {
+	r, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+	parse := Parse
+	if ext := strings.ToLower(filepath.Ext(path)); ext == ".yaml" || ext == ".yml" {
+		parse = ParseYAML
+	}
+	f, err := parse(r)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return f, nil
+}
+
+// Section returns the settings of one section, "" for the top level.
+func (f *File) Section(name string) map[string]string {
+	return f.sections[name]
+}
+
+// stripComment removes a # comment that is not inside a quoted string.
+func stripComment(line string) string {
+	var quote rune
+	escaped := false
+	for i, c := range line {
+		switch {
+		case escaped:
+			escaped = false
+		case quote == '"' && c == '\\':
+			escaped = true
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+		case c == '"' || c == '\'':
+			quote = c
+		case c == '#':
+			return line[:i]
+		}
+	}
+	return line
+}
+
+func parseValue(raw string) (string, error) {
+	switch {
+	case raw == "":
+		return "", fmt.Errorf("missing value")
+	case strings.HasPrefix(raw, `"`):
+		value, err := strconv.Unquote(raw)
+		if err != nil {
+			return "", fmt.Errorf("invalid string %s", raw)
+		}
+		return value, nil
+	case strings.HasPrefix(raw, "'"):
+		if len(raw) < 2 || !strings.HasSuffix(raw, "'") || strings.Contains(raw[1:len(raw)-1], "'") {
+			return "", fmt.Errorf("invalid string %s", raw)
+		}
+		return raw[1 : len(raw)-1], nil
+	case raw == "true" || raw == "false":
+		return raw, nil
+	}
+	if _, err := strconv.ParseFloat(strings.ReplaceAll(raw, "_", ""), 64); err != nil {
+		return "", fmt.Errorf("invalid value %s (quote strings and durations)", raw)
+	}
+	return strings.ReplaceAll(raw, "_", ""), nil
+}
+
+func normalizeKey(key string) string {
+	return strings.ReplaceAll(key, "_", "-")
+}
+
+func validKey(key string) bool {
+	if key == "" {
+		return false
+	}
+	for _, c := range key {
+		if !(c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || c == '-' || c == '_') {
+			return false
+		}
+	}
+	return true
+}
+
+// EnvName returns the environment variable for a flag, e.g.
+// MALGOPLAY_LOG_LEVEL for log-level with the prefix MALGOPLAY_.
+func EnvName(prefix, flagName string) string {
+	return prefix + strings.ToUpper(strings.ReplaceAll(flagName, "-", "_"))
+}
+
+// canonical maps every flag name to the longest name sharing its Value, so
+// that a shorthand such as -m and its long form --min are one setting.
+func canonical(flags *flag.FlagSet) map[string]string {
+	longest := make(map[flag.Value]string)
+	flags.VisitAll(func(f *flag.Flag) {
+		if name, ok := longest[f.Value]; !ok || len(f.Name) > len(name) {
+			longest[f.Value] = f.Name
+		}
+	})
+	names := make(map[string]string)
+	flags.VisitAll(func(f *flag.Flag) {
+		names[f.Name] = longest[f.Value]
+	})
+	return names
+}
+
+// Apply sets every flag not given on the command line from the
+// environment (prefix plus the flag name, see EnvName) or else from file,
+// which may be nil. Settings in the section table of file must name flags
+// of flags; top-level settings for other commands are ignored. Apply must
+// be called after flags.Parse and returns the source of every setting.
+func Apply(flags *flag.FlagSet, file *File, section, prefix string, lookupEnv func(string) (string, bool)) (map[string]Source, error) {
+	names := canonical(flags)
+	sources := make(map[string]Source)
+	for _, name := range names {
+		sources[name] = SourceDefault
+	}
+	flags.Visit(func(f *flag.Flag) {
+		sources[names[f.Name]] = SourceFlag
+	})
+
+	set := func(name, value string, source Source) error {
+		if err := flags.Set(name, value); err != nil {
+			return fmt.Errorf("%s from %s: %w", name, source, err)
+		}
+		sources[name] = source
+		return nil
+	}
+
+	if file != nil {
+		for _, table := range []string{"", section} {
+			settings := file.Section(table)
+			keys := make([]string, 0, len(settings))
+			for key := range settings {
+				keys = append(keys, key)
+			}
+			sort.Strings(keys)
+			for _, key := range keys {
+				name, ok := names[key]
+				if !ok {
+					if table == "" {
+						continue
+					}
+					return nil, fmt.Errorf("unknown setting %q in [%s]", key, table)
+				}
+				if sources[name] == SourceFlag {
+					continue
+				}
+				if err := set(name, settings[key], SourceFile); err != nil {
+					return nil, err
+				}
+			}
+			if section == "" {
+				break
+			}
+		}
+	}
+
+	for _, name := range sortedValues(names) {
+		if sources[name] == SourceFlag {
+			continue
+		}
+		if value, ok := lookupEnv(EnvName(prefix, name)); ok {
+			if err := set(name, value, SourceEnv); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return sources, nil
+}
+
+func sortedValues(names map[string]string) []string {
+	seen := make(map[string]bool)
+	var values []string
+	for _, name := range names {
+		if !seen[name] {
+			seen[name] = true
+			values = append(values, name)
+		}
+	}
+	sort.Strings(values)
+	return values
+}
+
+// Write prints the effective settings of flags as a config file section,
+// noting where each value came from. Shorthand aliases and the names in
+// skip are left out.
+func Write(w io.Writer, flags *flag.FlagSet, section string, sources map[string]Source, skip ...string) error {
+	names := canonical(flags)
+	omit := make(map[string]bool)
+	for _, name := range skip {
+		omit[name] = true
+	}
+
+	if _, err := fmt.Fprintf(w, "[%s]\n", section); err != nil {
+		return err
+	}
+	var err error
+	flags.VisitAll(func(f *flag.Flag) {
+		if err != nil || names[f.Name] != f.Name || omit[f.Name] {
+			return
+		}
+		_, err = fmt.Fprintf(w, "%s = %s  # %s\n", f.Name, formatValue(f.Value), sources[f.Name])
+	})
+	return err
+}
+
+func formatValue(value flag.Value) string {
+	if getter, ok := value.(flag.Getter); ok {
+		switch getter.Get().(type) {
+		case bool, int, int64, uint, uint64, float64:
+			return value.String()
+		case time.Duration:
+			return strconv.Quote(value.String())
+		}
+	}
+	return strconv.Quote(value.String())
+}
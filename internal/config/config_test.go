@@ -0,0 +1,162 @@
+package config
+
+import (
+	"bytes"
+	"flag"
+	"strings"
+	"testing"
+	"time"
+)
+
+const sample = `
+# Defaults for every command
+log_level = "info"
+rate = 48_000
+unused = true
+
+[play]
+min = 20.5   # Hz
+mode = 'octaves'
+sweep-duration = "30s"
+
+[render]
+out = "sweep # 1.wav"
+`
+
+func TestParse(t *testing.T) {
+	f, err := Parse(strings.NewReader(sample))
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+	tests := []struct{ section, key, want string }{
+		{"", "log-level", "info"},
+		{"", "rate", "48000"},
+		{"", "unused", "true"},
+		{"play", "min", "20.5"},
+		{"play", "mode", "octaves"},
+		{"play", "sweep-duration", "30s"},
+		{"render", "out", "sweep # 1.wav"},
+	}
+	for _, tc := range tests {
+		if got := f.Section(tc.section)[tc.key]; got != tc.want {
+			t.Errorf("Incorrect [%s] %s: got %q, want %q", tc.section, tc.key, got, tc.want)
+		}
+	}
+
+	for _, input := range []string{
+		"min",
+		"min = ",
+		"mode = linear",
+		"[play",
+		"[a b]",
+		`out = "unterminated`,
+		"min = 1\nmin = 2",
+		"bad key = 1",
+	} {
+		if _, err := Parse(strings.NewReader(input)); err == nil {
+			t.Errorf("Expected an error for %q", input)
+		}
+	}
+}
+
+type playFlags struct {
+	flags    *flag.FlagSet
+	min      float64
+	rate     uint
+	mode     string
+	logLevel string
+	duration time.Duration
+}
+
+func newPlayFlags() *playFlags {
+	p := &playFlags{flags: flag.NewFlagSet("play", flag.ContinueOnError)}
+	p.flags.Float64Var(&p.min, "min", 220, "")
+	p.flags.Var(p.flags.Lookup("min").Value, "m", "")
+	p.flags.UintVar(&p.rate, "rate", 44100, "")
+	p.flags.StringVar(&p.mode, "mode", "linear", "")
+	p.flags.StringVar(&p.logLevel, "log-level", "warn", "")
+	p.flags.DurationVar(&p.duration, "sweep-duration", 0, "")
+	return p
+}
+
+func TestApplyPrecedence(t *testing.T) {
+	f, _ := Parse(strings.NewReader(sample))
+	env := map[string]string{"MALGOPLAY_MODE": "sine", "MALGOPLAY_MIN": "99"}
+	lookup := func(name string) (string, bool) {
+		v, ok := env[name]
+		return v, ok
+	}
+
+	p := newPlayFlags()
+	if err := p.flags.Parse([]string{"-m", "30"}); err != nil {
+		t.Fatal(err)
+	}
+	sources, err := Apply(p.flags, f, "play", "MALGOPLAY_", lookup)
+	if err != nil {
+		t.Fatalf("Apply failed: %v", err)
+	}
+
+	if p.min != 30 || sources["min"] != SourceFlag {
+		t.Errorf("A flag should win over everything: got %v from %s", p.min, sources["min"])
+	}
+	if p.mode != "sine" || sources["mode"] != SourceEnv {
+		t.Errorf("The environment should win over the file: got %v from %s", p.mode, sources["mode"])
+	}
+	if p.duration != 30*time.Second || sources["sweep-duration"] != SourceFile {
+		t.Errorf("The command section should apply: got %v from %s", p.duration, sources["sweep-duration"])
+	}
+	if p.rate != 48000 || p.logLevel != "info" || sources["rate"] != SourceFile {
+		t.Errorf("Top-level settings should apply: got %v and %v", p.rate, p.logLevel)
+	}
+	if _, ok := sources["m"]; ok {
+		t.Error("Shorthands should be reported under their long name")
+	}
+
+	var out bytes.Buffer
+	if err := Write(&out, p.flags, "play", sources); err != nil {
+		t.Fatalf("Write failed: %v", err)
+	}
+	want := `[play]
+log-level = "info"  # file
+min = 30  # flag
+mode = "sine"  # env
+rate = 48000  # file
+sweep-duration = "30s"  # file
+`
+	if out.String() != want {
+		t.Errorf("Incorrect output:\n%s\nwant:\n%s", out.String(), want)
+	}
+
+	// What Write prints must read back as the same settings.
+	shown, err := Parse(&out)
+	if err != nil {
+		t.Fatalf("Written config does not parse: %v", err)
+	}
+	q := newPlayFlags()
+	q.flags.Parse(nil)
+	if _, err := Apply(q.flags, shown, "play", "MALGOPLAY_", func(string) (string, bool) { return "", false }); err != nil {
+		t.Fatalf("Apply failed: %v", err)
+	}
+	if q.min != 30 || q.mode != "sine" || q.duration != 30*time.Second {
+		t.Errorf("Round trip lost settings: %+v", q)
+	}
+}
+
+func TestApplyErrors(t *testing.T) {
+	none := func(string) (string, bool) { return "", false }
+	for _, input := range []string{"[play]\nwobble = 1", "[play]\nmin = \"low\""} {
+		f, _ := Parse(strings.NewReader(input))
+		p := newPlayFlags()
+		p.flags.Parse(nil)
+		if _, err := Apply(p.flags, f, "play", "MALGOPLAY_", none); err == nil {
+			t.Errorf("Expected an error for %q", input)
+		}
+	}
+
+	p := newPlayFlags()
+	p.flags.Parse(nil)
+	bad := func(name string) (string, bool) { return "x", name == "MALGOPLAY_RATE" }
+	if _, err := Apply(p.flags, nil, "play", "MALGOPLAY_", bad); err == nil {
+		t.Error("Expected an error for an invalid environment value")
+	}
+}
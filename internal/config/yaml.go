@@ -0,0 +1,95 @@
+package config
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// ParseYAML reads the YAML form of a config file: "key: value" lines at
+// the top level, and "command:" mappings whose settings are indented
+// below them, with # comments. Values are plain, single- or double-quoted
+// scalars; lists, flow collections and deeper nesting are not supported.
+func ParseYAML(r io.Reader) (*File, error) {
+	f := &File{sections: map[string]map[string]string{"": {}}}
+	section, sectionIndent := "", -1
+	scanner := bufio.NewScanner(r)
+	for line := 1; scanner.Scan(); line++ {
+		raw := strings.TrimRight(stripComment(scanner.Text()), " \t")
+		text := strings.TrimSpace(raw)
+		if text == "" || text == "---" {
+			continue
+		}
+		indent := len(raw) - len(strings.TrimLeft(raw, " \t"))
+		if strings.Contains(raw[:indent], "\t") {
+			return nil, fmt.Errorf("line %d: tabs are not allowed in indentation", line)
+		}
+
+		key, rawValue, ok := strings.Cut(text, ":")
+		if !ok {
+			return nil, fmt.Errorf("line %d: expected key: value", line)
+		}
+		key = normalizeKey(strings.TrimSpace(key))
+		if !validKey(key) {
+			return nil, fmt.Errorf("line %d: invalid key %q", line, key)
+		}
+		rawValue = strings.TrimSpace(rawValue)
+
+		switch {
+		case indent == 0:
+			section, sectionIndent = "", -1
+			if rawValue == "" {
+				// A mapping of command settings.
+				section = key
+				if f.sections[section] == nil {
+					f.sections[section] = make(map[string]string)
+				}
+				continue
+			}
+		case section == "":
+			return nil, fmt.Errorf("line %d: unexpected indentation", line)
+		case sectionIndent == -1:
+			sectionIndent = indent
+		case indent != sectionIndent:
+			return nil, fmt.Errorf("line %d: inconsistent indentation", line)
+		}
+
+		if rawValue == "" {
+			return nil, fmt.Errorf("line %d: %s: nested mappings are not supported", line, key)
+		}
+		value, err := parseYAMLValue(rawValue)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %s: %w", line, key, err)
+		}
+		if _, dup := f.sections[section][key]; dup {
+			return nil, fmt.Errorf("line %d: %s is set twice", line, key)
+		}
+		f.sections[section][key] = value
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+func parseYAMLValue(raw string) (string, error) {
+	switch {
+	case strings.HasPrefix(raw, `"`):
+		value, err := strconv.Unquote(raw)
+		if err != nil {
+			return "", fmt.Errorf("invalid string %s", raw)
+		}
+		return value, nil
+	case strings.HasPrefix(raw, "'"):
+		if len(raw) < 2 || !strings.HasSuffix(raw, "'") {
+			return "", fmt.Errorf("invalid string %s", raw)
+		}
+		// A quote inside single quotes is written twice.
+		return strings.ReplaceAll(raw[1:len(raw)-1], "''", "'"), nil
+	case strings.ContainsAny(raw[:1], "[{&*!|>%@`"):
+		return "", fmt.Errorf("unsupported value %s", raw)
+	}
+	return raw, nil
+}
@@ -0,0 +1,77 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const yamlSample = `---
+# Defaults for every command
+log_level: info
+rate: 48000
+
+play:
+  min: 20.5   # Hz
+  mode: 'octaves'
+  sweep-duration: 30s
+
+serve:
+  addr: 127.0.0.1:9000
+  preset: "it's # loud"
+`
+
+func TestParseYAML(t *testing.T) {
+	f, err := ParseYAML(strings.NewReader(yamlSample))
+	if err != nil {
+		t.Fatalf("ParseYAML failed: %v", err)
+	}
+	tests := []struct{ section, key, want string }{
+		{"", "log-level", "info"},
+		{"", "rate", "48000"},
+		{"play", "min", "20.5"},
+		{"play", "mode", "octaves"},
+		{"play", "sweep-duration", "30s"},
+		{"serve", "addr", "127.0.0.1:9000"},
+		{"serve", "preset", "it's # loud"},
+	}
+	for _, tc := range tests {
+		if got := f.Section(tc.section)[tc.key]; got != tc.want {
+			t.Errorf("Incorrect %s.%s: got %q, want %q", tc.section, tc.key, got, tc.want)
+		}
+	}
+
+	for _, input := range []string{
+		"min",
+		"  min: 1",
+		"play:\n  min: 1\n    max: 2",
+		"play:\n  nested:\n    min: 1",
+		"play:\n\tmin: 1",
+		"modes: [linear, sine]",
+		"min: 1\nmin: 2",
+		`out: "unterminated`,
+	} {
+		if _, err := ParseYAML(strings.NewReader(input)); err == nil {
+			t.Errorf("Expected an error for %q", input)
+		}
+	}
+}
+
+func TestLoadByExtension(t *testing.T) {
+	dir := t.TempDir()
+	yamlPath := filepath.Join(dir, "config.yml")
+	tomlPath := filepath.Join(dir, "config.toml")
+	os.WriteFile(yamlPath, []byte("play:\n  min: 30\n"), 0o644)
+	os.WriteFile(tomlPath, []byte("[play]\nmin = 30\n"), 0o644)
+
+	for _, path := range []string{yamlPath, tomlPath} {
+		f, err := Load(path)
+		if err != nil {
+			t.Fatalf("Load %s failed: %v", filepath.Base(path), err)
+		}
+		if got := f.Section("play")["min"]; got != "30" {
+			t.Errorf("%s: incorrect min: got %q, want 30", filepath.Base(path), got)
+		}
+	}
+}
@@ -0,0 +1,166 @@
+package fsg
+
+import (
+	"fmt"
+	"math"
+	"math/cmplx"
+)
+
+type FilterType int
+
+const (
+	FilterPeaking FilterType = iota
+	FilterLowShelf
+	FilterHighShelf
+	FilterLowPass
+	FilterHighPass
+	FilterNotch
+	FilterAllPass
+)
+
+// Biquad is a second-order IIR filter with coefficients from Robert
+// Bristow-Johnson's Audio EQ Cookbook, normalised so that a0 = 1.
+type Biquad struct {
+	b0, b1, b2 float64
+	a1, a2     float64
+	z1, z2     float64
+	sampleRate float64
+	design     filterDesign
+}
+
+// filterDesign keeps the parameters of a biquad so that it can be
+// redesigned for another sample rate.
+type filterDesign struct {
+	filterType    FilterType
+	freq, q, gain float64
+}
+
+// NewBiquad designs a filter at freq Hz. gainDB is only used by the
+// peaking and shelf types; for shelves q sets the slope, with 0.707 giving
+// the steepest shelf without overshoot.
+func NewBiquad(filterType FilterType, sampleRate, freq, q, gainDB float64) (*Biquad, error) {
+	if sampleRate <= 0 {
+		return nil, fmt.Errorf("sample rate must be positive, got %v", sampleRate)
+	}
+	if freq <= 0 || freq >= sampleRate/2 {
+		return nil, fmt.Errorf("filter frequency must be between 0 and %v Hz, got %v", sampleRate/2, freq)
+	}
+	if q <= 0 {
+		return nil, fmt.Errorf("filter Q must be positive, got %v", q)
+	}
+
+	a := math.Pow(10, gainDB/40)
+	w0 := 2 * math.Pi * freq / sampleRate
+	cosW0 := math.Cos(w0)
+	alpha := math.Sin(w0) / (2 * q)
+
+	var b0, b1, b2, a0, a1, a2 float64
+	switch filterType {
+	case FilterPeaking:
+		b0, b1, b2 = 1+alpha*a, -2*cosW0, 1-alpha*a
+		a0, a1, a2 = 1+alpha/a, -2*cosW0, 1-alpha/a
+	case FilterLowShelf:
+		k := 2 * math.Sqrt(a) * alpha
+		b0 = a * ((a + 1) - (a-1)*cosW0 + k)
+		b1 = 2 * a * ((a - 1) - (a+1)*cosW0)
+		b2 = a * ((a + 1) - (a-1)*cosW0 - k)
+		a0 = (a + 1) + (a-1)*cosW0 + k
+		a1 = -2 * ((a - 1) + (a+1)*cosW0)
+		a2 = (a + 1) + (a-1)*cosW0 - k
+	case FilterHighShelf:
+		k := 2 * math.Sqrt(a) * alpha
+		b0 = a * ((a + 1) + (a-1)*cosW0 + k)
+		b1 = -2 * a * ((a - 1) + (a+1)*cosW0)
+		b2 = a * ((a + 1) + (a-1)*cosW0 - k)
+		a0 = (a + 1) - (a-1)*cosW0 + k
+		a1 = 2 * ((a - 1) - (a+1)*cosW0)
+		a2 = (a + 1) - (a-1)*cosW0 - k
+	case FilterLowPass:
+		b0, b1, b2 = (1-cosW0)/2, 1-cosW0, (1-cosW0)/2
+		a0, a1, a2 = 1+alpha, -2*cosW0, 1-alpha
+	case FilterHighPass:
+		b0, b1, b2 = (1+cosW0)/2, -(1 + cosW0), (1+cosW0)/2
+		a0, a1, a2 = 1+alpha, -2*cosW0, 1-alpha
+	case FilterNotch:
+		b0, b1, b2 = 1, -2*cosW0, 1
+		a0, a1, a2 = 1+alpha, -2*cosW0, 1-alpha
+	case FilterAllPass:
+		b0, b1, b2 = 1-alpha, -2*cosW0, 1+alpha
+		a0, a1, a2 = 1+alpha, -2*cosW0, 1-alpha
+	default:
+		return nil, fmt.Errorf("unknown filter type %d", filterType)
+	}
+
+	return &Biquad{
+		b0:         b0 / a0,
+		b1:         b1 / a0,
+		b2:         b2 / a0,
+		a1:         a1 / a0,
+		a2:         a2 / a0,
+		sampleRate: sampleRate,
+		design:     filterDesign{filterType, freq, q, gainDB},
+	}, nil
+}
+
+// resample returns the same filter designed for another sample rate,
+// keeping its state.
+func (b *Biquad) resample(sampleRate float64) (*Biquad, error) {
+	d := b.design
+	resampled, err := NewBiquad(d.filterType, sampleRate, d.freq, d.q, d.gain)
+	if err != nil {
+		return nil, err
+	}
+	resampled.z1, resampled.z2 = b.z1, b.z2
+	return resampled, nil
+}
+
+// Process filters one sample (transposed direct form II).
+func (b *Biquad) Process(x float64) float64 {
+	y := b.b0*x + b.z1
+	b.z1 = b.b1*x - b.a1*y + b.z2
+	b.z2 = b.b2*x - b.a2*y
+	return y
+}
+
+func (b *Biquad) Reset() {
+	b.z1, b.z2 = 0, 0
+}
+
+// Response returns the complex frequency response at freq Hz.
+func (b *Biquad) Response(freq float64) complex128 {
+	z1 := cmplx.Exp(complex(0, -2*math.Pi*freq/b.sampleRate))
+	z2 := z1 * z1
+	num := complex(b.b0, 0) + complex(b.b1, 0)*z1 + complex(b.b2, 0)*z2
+	den := 1 + complex(b.a1, 0)*z1 + complex(b.a2, 0)*z2
+	return num / den
+}
+
+// MagnitudeDB returns the gain of the filter at freq Hz in dB.
+func (b *Biquad) MagnitudeDB(freq float64) float64 {
+	return 20 * math.Log10(cmplx.Abs(b.Response(freq)))
+}
+
+// FilterChain runs biquads in series.
+type FilterChain []*Biquad
+
+func (c FilterChain) Process(x float64) float64 {
+	for _, b := range c {
+		x = b.Process(x)
+	}
+	return x
+}
+
+func (c FilterChain) Reset() {
+	for _, b := range c {
+		b.Reset()
+	}
+}
+
+// MagnitudeDB returns the combined gain of the chain at freq Hz in dB.
+func (c FilterChain) MagnitudeDB(freq float64) float64 {
+	total := 0.0
+	for _, b := range c {
+		total += b.MagnitudeDB(freq)
+	}
+	return total
+}
@@ -0,0 +1,101 @@
+package fsg
+
+import (
+	"math"
+	"testing"
+)
+
+func TestBiquadResponse(t *testing.T) {
+	const sampleRate = 48000
+	tests := []struct {
+		name       string
+		filterType FilterType
+		gainDB     float64
+		at         float64
+		want       float64
+	}{
+		{"peaking at centre", FilterPeaking, 6, 1000, 6},
+		{"peaking far away", FilterPeaking, 6, 20, 0},
+		{"low shelf below", FilterLowShelf, -6, 20, -6},
+		{"low shelf above", FilterLowShelf, -6, 20000, 0},
+		{"high shelf above", FilterHighShelf, 4, 20000, 4},
+		{"low-pass at cutoff", FilterLowPass, 0, 1000, -3.01},
+		{"low-pass passband", FilterLowPass, 0, 20, 0},
+		{"high-pass at cutoff", FilterHighPass, 0, 1000, -3.01},
+		{"all-pass", FilterAllPass, 0, 3000, 0},
+	}
+	for _, tc := range tests {
+		b, err := NewBiquad(tc.filterType, sampleRate, 1000, 1/math.Sqrt2, tc.gainDB)
+		if err != nil {
+			t.Fatalf("%s: %v", tc.name, err)
+		}
+		if got := b.MagnitudeDB(tc.at); math.Abs(got-tc.want) > 0.05 {
+			t.Errorf("%s: got %.2f dB at %v Hz, want %.2f dB", tc.name, got, tc.at, tc.want)
+		}
+	}
+
+	notch, _ := NewBiquad(FilterNotch, sampleRate, 1000, 2, 0)
+	if got := notch.MagnitudeDB(1000); got > -100 {
+		t.Errorf("Notch should reject its centre frequency, got %.2f dB", got)
+	}
+}
+
+func TestBiquadProcessMatchesResponse(t *testing.T) {
+	const sampleRate = 48000
+	b, _ := NewBiquad(FilterPeaking, sampleRate, 2000, 2, -9)
+	want := math.Pow(10, b.MagnitudeDB(2000)/20)
+
+	peak := 0.0
+	for i := 0; i < sampleRate/2; i++ {
+		y := b.Process(math.Sin(2 * math.Pi * 2000 * float64(i) / sampleRate))
+		if i > sampleRate/4 {
+			peak = math.Max(peak, math.Abs(y))
+		}
+	}
+	if math.Abs(peak-want) > 1e-3 {
+		t.Errorf("Steady-state amplitude: got %v, want %v", peak, want)
+	}
+}
+
+func TestNewBiquadInvalid(t *testing.T) {
+	if _, err := NewBiquad(FilterLowPass, 48000, 30000, 0.7, 0); err == nil {
+		t.Error("Expected an error for a frequency above Nyquist")
+	}
+	if _, err := NewBiquad(FilterLowPass, 48000, 1000, 0, 0); err == nil {
+		t.Error("Expected an error for a zero Q")
+	}
+	if _, err := NewBiquad(FilterType(99), 48000, 1000, 0.7, 0); err == nil {
+		t.Error("Expected an error for an unknown filter type")
+	}
+}
+
+func TestGeneratorFilters(t *testing.T) {
+	gen := NewFrequencySweepGenerator(5000, 5000, 48000, 2)
+	if err := gen.AddFilter(1, FilterLowPass, 500, 1/math.Sqrt2, 0); err != nil {
+		t.Fatalf("AddFilter failed: %v", err)
+	}
+	if err := gen.AddFilter(2, FilterLowPass, 500, 1/math.Sqrt2, 0); err == nil {
+		t.Error("Expected an error for a channel out of range")
+	}
+
+	peaks := func(samples []float32) [2]float64 {
+		var p [2]float64
+		for i, s := range samples[len(samples)/2:] {
+			p[i%2] = math.Max(p[i%2], math.Abs(float64(s)))
+		}
+		return p
+	}
+
+	p := peaks(gen.Render(4800))
+	if p[0] < 0.99 {
+		t.Errorf("Unfiltered channel 0 peak: got %v, want 1", p[0])
+	}
+	if p[1] > 0.05 {
+		t.Errorf("Low-passed channel 1 peak: got %v, want < 0.05", p[1])
+	}
+
+	gen.ClearFilters(-1)
+	if p := peaks(gen.Render(4800)); p[1] < 0.99 {
+		t.Errorf("Channel 1 peak after ClearFilters: got %v, want 1", p[1])
+	}
+}
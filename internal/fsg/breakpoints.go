@@ -0,0 +1,289 @@
+package fsg
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Interpolation is how a breakpoint curve moves from one point to the next.
+type Interpolation int
+
+const (
+	InterpolateLinear Interpolation = iota
+	// InterpolateLog is linear in log frequency.
+	InterpolateLog
+	// InterpolateCubic is a monotone cubic through the neighbouring points;
+	// it never overshoots them.
+	InterpolateCubic
+	// InterpolateStep holds the segment's start frequency.
+	InterpolateStep
+)
+
+var interpolationNames = map[string]Interpolation{
+	"linear": InterpolateLinear,
+	"log":    InterpolateLog,
+	"cubic":  InterpolateCubic,
+	"step":   InterpolateStep,
+}
+
+func ParseInterpolation(name string) (Interpolation, error) {
+	interp, ok := interpolationNames[strings.ToLower(strings.TrimSpace(name))]
+	if !ok {
+		return 0, fmt.Errorf("unknown interpolation: %s", name)
+	}
+	return interp, nil
+}
+
+func (i Interpolation) String() string {
+	for name, v := range interpolationNames {
+		if v == i {
+			return name
+		}
+	}
+	return strconv.Itoa(int(i))
+}
+
+func (i Interpolation) MarshalText() ([]byte, error) {
+	return []byte(i.String()), nil
+}
+
+func (i *Interpolation) UnmarshalText(text []byte) error {
+	v, err := ParseInterpolation(string(text))
+	*i = v
+	return err
+}
+
+// Breakpoint is a point of a custom sweep. Time is in seconds and
+// Interpolation applies to the segment that starts at the point.
+type Breakpoint struct {
+	Time          float64       `json:"time"`
+	Frequency     float64       `json:"frequency"`
+	Interpolation Interpolation `json:"interpolation"`
+}
+
+// Breakpoints is a custom frequency trajectory, sorted by time.
+type Breakpoints struct {
+	points   []Breakpoint
+	tangents []float64
+}
+
+// NewBreakpoints validates points and prepares them for interpolation.
+func NewBreakpoints(points []Breakpoint) (*Breakpoints, error) {
+	if len(points) == 0 {
+		return nil, fmt.Errorf("breakpoint curve has no points")
+	}
+	sorted := append([]Breakpoint(nil), points...)
+	sort.SliceStable(sorted, func(i, j int) bool { return sorted[i].Time < sorted[j].Time })
+	for i, p := range sorted {
+		if p.Time < 0 || p.Frequency < 0 {
+			return nil, fmt.Errorf("breakpoint %d: time and frequency must not be negative", i)
+		}
+		if i > 0 && p.Time == sorted[i-1].Time {
+			return nil, fmt.Errorf("breakpoint %d: duplicate time %v", i, p.Time)
+		}
+		if p.Interpolation == InterpolateLog && i+1 < len(sorted) && (p.Frequency == 0 || sorted[i+1].Frequency == 0) {
+			return nil, fmt.Errorf("breakpoint %d: log segments need positive frequencies", i)
+		}
+	}
+	return &Breakpoints{points: sorted, tangents: monotoneTangents(sorted)}, nil
+}
+
+// monotoneTangents returns Fritsch-Carlson tangents, which keep a cubic
+// Hermite spline within the range of each pair of points.
+func monotoneTangents(points []Breakpoint) []float64 {
+	n := len(points)
+	tangents := make([]float64, n)
+	if n < 2 {
+		return tangents
+	}
+	slopes := make([]float64, n-1)
+	for i := range slopes {
+		slopes[i] = (points[i+1].Frequency - points[i].Frequency) / (points[i+1].Time - points[i].Time)
+	}
+	tangents[0], tangents[n-1] = slopes[0], slopes[n-2]
+	for i := 1; i < n-1; i++ {
+		if slopes[i-1]*slopes[i] <= 0 {
+			continue
+		}
+		tangents[i] = (slopes[i-1] + slopes[i]) / 2
+	}
+	for i, s := range slopes {
+		if s == 0 {
+			tangents[i], tangents[i+1] = 0, 0
+			continue
+		}
+		a, b := tangents[i]/s, tangents[i+1]/s
+		if h := math.Hypot(a, b); h > 3 {
+			tangents[i], tangents[i+1] = 3*a/h*s, 3*b/h*s
+		}
+	}
+	return tangents
+}
+
+// Duration returns the time of the last point.
+func (b *Breakpoints) Duration() float64 {
+	return b.points[len(b.points)-1].Time
+}
+
+// Range returns the lowest and highest frequency the curve reaches.
+func (b *Breakpoints) Range() (float64, float64) {
+	lo, hi := b.points[0].Frequency, b.points[0].Frequency
+	for _, p := range b.points {
+		lo, hi = math.Min(lo, p.Frequency), math.Max(hi, p.Frequency)
+	}
+	return lo, hi
+}
+
+// Frequency returns the frequency at t seconds, holding the first and last
+// points outside the curve.
+func (b *Breakpoints) Frequency(t float64) float64 {
+	points := b.points
+	if t <= points[0].Time {
+		return points[0].Frequency
+	}
+	if t >= points[len(points)-1].Time {
+		return points[len(points)-1].Frequency
+	}
+
+	i := sort.Search(len(points), func(i int) bool { return points[i].Time > t }) - 1
+	p0, p1 := points[i], points[i+1]
+	span := p1.Time - p0.Time
+	x := (t - p0.Time) / span
+	switch p0.Interpolation {
+	case InterpolateLog:
+		return p0.Frequency * math.Pow(p1.Frequency/p0.Frequency, x)
+	case InterpolateCubic:
+		h00 := 2*x*x*x - 3*x*x + 1
+		h10 := x*x*x - 2*x*x + x
+		h01 := -2*x*x*x + 3*x*x
+		h11 := x*x*x - x*x
+		return h00*p0.Frequency + h10*span*b.tangents[i] + h01*p1.Frequency + h11*span*b.tangents[i+1]
+	case InterpolateStep:
+		return p0.Frequency
+	default:
+		return p0.Frequency + (p1.Frequency-p0.Frequency)*x
+	}
+}
+
+// ParseBreakpoints reads a curve from JSON, either an array of
+// {"time", "frequency", "interpolation"} objects or {"points": [...]}, or
+// from CSV rows of time,frequency[,interpolation] with an optional header.
+func ParseBreakpoints(r io.Reader) (*Breakpoints, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	text := strings.TrimSpace(string(data))
+	if strings.HasPrefix(text, "[") || strings.HasPrefix(text, "{") {
+		return parseBreakpointsJSON([]byte(text))
+	}
+	return parseBreakpointsCSV(text)
+}
+
+func parseBreakpointsJSON(data []byte) (*Breakpoints, error) {
+	var points []Breakpoint
+	if data[0] == '{' {
+		var doc struct {
+			Points []Breakpoint `json:"points"`
+		}
+		if err := json.Unmarshal(data, &doc); err != nil {
+			return nil, fmt.Errorf("breakpoints: %w", err)
+		}
+		points = doc.Points
+	} else if err := json.Unmarshal(data, &points); err != nil {
+		return nil, fmt.Errorf("breakpoints: %w", err)
+	}
+	return NewBreakpoints(points)
+}
+
+func parseBreakpointsCSV(text string) (*Breakpoints, error) {
+	reader := csv.NewReader(strings.NewReader(text))
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+	reader.Comment = '#'
+	rows, err := reader.ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("breakpoints: %w", err)
+	}
+
+	var points []Breakpoint
+	for i, row := range rows {
+		if len(row) < 2 {
+			return nil, fmt.Errorf("breakpoints: row %d: need time,frequency", i+1)
+		}
+		t, err := strconv.ParseFloat(row[0], 64)
+		if err != nil {
+			if i == 0 {
+				continue // header
+			}
+			return nil, fmt.Errorf("breakpoints: row %d: invalid time %q", i+1, row[0])
+		}
+		freq, err := strconv.ParseFloat(row[1], 64)
+		if err != nil {
+			return nil, fmt.Errorf("breakpoints: row %d: invalid frequency %q", i+1, row[1])
+		}
+		point := Breakpoint{Time: t, Frequency: freq}
+		if len(row) > 2 && row[2] != "" {
+			if point.Interpolation, err = ParseInterpolation(row[2]); err != nil {
+				return nil, fmt.Errorf("breakpoints: row %d: %w", i+1, err)
+			}
+		}
+		points = append(points, point)
+	}
+	return NewBreakpoints(points)
+}
+
+func LoadBreakpoints(path string) (*Breakpoints, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return ParseBreakpoints(f)
+}
+
+// SetBreakpoints switches to SweepModeCustom, which follows curve from its
+// start. At the end it holds the last frequency, or starts again when loop
+// is set.
+//
+// The sweep range becomes that of the curve, validated like a Config, and
+// stays so when another mode is set later; call SetFrequencyRange to
+// change it back. A nil curve only clears the curve.
+func (g *FrequencySweepGenerator) SetBreakpoints(curve *Breakpoints, loop bool) error {
+	g.mutex.Lock()
+	defer g.mutex.Unlock()
+	if curve != nil {
+		if err := g.replaceRange(curve.Range()); err != nil {
+			return err
+		}
+		g.sweepMode = SweepModeCustom
+	}
+	g.curve = curve
+	g.curveLoop = loop
+	g.curveFrame = 0
+	return nil
+}
+
+func (g *FrequencySweepGenerator) curveFrequency() float64 {
+	if g.curve == nil {
+		return g.minFrequency
+	}
+	return g.curve.Frequency(float64(g.curveFrame) / float64(g.sampleRate))
+}
+
+func (g *FrequencySweepGenerator) advanceCurve() {
+	if g.curve == nil {
+		return
+	}
+	g.curveFrame++
+	if end := int(g.curve.Duration() * float64(g.sampleRate)); g.curveLoop && end > 0 && g.curveFrame >= end {
+		g.curveFrame = 0
+	}
+}
@@ -0,0 +1,126 @@
+package fsg
+
+import (
+	"errors"
+	"math"
+	"strings"
+	"testing"
+)
+
+func TestBreakpointInterpolation(t *testing.T) {
+	curve, err := NewBreakpoints([]Breakpoint{
+		{Time: 0, Frequency: 100, Interpolation: InterpolateLinear},
+		{Time: 1, Frequency: 200, Interpolation: InterpolateLog},
+		{Time: 2, Frequency: 800, Interpolation: InterpolateStep},
+		{Time: 3, Frequency: 400},
+	})
+	if err != nil {
+		t.Fatalf("NewBreakpoints failed: %v", err)
+	}
+
+	tests := map[float64]float64{
+		-1:  100,
+		0.5: 150,
+		1.5: 400,
+		2.9: 800,
+		3:   400,
+		10:  400,
+	}
+	for at, want := range tests {
+		if got := curve.Frequency(at); math.Abs(got-want) > 1e-9 {
+			t.Errorf("Incorrect frequency at %vs: got %v, want %v", at, got, want)
+		}
+	}
+	if lo, hi := curve.Range(); lo != 100 || hi != 800 {
+		t.Errorf("Incorrect range: got %v-%v, want 100-800", lo, hi)
+	}
+}
+
+func TestBreakpointCubicIsMonotone(t *testing.T) {
+	curve, err := NewBreakpoints([]Breakpoint{
+		{Time: 0, Frequency: 100, Interpolation: InterpolateCubic},
+		{Time: 1, Frequency: 1000, Interpolation: InterpolateCubic},
+		{Time: 1.1, Frequency: 1000, Interpolation: InterpolateCubic},
+		{Time: 3, Frequency: 200},
+	})
+	if err != nil {
+		t.Fatalf("NewBreakpoints failed: %v", err)
+	}
+
+	previous := curve.Frequency(0)
+	for i := 1; i <= 1000; i++ {
+		at := 3 * float64(i) / 1000
+		f := curve.Frequency(at)
+		if f < 100-1e-9 || f > 1000+1e-9 {
+			t.Fatalf("Cubic overshoots at %vs: %v", at, f)
+		}
+		if at <= 1 && f < previous-1e-9 || at >= 1.1 && f > previous+1e-9 {
+			t.Fatalf("Cubic is not monotone at %vs: %v after %v", at, f, previous)
+		}
+		previous = f
+	}
+}
+
+func TestParseBreakpoints(t *testing.T) {
+	inputs := map[string]string{
+		"json":  `[{"time": 0, "frequency": 100, "interpolation": "log"}, {"time": 2, "frequency": 400}]`,
+		"doc":   `{"points": [{"time": 2, "frequency": 400}, {"time": 0, "frequency": 100, "interpolation": "log"}]}`,
+		"csv":   "time,frequency,interpolation\n0,100,log\n2,400\n",
+		"plain": "# sweep\n0, 100, log\n2, 400",
+	}
+	for name, input := range inputs {
+		curve, err := ParseBreakpoints(strings.NewReader(input))
+		if err != nil {
+			t.Errorf("%s: ParseBreakpoints failed: %v", name, err)
+			continue
+		}
+		if got := curve.Frequency(1); math.Abs(got-200) > 1e-9 {
+			t.Errorf("%s: incorrect frequency: got %v, want 200", name, got)
+		}
+		if curve.Duration() != 2 {
+			t.Errorf("%s: incorrect duration: got %v, want 2", name, curve.Duration())
+		}
+	}
+
+	for _, input := range []string{
+		"",
+		"[]",
+		"0,100\n0,200",
+		"0,-100\n1,200",
+		"0,0,log\n1,200",
+		"0,100,spline\n1,200",
+		`[{"time": 0, "frequency": 100, "interpolation": "spline"}]`,
+		"0,100\nx,200",
+	} {
+		if _, err := ParseBreakpoints(strings.NewReader(input)); err == nil {
+			t.Errorf("Expected an error for %q", input)
+		}
+	}
+}
+
+func TestCustomSweep(t *testing.T) {
+	curve, _ := ParseBreakpoints(strings.NewReader("0,100\n0.01,200"))
+	gen := NewFrequencySweepGenerator(20, 20000, 1000, 1)
+	if err := gen.SetBreakpoints(curve, false); err != nil {
+		t.Fatalf("SetBreakpoints failed: %v", err)
+	}
+
+	trace := sweepTrace(gen, 20)
+	if trace[0] != 100 || math.Abs(trace[5]-150) > 1e-9 || trace[19] != 200 {
+		t.Errorf("Incorrect custom sweep: %v", trace)
+	}
+
+	gen.SetBreakpoints(curve, true)
+	trace = sweepTrace(gen, 20)
+	if trace[10] != 100 || math.Abs(trace[15]-150) > 1e-9 {
+		t.Errorf("Looping custom sweep should restart: %v", trace)
+	}
+
+	high, _ := ParseBreakpoints(strings.NewReader("0,100\n1,600"))
+	if err := gen.SetBreakpoints(high, false); !errors.Is(err, ErrAboveNyquist) {
+		t.Errorf("Expected ErrAboveNyquist for a curve above Nyquist, got %v", err)
+	}
+	if gen.curve != curve || gen.minFrequency != 100 || gen.maxFrequency != 200 {
+		t.Errorf("A rejected curve should change nothing: %v-%v", gen.minFrequency, gen.maxFrequency)
+	}
+}
@@ -0,0 +1,64 @@
+package fsg
+
+import (
+	"errors"
+	"fmt"
+	"math"
+)
+
+var (
+	ErrInvalidRange      = errors.New("invalid frequency range")
+	ErrAboveNyquist      = errors.New("frequency above Nyquist")
+	ErrInvalidSampleRate = errors.New("invalid sample rate")
+	ErrInvalidChannels   = errors.New("invalid channel count")
+)
+
+// The limits of miniaudio, which backs the playback devices.
+const (
+	MaxSampleRate = 384000
+	MaxChannels   = 254
+)
+
+// Config holds the parameters a generator cannot change after it is
+// created.
+type Config struct {
+	MinFrequency float64
+	MaxFrequency float64
+	SampleRate   uint32
+	Channels     uint32
+}
+
+func DefaultConfig() Config {
+	return Config{MinFrequency: 220, MaxFrequency: 880, SampleRate: 44100, Channels: 2}
+}
+
+// Validate reports the first invalid parameter, wrapping one of the Err
+// values so that callers can tell them apart with errors.Is.
+func (c Config) Validate() error {
+	if c.SampleRate == 0 || c.SampleRate > MaxSampleRate {
+		return fmt.Errorf("%w: %d Hz, must be between 1 and %d", ErrInvalidSampleRate, c.SampleRate, MaxSampleRate)
+	}
+	if c.Channels == 0 || c.Channels > MaxChannels {
+		return fmt.Errorf("%w: %d, must be between 1 and %d", ErrInvalidChannels, c.Channels, MaxChannels)
+	}
+	for _, f := range []float64{c.MinFrequency, c.MaxFrequency} {
+		if f < 0 || math.IsNaN(f) || math.IsInf(f, 0) {
+			return fmt.Errorf("%w: %v Hz is not a valid frequency", ErrInvalidRange, f)
+		}
+	}
+	if c.MinFrequency > c.MaxFrequency {
+		return fmt.Errorf("%w: minimum %v Hz is above maximum %v Hz", ErrInvalidRange, c.MinFrequency, c.MaxFrequency)
+	}
+	if nyquist := float64(c.SampleRate) / 2; c.MaxFrequency > nyquist {
+		return fmt.Errorf("%w: %v Hz at a sample rate of %d Hz (Nyquist %v Hz)", ErrAboveNyquist, c.MaxFrequency, c.SampleRate, nyquist)
+	}
+	return nil
+}
+
+// New validates config and creates a generator from it.
+func New(config Config, options ...Option) (*FrequencySweepGenerator, error) {
+	if err := config.Validate(); err != nil {
+		return nil, err
+	}
+	return NewFrequencySweepGenerator(config.MinFrequency, config.MaxFrequency, config.SampleRate, config.Channels, options...), nil
+}
@@ -0,0 +1,60 @@
+package fsg
+
+import (
+	"errors"
+	"math"
+	"testing"
+)
+
+func TestConfigValidate(t *testing.T) {
+	if err := DefaultConfig().Validate(); err != nil {
+		t.Errorf("Default config should be valid: %v", err)
+	}
+
+	tests := []struct {
+		name   string
+		modify func(*Config)
+		want   error
+	}{
+		{"zero sample rate", func(c *Config) { c.SampleRate = 0 }, ErrInvalidSampleRate},
+		{"huge sample rate", func(c *Config) { c.SampleRate = MaxSampleRate + 1 }, ErrInvalidSampleRate},
+		{"zero channels", func(c *Config) { c.Channels = 0 }, ErrInvalidChannels},
+		{"too many channels", func(c *Config) { c.Channels = MaxChannels + 1 }, ErrInvalidChannels},
+		{"negative minimum", func(c *Config) { c.MinFrequency = -1 }, ErrInvalidRange},
+		{"NaN maximum", func(c *Config) { c.MaxFrequency = math.NaN() }, ErrInvalidRange},
+		{"inverted range", func(c *Config) { c.MinFrequency, c.MaxFrequency = 880, 220 }, ErrInvalidRange},
+		{"above Nyquist", func(c *Config) { c.MaxFrequency = 22051 }, ErrAboveNyquist},
+	}
+	for _, tc := range tests {
+		config := DefaultConfig()
+		tc.modify(&config)
+		if err := config.Validate(); !errors.Is(err, tc.want) {
+			t.Errorf("%s: got %v, want %v", tc.name, err, tc.want)
+		}
+	}
+
+	config := DefaultConfig()
+	config.MinFrequency, config.MaxFrequency = 1000, 1000
+	if err := config.Validate(); err != nil {
+		t.Errorf("A fixed frequency should be valid: %v", err)
+	}
+	config.MaxFrequency = 22050
+	config.MinFrequency = 0
+	if err := config.Validate(); err != nil {
+		t.Errorf("Nyquist itself should be valid: %v", err)
+	}
+}
+
+func TestNew(t *testing.T) {
+	gen, err := New(Config{MinFrequency: 100, MaxFrequency: 1000, SampleRate: 8000, Channels: 1})
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+	if gen.minFrequency != 100 || gen.maxFrequency != 1000 || gen.sampleRate != 8000 || gen.channels != 1 {
+		t.Errorf("Config not applied: %+v", gen)
+	}
+
+	if gen, err := New(Config{MinFrequency: 100, MaxFrequency: 5000, SampleRate: 8000, Channels: 1}); !errors.Is(err, ErrAboveNyquist) || gen != nil {
+		t.Errorf("Expected ErrAboveNyquist and no generator, got %v, %v", gen, err)
+	}
+}
@@ -0,0 +1,229 @@
+package fsg
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Curve shapes the segments of an envelope.
+type Curve int
+
+const (
+	CurveLinear Curve = iota
+	// CurveExponential moves quickly at first and settles gradually, like
+	// an analog RC envelope.
+	CurveExponential
+	// CurveRaisedCosine is a half Hann window, smooth at both ends.
+	CurveRaisedCosine
+)
+
+// expCurvature is the time constant of CurveExponential: a segment covers
+// 1-e^-5 (99.3%) of its range before being normalised to end exactly.
+const expCurvature = 5.0
+
+// shape maps x in [0, 1] to the progress of a segment in [0, 1].
+func (c Curve) shape(x float64) float64 {
+	x = math.Max(0, math.Min(1, x))
+	switch c {
+	case CurveExponential:
+		return (1 - math.Exp(-expCurvature*x)) / (1 - math.Exp(-expCurvature))
+	case CurveRaisedCosine:
+		return 0.5 - 0.5*math.Cos(math.Pi*x)
+	default:
+		return x
+	}
+}
+
+// ADSR describes an attack-decay-sustain-release envelope. Sustain is a
+// level in [0, 1].
+type ADSR struct {
+	Attack  time.Duration
+	Decay   time.Duration
+	Sustain float64
+	Release time.Duration
+	Curve   Curve
+}
+
+type envelopeStage int
+
+const (
+	stageIdle envelopeStage = iota
+	stageAttack
+	stageDecay
+	stageSustain
+	stageRelease
+	stageDone
+)
+
+// Envelope runs an ADSR one frame at a time.
+type Envelope struct {
+	adsr        ADSR
+	sampleRate  float64
+	stage       envelopeStage
+	position    int
+	level       float64
+	releaseFrom float64
+}
+
+func NewEnvelope(adsr ADSR, sampleRate uint32) *Envelope {
+	return &Envelope{adsr: adsr, sampleRate: float64(sampleRate)}
+}
+
+// Trigger restarts the attack from the current level.
+func (e *Envelope) Trigger() {
+	e.stage = stageAttack
+	e.position = 0
+	e.releaseFrom = e.level
+}
+
+// Release starts the release from the current level.
+func (e *Envelope) Release() {
+	if e.stage == stageIdle || e.stage == stageDone {
+		return
+	}
+	e.stage = stageRelease
+	e.position = 0
+	e.releaseFrom = e.level
+}
+
+// Done reports whether the release has finished.
+func (e *Envelope) Done() bool {
+	return e.stage == stageDone
+}
+
+func (e *Envelope) frames(d time.Duration) int {
+	return int(d.Seconds() * e.sampleRate)
+}
+
+// Next returns the envelope level for the next frame.
+func (e *Envelope) Next() float64 {
+	curve := e.adsr.Curve
+	switch e.stage {
+	case stageAttack:
+		if n := e.frames(e.adsr.Attack); e.position < n {
+			// Attack starts from releaseFrom, so a retrigger does not click.
+			e.level = e.releaseFrom + (1-e.releaseFrom)*curve.shape(float64(e.position)/float64(n))
+			e.position++
+			return e.level
+		}
+		e.stage, e.position = stageDecay, 0
+		fallthrough
+	case stageDecay:
+		if n := e.frames(e.adsr.Decay); e.position < n {
+			e.level = 1 - (1-e.adsr.Sustain)*curve.shape(float64(e.position)/float64(n))
+			e.position++
+			return e.level
+		}
+		e.stage = stageSustain
+		fallthrough
+	case stageSustain:
+		e.level = e.adsr.Sustain
+	case stageRelease:
+		if n := e.frames(e.adsr.Release); e.position < n {
+			e.level = e.releaseFrom * (1 - curve.shape(float64(e.position)/float64(n)))
+			e.position++
+			return e.level
+		}
+		e.stage = stageDone
+		e.level = 0
+	default:
+		e.level = 0
+	}
+	return e.level
+}
+
+// ToneBurst gates the carrier on for On cycles and off for Off cycles.
+// Windowed applies a raised-cosine (Hann) window across each burst, which
+// narrows its spectrum and spares tweeters the edges of a rectangular gate.
+type ToneBurst struct {
+	On       int
+	Off      int
+	Windowed bool
+}
+
+// gain returns the burst gain at cycles carrier cycles into the signal.
+func (b ToneBurst) gain(cycles float64) float64 {
+	position := math.Mod(cycles, float64(b.On+b.Off))
+	if position >= float64(b.On) {
+		return 0
+	}
+	if b.Windowed {
+		return 0.5 - 0.5*math.Cos(2*math.Pi*position/float64(b.On))
+	}
+	return 1
+}
+
+// ParseADSR parses "attack,decay,sustain,release[:curve]", with durations
+// such as 10ms and curve one of linear, exp or cosine.
+func ParseADSR(spec string) (ADSR, error) {
+	var adsr ADSR
+	spec, curve, hasCurve := strings.Cut(spec, ":")
+	if hasCurve {
+		var err error
+		if adsr.Curve, err = ParseCurve(curve); err != nil {
+			return adsr, err
+		}
+	}
+
+	parts := strings.Split(spec, ",")
+	if len(parts) != 4 {
+		return adsr, fmt.Errorf("ADSR must be attack,decay,sustain,release, got %q", spec)
+	}
+	durations := make([]time.Duration, 0, 3)
+	for _, i := range []int{0, 1, 3} {
+		d, err := time.ParseDuration(strings.TrimSpace(parts[i]))
+		if err != nil || d < 0 {
+			return adsr, fmt.Errorf("invalid ADSR duration %q", parts[i])
+		}
+		durations = append(durations, d)
+	}
+	sustain, err := strconv.ParseFloat(strings.TrimSpace(parts[2]), 64)
+	if err != nil || sustain < 0 || sustain > 1 {
+		return adsr, fmt.Errorf("ADSR sustain must be between 0 and 1, got %q", parts[2])
+	}
+
+	adsr.Attack, adsr.Decay, adsr.Release = durations[0], durations[1], durations[2]
+	adsr.Sustain = sustain
+	return adsr, nil
+}
+
+func ParseCurve(name string) (Curve, error) {
+	switch name {
+	case "linear":
+		return CurveLinear, nil
+	case "exp", "exponential":
+		return CurveExponential, nil
+	case "cosine", "hann":
+		return CurveRaisedCosine, nil
+	default:
+		return 0, fmt.Errorf("unknown envelope curve: %s", name)
+	}
+}
+
+// ParseToneBurst parses "on,off[:hann]" in carrier cycles.
+func ParseToneBurst(spec string) (ToneBurst, error) {
+	var burst ToneBurst
+	spec, window, hasWindow := strings.Cut(spec, ":")
+	if hasWindow {
+		if window != "hann" && window != "cosine" {
+			return burst, fmt.Errorf("unknown burst window: %s", window)
+		}
+		burst.Windowed = true
+	}
+
+	on, off, ok := strings.Cut(spec, ",")
+	if !ok {
+		return burst, fmt.Errorf("tone burst must be on,off cycles, got %q", spec)
+	}
+	var err error
+	if burst.On, err = strconv.Atoi(strings.TrimSpace(on)); err != nil || burst.On <= 0 {
+		return burst, fmt.Errorf("tone burst on cycles must be a positive integer, got %q", on)
+	}
+	if burst.Off, err = strconv.Atoi(strings.TrimSpace(off)); err != nil || burst.Off < 0 {
+		return burst, fmt.Errorf("tone burst off cycles must be a non-negative integer, got %q", off)
+	}
+	return burst, nil
+}
@@ -0,0 +1,148 @@
+package fsg
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+func TestEnvelopeStages(t *testing.T) {
+	// At 1 kHz each millisecond is one frame.
+	env := NewEnvelope(ADSR{
+		Attack:  10 * time.Millisecond,
+		Decay:   10 * time.Millisecond,
+		Sustain: 0.5,
+		Release: 20 * time.Millisecond,
+	}, 1000)
+
+	if level := env.Next(); level != 0 {
+		t.Errorf("Untriggered envelope: got %v, want 0", level)
+	}
+
+	env.Trigger()
+	levels := make([]float64, 40)
+	for i := range levels {
+		levels[i] = env.Next()
+	}
+	checks := map[int]float64{0: 0, 5: 0.5, 10: 1, 15: 0.75, 20: 0.5, 39: 0.5}
+	for i, want := range checks {
+		if math.Abs(levels[i]-want) > 1e-9 {
+			t.Errorf("Level at frame %d: got %v, want %v", i, levels[i], want)
+		}
+	}
+
+	env.Release()
+	for i := 0; i < 10; i++ {
+		env.Next()
+	}
+	if level := env.Next(); math.Abs(level-0.25) > 1e-9 {
+		t.Errorf("Level halfway through release: got %v, want 0.25", level)
+	}
+	for i := 0; i < 10; i++ {
+		env.Next()
+	}
+	if !env.Done() || env.Next() != 0 {
+		t.Error("Envelope should be silent and done after its release")
+	}
+}
+
+func TestCurveShapes(t *testing.T) {
+	for _, curve := range []Curve{CurveLinear, CurveExponential, CurveRaisedCosine} {
+		if curve.shape(0) != 0 || math.Abs(curve.shape(1)-1) > 1e-12 {
+			t.Errorf("Curve %d should run from 0 to 1", curve)
+		}
+		previous := 0.0
+		for x := 0.01; x <= 1; x += 0.01 {
+			if v := curve.shape(x); v < previous {
+				t.Errorf("Curve %d is not monotonic at %v", curve, x)
+			} else {
+				previous = v
+			}
+		}
+	}
+	if CurveExponential.shape(0.2) <= CurveLinear.shape(0.2) {
+		t.Error("Exponential curve should rise faster than linear at first")
+	}
+	if math.Abs(CurveRaisedCosine.shape(0.5)-0.5) > 1e-12 {
+		t.Error("Raised cosine should be symmetric about its midpoint")
+	}
+}
+
+func TestParseADSR(t *testing.T) {
+	adsr, err := ParseADSR("5ms,50ms,0.7,200ms:exp")
+	if err != nil {
+		t.Fatalf("ParseADSR failed: %v", err)
+	}
+	want := ADSR{5 * time.Millisecond, 50 * time.Millisecond, 0.7, 200 * time.Millisecond, CurveExponential}
+	if adsr != want {
+		t.Errorf("Incorrect ADSR: got %+v, want %+v", adsr, want)
+	}
+
+	for _, spec := range []string{"5ms,50ms,0.7", "5ms,50ms,1.5,1s", "5,50ms,0.7,1s", "5ms,50ms,0.7,1s:steep"} {
+		if _, err := ParseADSR(spec); err == nil {
+			t.Errorf("Expected an error for %q", spec)
+		}
+	}
+}
+
+func TestParseToneBurst(t *testing.T) {
+	burst, err := ParseToneBurst("4,12:hann")
+	if err != nil {
+		t.Fatalf("ParseToneBurst failed: %v", err)
+	}
+	if burst != (ToneBurst{On: 4, Off: 12, Windowed: true}) {
+		t.Errorf("Incorrect burst: got %+v", burst)
+	}
+	for _, spec := range []string{"4", "0,4", "4,-1", "4,4:square"} {
+		if _, err := ParseToneBurst(spec); err == nil {
+			t.Errorf("Expected an error for %q", spec)
+		}
+	}
+}
+
+func TestGeneratorToneBurst(t *testing.T) {
+	// 1 kHz at 48 kHz is 48 samples per cycle: 2 cycles on, 2 off.
+	gen := NewFrequencySweepGenerator(1000, 1000, 48000, 1)
+	gen.SetToneBurst(&ToneBurst{On: 2, Off: 2})
+	output := gen.Render(4 * 48 * 3)
+
+	for i, s := range output {
+		on := (i+1)%(4*48) < 2*48
+		if !on && math.Abs(float64(s)) > 1e-6 {
+			t.Fatalf("Sample %d should be gated off, got %v", i, s)
+		}
+	}
+	peak := 0.0
+	for _, s := range output[:96] {
+		peak = math.Max(peak, math.Abs(float64(s)))
+	}
+	if peak < 0.99 {
+		t.Errorf("Burst peak: got %v, want 1", peak)
+	}
+}
+
+func TestGeneratorEnvelope(t *testing.T) {
+	gen := NewFrequencySweepGenerator(1000, 1000, 48000, 1)
+	gen.SetEnvelope(&ADSR{Attack: 10 * time.Millisecond, Sustain: 0.5, Release: 10 * time.Millisecond})
+
+	peak := func(samples []float32) float64 {
+		p := 0.0
+		for _, s := range samples {
+			p = math.Max(p, math.Abs(float64(s)))
+		}
+		return p
+	}
+
+	if p := peak(gen.Render(48)); p > 0.1 {
+		t.Errorf("First millisecond of the attack: peak %v, want < 0.1", p)
+	}
+	gen.Render(960)
+	if p := peak(gen.Render(480)); math.Abs(p-0.5) > 1e-3 {
+		t.Errorf("Sustain peak: got %v, want 0.5", p)
+	}
+	gen.ReleaseEnvelope()
+	gen.Render(480)
+	if p := peak(gen.Render(480)); p != 0 {
+		t.Errorf("Output after release: peak %v, want 0", p)
+	}
+}
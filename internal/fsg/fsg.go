@@ -3,12 +3,15 @@ package fsg
 import (
 	"encoding/binary"
 	"fmt"
+	"log/slog"
 	"math"
 	"math/rand"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/gen2brain/malgo"
+	"github.com/hailam/malgoplay/internal/calibration"
 )
 
 type SweepMode int
@@ -22,25 +25,94 @@ const (
 	SweepModeSquare
 	SweepModeSawtooth
 	SweepModeRandom
+	// SweepModeOctaves rises exponentially in frequency, covering the same
+	// number of octaves every second (an exponential sine sweep).
+	SweepModeOctaves
+	// SweepModeNotes steps through musical pitches; see SetNoteSweep.
+	SweepModeNotes
+	// SweepModeCustom follows a breakpoint curve; see SetBreakpoints.
+	SweepModeCustom
 )
 
+var sweepModeNames = map[string]SweepMode{
+	"linear":      SweepModeLinear,
+	"sine":        SweepModeSine,
+	"triangle":    SweepModeTriangle,
+	"exponential": SweepModeExponential,
+	"logarithmic": SweepModeLogarithmic,
+	"square":      SweepModeSquare,
+	"sawtooth":    SweepModeSawtooth,
+	"random":      SweepModeRandom,
+	"octaves":     SweepModeOctaves,
+	"notes":       SweepModeNotes,
+	"custom":      SweepModeCustom,
+}
+
+// SweepModeNames returns the names ParseSweepMode accepts, in the order of
+// the SweepMode constants.
+func SweepModeNames() []string {
+	names := make([]string, 0, len(sweepModeNames))
+	for mode := SweepModeLinear; mode <= SweepModeCustom; mode++ {
+		names = append(names, mode.String())
+	}
+	return names
+}
+
+func ParseSweepMode(name string) (SweepMode, error) {
+	mode, ok := sweepModeNames[name]
+	if !ok {
+		return 0, fmt.Errorf("unknown sweep mode: %s", name)
+	}
+	return mode, nil
+}
+
+func (m SweepMode) String() string {
+	for name, mode := range sweepModeNames {
+		if mode == m {
+			return name
+		}
+	}
+	return fmt.Sprintf("SweepMode(%d)", int(m))
+}
+
+func (m SweepMode) MarshalText() ([]byte, error) {
+	return []byte(m.String()), nil
+}
+
+func (m *SweepMode) UnmarshalText(text []byte) error {
+	mode, err := ParseSweepMode(string(text))
+	*m = mode
+	return err
+}
+
 type AudioDevice interface {
 	Start() error
 	Stop() error
 	Uninit() error
 }
 
+// Source produces interleaved float32 samples, either from a device data
+// callback or offline through Render.
+type Source interface {
+	DataCallback(pOutputSample, pInputSamples []byte, framecount uint32)
+	Render(frames uint32) []float32
+}
+
 type FrequencySweepGenerator struct {
 	minFrequency     float64
 	maxFrequency     float64
 	sampleRate       uint32
 	channels         uint32
 	isPlaying        bool
+	paused           bool
 	currentFreq      float64
 	phase            float64
 	currentAmplitude float64
+	outputPeak       float64
 	targetAmplitude  float64
 	sweepRate        float64
+	timing           sweepTiming
+	timingValue      float64
 	sweepMode        SweepMode
 	sweepPhase       float64
 	sweepDirection   int
@@ -49,18 +121,55 @@ type FrequencySweepGenerator struct {
 	fadeStartTime    time.Time
 	isFadingIn       bool
 	isFadingOut      bool
-	randomSeed       int64
+	seed             int64
+	rng              *rand.Rand
+	random           RandomOptions
+	randomStarted    bool
+	randomFrom       float64
+	randomTo         float64
+	randomPosition   int
+	notes            []float64
+	noteStep         time.Duration
+	noteGlide        time.Duration
+	noteIndex        int
+	notePosition     int
+	notePrevious     float64
+	curve            *Breakpoints
+	curveLoop        bool
+	curveFrame       int
+	waveform         Waveform
+	rangeGlide       *rangeGlide
+	calibration      *calibration.Profile
+	calibrationScale float64
+	filters          []FilterChain
+	envelope         *Envelope
+	burst            *ToneBurst
+	burstCycles      float64
+	am               *lfo
+	options          *SweepOptions
+	passes           int
+	sweepDone        bool
+	done             chan struct{}
+	fm               *lfo
 	context          *malgo.AllocatedContext
 	device           AudioDevice
 	deviceConfig     malgo.DeviceConfig
 	isInitialized    bool
 	mutex            sync.Mutex
 	initMutex        sync.Mutex
-	enableLogging    bool
+	logger           *slog.Logger
+	logQueue         chan slog.Record
+	logDropped       int
+	callbackFrames   uint64
+	lastCallbackLog  time.Time
+	missedCallbacks  atomic.Uint64
 }
 
-func NewFrequencySweepGenerator(minFreq, maxFreq float64, sampleRate, channels uint32) *FrequencySweepGenerator {
-	return &FrequencySweepGenerator{
+// NewFrequencySweepGenerator creates a generator without checking its
+// parameters; New validates them first.
+func NewFrequencySweepGenerator(minFreq, maxFreq float64, sampleRate, channels uint32, options ...Option) *FrequencySweepGenerator {
+	seed := time.Now().UnixNano()
+	g := &FrequencySweepGenerator{
 		minFrequency:     minFreq,
 		maxFrequency:     maxFreq,
 		sampleRate:       sampleRate,
@@ -73,10 +182,16 @@ func NewFrequencySweepGenerator(minFreq, maxFreq float64, sampleRate, channels u
 		sweepDirection:   1,
 		fadeInDuration:   500 * time.Millisecond,
 		fadeOutDuration:  500 * time.Millisecond,
-		randomSeed:       time.Now().UnixNano(),
+		seed:             seed,
+		rng:              rand.New(rand.NewSource(seed)),
+		random:           DefaultRandomOptions(),
 		isInitialized:    false,
-		enableLogging:    false,
+		logger:           discardLogger,
+	}
+	for _, option := range options {
+		option(g)
 	}
+	return g
 }
 
 func (g *FrequencySweepGenerator) SetDeviceConfig(config malgo.DeviceConfig) {
@@ -95,7 +210,7 @@ func (g *FrequencySweepGenerator) Initialize() error {
 
 	var err error
 	g.context, err = malgo.InitContext(nil, malgo.ContextConfig{}, func(message string) {
-		g.Log(fmt.Sprintf("Malgo Log: %v", message))
+		g.logger.Debug("malgo", "message", message)
 	})
 	if err != nil {
 		return err
@@ -103,19 +218,25 @@ func (g *FrequencySweepGenerator) Initialize() error {
 
 	g.deviceConfig = malgo.DefaultDeviceConfig(malgo.Playback)
 	g.deviceConfig.Playback.Format = malgo.FormatF32
+	if err := g.initDevice(); err != nil {
+		g.context.Free()
+		return err
+	}
+	g.isInitialized = true
+	return nil
+}
+
+// initDevice opens a playback device in the generator's current format.
+func (g *FrequencySweepGenerator) initDevice() error {
 	g.deviceConfig.Playback.Channels = g.channels
 	g.deviceConfig.SampleRate = g.sampleRate
-
 	device, err := malgo.InitDevice(g.context.Context, g.deviceConfig, malgo.DeviceCallbacks{
 		Data: g.DataCallback,
 	})
 	if err != nil {
-		g.context.Free()
 		return err
 	}
-
 	g.device = &MalgoDeviceWrapper{Device: device}
-	g.isInitialized = true
 	return nil
 }
 
@@ -125,25 +246,26 @@ func (g *FrequencySweepGenerator) SetMockDevice(device AudioDevice) {
 	defer g.mutex.Unlock()
 	g.device = device
 	g.isInitialized = true
+	if mock, ok := device.(*MockDevice); ok && mock.logger == nil {
+		mock.logger = g.logger
+	}
 }
 
 func (g *FrequencySweepGenerator) Start() error {
 	g.mutex.Lock()
-	g.Log("Start: Acquired lock")
 
 	if !g.isInitialized {
-		g.Log("Initializing the generator...")
+		g.logger.Debug("initializing audio device")
 		if err := g.Initialize(); err != nil {
 			g.mutex.Unlock()
+			g.logger.Error("audio device initialization failed", "error", err)
 			return err
 		}
-
-		g.Log("Generator initialized successfully.")
 	}
 
 	if g.isPlaying {
 		g.mutex.Unlock()
-		g.Log("Generator is already playing.")
+		g.logger.Debug("generator is already playing")
 		return nil
 	}
 
@@ -155,63 +277,85 @@ func (g *FrequencySweepGenerator) Start() error {
 
 	// Set isPlaying to true before starting the device
 	g.isPlaying = true
+	g.paused = false
 	g.isFadingIn = true
 	g.isFadingOut = false
 	g.fadeStartTime = time.Now()
 	g.currentAmplitude = 0
 
+	// A finished one-shot sweep plays again from the start.
+	if g.sweepDone {
+		g.resetSweep()
+	}
+
+	// The envelope's attack replaces the fade-in.
+	if g.envelope != nil {
+		g.envelope.Trigger()
+		g.isFadingIn = false
+		g.currentAmplitude = g.targetAmplitude
+	}
+
 	// Set the callback for the mock device
 	if mockDevice, ok := g.device.(*MockDevice); ok {
-		g.Log("Setting mock device callback...")
 		mockDevice.SetCallback(g.DataCallback)
 	}
 
 	time.Sleep(100 * time.Millisecond)
-	g.Log("Starting the audio device...")
 	err := g.device.Start()
 	if err != nil {
 		g.isPlaying = false // Revert if the device failed to start
 		g.mutex.Unlock()
-		g.Log(fmt.Sprintf("Failed to start device: %v", err))
+		g.logger.Error("audio device failed to start", "error", err)
 		return err
 	}
-
-	g.Log("Generator started. Fade-in begins.")
 	g.mutex.Unlock()
 
 	// Optional: Add a small delay if the real device needs setup time
 	time.Sleep(50 * time.Millisecond)
 
 	g.isPlaying = true
-	g.isFadingIn = true
-	g.isFadingOut = false
-	g.fadeStartTime = time.Now()
-	g.currentAmplitude = 0
+	if g.envelope == nil {
+		g.isFadingIn = true
+		g.isFadingOut = false
+		g.fadeStartTime = time.Now()
+		g.currentAmplitude = 0
+	}
 
-	g.Log("Generator is now playing.")
+	g.logger.Info("playback started",
+		"sample_rate", g.sampleRate,
+		"channels", g.channels,
+		"min_freq", g.minFrequency,
+		"max_freq", g.maxFrequency,
+		"mode", g.sweepMode)
 	return nil
 }
 
 func (g *FrequencySweepGenerator) Stop() error {
-	g.Log("Stop: Attempting to stop generator...")
-
 	g.mutex.Lock()
-	g.Log("Stop: Acquired lock")
 
+	// Stopping a paused generator ends the pause; one that has faded
+	// out is already stopped.
+	g.paused = false
 	if !g.isPlaying {
-		g.Log("Generator is not playing.")
 		g.mutex.Unlock()
 		return nil
 	}
 
 	// Start the fade-out process
-	g.isFadingOut = true
-	g.fadeStartTime = time.Now()
 	fadeDuration := g.fadeOutDuration
+	if g.envelope != nil {
+		g.envelope.Release()
+		fadeDuration = g.envelope.adsr.Release
+	} else if !g.isFadingOut {
+		// A pause's fade-out carries on rather than restarting.
+		g.isFadingOut = true
+		g.fadeStartTime = time.Now()
+	}
 
 	g.mutex.Unlock()
 
 	// Wait for the fade-out duration (without holding the lock)
+	g.logger.Debug("fading out", "duration", fadeDuration)
 	time.Sleep(fadeDuration)
 
 	g.mutex.Lock()
@@ -221,6 +365,7 @@ func (g *FrequencySweepGenerator) Stop() error {
 	g.isPlaying = false
 	g.isFadingIn = false
 	g.isFadingOut = false
+	g.logger.Info("playback stopped", "frames", g.callbackFrames, "freq", g.currentFreq)
 	return g.device.Stop()
 }
 
@@ -230,10 +375,17 @@ func (g *FrequencySweepGenerator) SetAmplitude(amplitude float64) {
 	g.targetAmplitude = math.Max(0, math.Min(1, amplitude))
 }
 
+// SetSweepRate sets the rate in sweep-phase units per second, whose meaning
+// depends on the mode: one-way sweeps (linear, exponential, logarithmic,
+// octaves) reach the maximum in 1/rate seconds, triangle and sine take
+// 1/rate seconds per direction, sawtooth restarts every 1/rate seconds and
+// square and random ignore it. SetSweepDuration, SetHzPerSecond and
+// SetOctavesPerSecond express the same rate in physical terms.
 func (g *FrequencySweepGenerator) SetSweepRate(rate float64) {
 	g.mutex.Lock()
 	defer g.mutex.Unlock()
 	g.sweepRate = rate
+	g.timing = timingRate
 }
 
 func (g *FrequencySweepGenerator) SetSweepMode(mode SweepMode) {
@@ -241,10 +393,132 @@ func (g *FrequencySweepGenerator) SetSweepMode(mode SweepMode) {
 	defer g.mutex.Unlock()
 	g.sweepMode = mode
 	if mode == SweepModeRandom {
-		g.randomSeed = time.Now().UnixNano()
+		g.randomStarted = false
+	}
+}
+
+// SetCalibration pre-emphasises the output with the inverse of a speaker
+// profile, scaled so that the loudest point of the sweep range stays at
+// the set amplitude. A nil profile restores a flat output.
+func (g *FrequencySweepGenerator) SetCalibration(profile *calibration.Profile) {
+	g.mutex.Lock()
+	defer g.mutex.Unlock()
+	g.calibration = profile
+	g.scaleCalibration(g.minFrequency, g.maxFrequency)
+}
+
+// scaleCalibration scales the calibration for the loudest point between
+// minFreq and maxFreq.
+func (g *FrequencySweepGenerator) scaleCalibration(minFreq, maxFreq float64) {
+	if g.calibration != nil {
+		g.calibrationScale = 1 / g.calibration.MaxPreEmphasis(minFreq, maxFreq)
+	}
+}
+
+// AddFilter appends a biquad to the filter chain of one output channel, or
+// of every channel when channel is negative.
+func (g *FrequencySweepGenerator) AddFilter(channel int, filterType FilterType, freq, q, gainDB float64) error {
+	g.mutex.Lock()
+	defer g.mutex.Unlock()
+
+	if channel >= int(g.channels) {
+		return fmt.Errorf("channel %d out of range, generator has %d channels", channel, g.channels)
+	}
+	if g.filters == nil {
+		g.filters = make([]FilterChain, g.channels)
+	}
+	for ch := range g.filters {
+		if channel >= 0 && ch != channel {
+			continue
+		}
+		filter, err := NewBiquad(filterType, float64(g.sampleRate), freq, q, gainDB)
+		if err != nil {
+			return err
+		}
+		g.filters[ch] = append(g.filters[ch], filter)
+	}
+	return nil
+}
+
+// ClearFilters removes the filters of one channel, or of every channel
+// when channel is negative.
+func (g *FrequencySweepGenerator) ClearFilters(channel int) {
+	g.mutex.Lock()
+	defer g.mutex.Unlock()
+
+	for ch := range g.filters {
+		if channel < 0 || ch == channel {
+			g.filters[ch] = nil
+		}
+	}
+}
+
+// SetEnvelope shapes playback with an ADSR envelope instead of the linear
+// fades: Start triggers the attack and Stop waits for the release. A nil
+// envelope restores the fades.
+func (g *FrequencySweepGenerator) SetEnvelope(adsr *ADSR) {
+	g.mutex.Lock()
+	defer g.mutex.Unlock()
+	g.envelope = nil
+	if adsr != nil {
+		g.envelope = NewEnvelope(*adsr, g.sampleRate)
 	}
 }
 
+// ReleaseEnvelope starts the release of the envelope without stopping the
+// device, e.g. to end a Render.
+func (g *FrequencySweepGenerator) ReleaseEnvelope() {
+	g.mutex.Lock()
+	defer g.mutex.Unlock()
+	if g.envelope != nil {
+		g.envelope.Release()
+	}
+}
+
+// SetToneBurst gates the output into bursts of whole carrier cycles, or
+// plays continuously when burst is nil. Bursts start at a zero crossing.
+func (g *FrequencySweepGenerator) SetToneBurst(burst *ToneBurst) {
+	g.mutex.Lock()
+	defer g.mutex.Unlock()
+	g.burst = burst
+	g.burstCycles = 0
+	g.phase = 0
+}
+
+// SetAM modulates the amplitude between 1 and 1-Depth. It can be called
+// while playing; a nil modulator or zero depth turns AM off.
+func (g *FrequencySweepGenerator) SetAM(mod *Modulator) error {
+	if mod != nil && (mod.Depth < 0 || mod.Depth > 1) {
+		return fmt.Errorf("AM depth must be between 0 and 1, got %v", mod.Depth)
+	}
+	if mod != nil {
+		if err := mod.validate(); err != nil {
+			return err
+		}
+	}
+	g.mutex.Lock()
+	defer g.mutex.Unlock()
+	g.am = g.am.update(mod)
+	return nil
+}
+
+// SetFM deviates the carrier by up to Depth Hz around the swept frequency;
+// slow rates of a few Hz give vibrato. It can be called while playing.
+func (g *FrequencySweepGenerator) SetFM(mod *Modulator) error {
+	if mod != nil && mod.Depth < 0 {
+		return fmt.Errorf("FM deviation must not be negative, got %v", mod.Depth)
+	}
+	if mod != nil {
+		if err := mod.validate(); err != nil {
+			return err
+		}
+	}
+	g.mutex.Lock()
+	defer g.mutex.Unlock()
+	g.fm = g.fm.update(mod)
+	return nil
+}
+
 func (g *FrequencySweepGenerator) SetFadeDurations(fadeIn, fadeOut time.Duration) {
 	g.mutex.Lock()
 	defer g.mutex.Unlock()
@@ -253,78 +527,148 @@ func (g *FrequencySweepGenerator) SetFadeDurations(fadeIn, fadeOut time.Duration
 }
 
 func (g *FrequencySweepGenerator) DataCallback(pOutputSample, pInputSamples []byte, framecount uint32) {
-	g.Log("DataCallback: Acquired lock")
 	if !g.mutex.TryLock() {
-		g.Log("DataCallback: Failed to acquire lock")
+		// Counted rather than logged: the logger is guarded by the lock.
+		g.missedCallbacks.Add(1)
 		return
 	}
-
-	defer func() {
-		g.Log("DataCallback: Releasing lock")
-		g.mutex.Unlock()
-	}()
-
-	g.Log("DataCallback invoked.")
+	defer g.mutex.Unlock()
 
 	if !g.isPlaying {
-		g.Log("DataCallback invoked, but generator is not playing.")
 		return
 	}
 
-	g.Log("DataCallback is generating samples.")
-
 	samples := framecount * g.channels
 	if samples == 0 {
-		g.Log("DataCallback: No samples expected to be generated.")
 		return
 	}
 
 	output := make([]float32, samples)
+	g.generate(output)
+	g.logCallback(framecount)
+
+	// Convert float32 samples to bytes
+	for i, sample := range output {
+		binary.LittleEndian.PutUint32(pOutputSample[i*4:(i+1)*4], math.Float32bits(sample))
+	}
+}
 
-	for i := uint32(0); i < samples; i++ {
-		g.currentFreq = g.interpolateFrequency()
+// Render generates frames of interleaved output without an audio device,
+// advancing the sweep exactly as the data callback does. When the
+// generator is not playing, fades are skipped and the target amplitude is
+// used directly.
+func (g *FrequencySweepGenerator) Render(frames uint32) []float32 {
+	g.mutex.Lock()
+	defer g.mutex.Unlock()
+
+	if !g.isPlaying {
+		g.currentAmplitude = g.targetAmplitude
+		if g.envelope != nil && g.envelope.stage == stageIdle {
+			g.envelope.Trigger()
+		}
+	}
+
+	output := make([]float32, frames*g.channels)
+	g.generate(output)
+	g.logger.Debug("rendered", "frames", frames, "freq", g.currentFreq, "amplitude", g.currentAmplitude)
+	return output
+}
+
+// generate fills output with interleaved frames. Each frame is computed
+// once, advancing the carrier phase and the sweep by one sample period,
+// and copied to every channel before the per-channel filters.
+func (g *FrequencySweepGenerator) generate(output []float32) {
+	peak := 0.0
+	defer func() { g.outputPeak = peak }()
+	channels := int(g.channels)
+	for frame := 0; frame+channels <= len(output); frame += channels {
+		if g.frozen() {
+			clear(output[frame:])
+			return
+		}
+		if g.rangeGlide != nil {
+			g.advanceRange()
+		}
+		level, deviation := 1.0, 0.0
+		if g.envelope != nil {
+			level = g.envelope.Next()
+		}
+		if g.am != nil {
+			level *= 1 - g.am.Depth*(1-g.am.next(float64(g.sampleRate), g.rng))/2
+		}
+		if g.fm != nil {
+			deviation = g.fm.Depth * g.fm.next(float64(g.sampleRate), g.rng)
+		}
+
+		freq := g.interpolateFrequency()
+		g.currentFreq = math.Max(0, freq+deviation)
+		step := math.Max(0, g.stepFrequency(freq)+deviation)
 		g.updateAmplitude()
 
-		g.phase += 2.0 * math.Pi * g.currentFreq / float64(g.sampleRate)
+		g.phase += 2.0 * math.Pi * step / float64(g.sampleRate)
 		if g.phase > 2.0*math.Pi {
 			g.phase -= 2.0 * math.Pi
 		}
 
-		sample := float32(math.Sin(g.phase) * g.currentAmplitude)
-		output[i] = sample
-
-		if i%g.channels == 0 {
-			switch g.sweepMode {
-			case SweepModeLinear, SweepModeExponential, SweepModeLogarithmic:
-				g.sweepPhase += g.sweepRate / float64(g.sampleRate)
-				if g.sweepPhase > 1.0 {
-					g.sweepPhase = 1.0
-				}
-			case SweepModeTriangle, SweepModeSine:
-				g.sweepPhase += float64(g.sweepDirection) * g.sweepRate / float64(g.sampleRate)
-				if g.sweepPhase > 1.0 {
-					g.sweepPhase = 1.0
-					g.sweepDirection = -1
-				} else if g.sweepPhase < 0.0 {
-					g.sweepPhase = 0.0
-					g.sweepDirection = 1
-				}
-			case SweepModeSawtooth:
-				g.sweepPhase += g.sweepRate / float64(g.sampleRate)
-				if g.sweepPhase > 1.0 {
-					g.sweepPhase = 0.0
-				}
-			case SweepModeRandom:
-				g.sweepPhase = rand.Float64()
+		sample := g.carrier(step) * g.currentAmplitude * level
+		if g.silenced() {
+			sample = 0
+		}
+		if g.burst != nil {
+			g.burstCycles += step / float64(g.sampleRate)
+			if period := float64(g.burst.On + g.burst.Off); g.burstCycles >= period {
+				g.burstCycles -= period
 			}
+			sample *= g.burst.gain(g.burstCycles)
+		}
+		if g.calibration != nil {
+			sample *= g.calibration.PreEmphasis(g.currentFreq) * g.calibrationScale
+		}
+		for c := 0; c < channels; c++ {
+			out := sample
+			if g.filters != nil {
+				out = g.filters[c].Process(sample)
+			}
+			output[frame+c] = float32(out)
+			peak = math.Max(peak, math.Abs(out))
 		}
-	}
 
-	g.Log("DataCallback generated samples.")
+		g.advanceMode()
+	}
+}
 
-	// Convert float32 samples to bytes
-	for i, sample := range output {
-		binary.LittleEndian.PutUint32(pOutputSample[i*4:(i+1)*4], math.Float32bits(sample))
+// advanceMode moves the sweep of the current mode on by one frame.
+func (g *FrequencySweepGenerator) advanceMode() {
+	switch g.sweepMode {
+	case SweepModeLinear, SweepModeExponential, SweepModeLogarithmic, SweepModeOctaves:
+		if g.options != nil {
+			g.advanceSweep()
+			break
+		}
+		g.sweepPhase += g.sweepRate / float64(g.sampleRate)
+		if g.sweepPhase > 1.0 {
+			g.sweepPhase = 1.0
+		}
+	case SweepModeTriangle, SweepModeSine:
+		g.sweepPhase += float64(g.sweepDirection) * g.sweepRate / float64(g.sampleRate)
+		if g.sweepPhase > 1.0 {
+			g.sweepPhase = 1.0
+			g.sweepDirection = -1
+		} else if g.sweepPhase < 0.0 {
+			g.sweepPhase = 0.0
+			g.sweepDirection = 1
+		}
+	case SweepModeSawtooth:
+		g.sweepPhase += g.sweepRate / float64(g.sampleRate)
+		if g.sweepPhase > 1.0 {
+			g.sweepPhase = 0.0
+		}
+	case SweepModeRandom:
+		g.advanceRandom()
+	case SweepModeNotes:
+		g.advanceNotes()
+	case SweepModeCustom:
+		g.advanceCurve()
 	}
 }
 
@@ -354,11 +698,17 @@ func (g *FrequencySweepGenerator) interpolateFrequency() float64 {
 			break
 		}
 		t = 1
+	case SweepModeOctaves:
+		if g.minFrequency > 0 {
+			return g.minFrequency * math.Pow(g.maxFrequency/g.minFrequency, g.sweepPhase)
+		}
+		t = g.sweepPhase
 	case SweepModeRandom:
-		g.randomSeed = (g.randomSeed*1103515245 + 12345) & 0x7fffffff
-		//randGen := rand.New(rand.NewSource(g.randomSeed))
-		//g.randomSeed = randGen.Int63()
-		t = float64(g.randomSeed) / float64(0x7fffffff)
+		return g.randomFrequency()
+	case SweepModeNotes:
+		return g.noteFrequency()
+	case SweepModeCustom:
+		return g.curveFrequency()
 	}
 
 	return g.minFrequency + t*freqRange
@@ -370,11 +720,10 @@ func (g *FrequencySweepGenerator) updateAmplitude() {
 		if elapsed >= g.fadeInDuration {
 			g.currentAmplitude = g.targetAmplitude
 			g.isFadingIn = false
-			g.Log(fmt.Sprintf("Fade-in complete. Amplitude set to target: %v", g.currentAmplitude))
+			g.logEvent(slog.LevelDebug, "fade-in complete", "amplitude", g.currentAmplitude)
 			return
 		}
 		g.currentAmplitude = g.targetAmplitude * float64(elapsed) / float64(g.fadeInDuration)
-		g.Log(fmt.Sprintf("Fading in. Current amplitude: %v", g.currentAmplitude))
 		return
 	}
 
@@ -383,11 +732,10 @@ func (g *FrequencySweepGenerator) updateAmplitude() {
 		if elapsed >= g.fadeOutDuration {
 			g.currentAmplitude = 0
 			g.isFadingOut = false
-			g.Log("Fade-out complete. Amplitude set to 0")
+			g.logEvent(slog.LevelDebug, "fade-out complete")
 			return
 		}
 		g.currentAmplitude = g.targetAmplitude * (1 - float64(elapsed)/float64(g.fadeOutDuration))
-		g.Log(fmt.Sprintf("Fading out. Current amplitude: %v", g.currentAmplitude))
 		return
 	}
 
@@ -428,11 +776,6 @@ func (g *FrequencySweepGenerator) Close() error {
 	}
 
 	g.isInitialized = false
+	g.closeLog()
 	return nil
 }
-
-func (g *FrequencySweepGenerator) Log(message string) {
-	if g.enableLogging {
-		fmt.Println(message)
-	}
-}
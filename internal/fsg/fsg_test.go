@@ -6,6 +6,7 @@ import (
 	"time"
 
 	"github.com/gen2brain/malgo"
+	"github.com/hailam/malgoplay/internal/calibration"
 )
 
 func TestSetMockDevice(t *testing.T) {
@@ -193,6 +194,18 @@ func TestSetSweepMode(t *testing.T) {
 	}
 }
 
+func TestSweepModeNames(t *testing.T) {
+	names := SweepModeNames()
+	if len(names) != len(sweepModeNames) {
+		t.Fatalf("Incorrect number of names: got %v, want %d", names, len(sweepModeNames))
+	}
+	for i, name := range names {
+		if mode, err := ParseSweepMode(name); err != nil || mode != SweepMode(i) {
+			t.Errorf("Name %q parses to %v, %v, want %v", name, mode, err, SweepMode(i))
+		}
+	}
+}
+
 func TestSetFadeDurations(t *testing.T) {
 	gen := NewFrequencySweepGenerator(220, 880, 44100, 2)
 	gen.SetFadeDurations(1*time.Second, 2*time.Second)
@@ -201,6 +214,38 @@ func TestSetFadeDurations(t *testing.T) {
 	}
 }
 
+func TestSetCalibration(t *testing.T) {
+	// The speaker is 6 dB quiet at 100 Hz and flat at 1 kHz.
+	profile := &calibration.Profile{Points: []calibration.Point{
+		{Frequency: 100, GainDB: -6},
+		{Frequency: 1000, GainDB: 0},
+	}}
+	gen := NewFrequencySweepGenerator(100, 1000, 44100, 1)
+	gen.SetSweepRate(0)
+	gen.SetCalibration(profile)
+
+	peak := func(samples []float32) float64 {
+		p := 0.0
+		for _, s := range samples {
+			p = math.Max(p, math.Abs(float64(s)))
+		}
+		return p
+	}
+
+	if p := peak(gen.Render(4410)); math.Abs(p-1) > 1e-3 {
+		t.Errorf("Incorrect peak at 100 Hz: got %v, want 1", p)
+	}
+	gen.sweepPhase = 1
+	if p := peak(gen.Render(4410)); math.Abs(p-math.Pow(10, -6.0/20)) > 1e-3 {
+		t.Errorf("Incorrect peak at 1 kHz: got %v, want %v", p, math.Pow(10, -6.0/20))
+	}
+
+	gen.SetCalibration(nil)
+	if p := peak(gen.Render(4410)); math.Abs(p-1) > 1e-3 {
+		t.Errorf("Incorrect peak without calibration: got %v, want 1", p)
+	}
+}
+
 func TestStartStop(t *testing.T) {
 	gen := NewFrequencySweepGenerator(220, 880, 44100, 2)
 	mockDevice := NewMockDevice(44100, 2)
@@ -288,11 +333,17 @@ func TestFrequencySweep(t *testing.T) {
 	framesToGenerate := uint32(float64(sampleRate) * sampleDuration.Seconds())
 	mockDevice.GenerateSamples(framesToGenerate)
 
-	samples := mockDevice.GetCapturedSamples()
-	if len(samples) == 0 {
+	interleaved := mockDevice.GetCapturedSamples()
+	if len(interleaved) == 0 {
 		t.Fatal("No samples were generated")
 	}
 
+	// Analyze the first channel
+	samples := make([]float32, len(interleaved)/int(channels))
+	for i := range samples {
+		samples[i] = interleaved[i*int(channels)]
+	}
+
 	// Analyze frequency at multiple points
 	analysisDuration := 0.1 // 100ms for each analysis window
 	analysisFrames := int(float64(sampleRate) * analysisDuration)
@@ -313,9 +364,12 @@ func TestFrequencySweep(t *testing.T) {
 
 	t.Logf("First frequency: %v Hz, Last frequency: %v Hz", firstFreq, lastFreq)
 
+	// The first window sweeps on from the minimum, so it measures the
+	// frequency at its middle.
+	wantFirst := minFreq + (maxFreq-minFreq)*sweepRate*analysisDuration/2
 	tolerance := 20.0 // Allow 20 Hz tolerance
-	if math.Abs(firstFreq-minFreq) > tolerance {
-		t.Errorf("Initial frequency out of expected range: got %v, want close to %v", firstFreq, minFreq)
+	if math.Abs(firstFreq-wantFirst) > tolerance {
+		t.Errorf("Initial frequency out of expected range: got %v, want close to %v", firstFreq, wantFirst)
 	}
 
 	if math.Abs(lastFreq-maxFreq) > tolerance {
@@ -0,0 +1,73 @@
+package fsg
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Harmonic is one partial of an additive carrier. Number 1 is the
+// fundamental, Amplitude is relative to the generator amplitude and Phase
+// is in radians.
+type Harmonic struct {
+	Number    int
+	Amplitude float64
+	Phase     float64
+}
+
+// Harmonics is an additive carrier with exactly known harmonic content.
+// Unlike a Wavetable it is not normalised, so a profile of "1:1,2:0.01"
+// carries second-harmonic distortion of exactly 1%. Harmonics at or above
+// Nyquist are left out as the sweep rises.
+type Harmonics []Harmonic
+
+// ParseHarmonics parses "number:amplitude[@phase]" entries separated by
+// commas, with the phase in degrees, e.g. "1:1.0,2:0.01,3:0.003@90".
+func ParseHarmonics(spec string) (Harmonics, error) {
+	var harmonics Harmonics
+	seen := make(map[int]bool)
+	for _, field := range strings.Split(spec, ",") {
+		field = strings.TrimSpace(field)
+		number, rest, ok := strings.Cut(field, ":")
+		if !ok {
+			return nil, fmt.Errorf("harmonic must be number:amplitude[@phase], got %q", field)
+		}
+		amplitude, phase, hasPhase := strings.Cut(rest, "@")
+
+		var h Harmonic
+		var err error
+		if h.Number, err = strconv.Atoi(strings.TrimSpace(number)); err != nil || h.Number < 1 {
+			return nil, fmt.Errorf("harmonic number must be a positive integer, got %q", number)
+		}
+		if seen[h.Number] {
+			return nil, fmt.Errorf("harmonic %d given twice", h.Number)
+		}
+		seen[h.Number] = true
+		if h.Amplitude, err = strconv.ParseFloat(strings.TrimSpace(amplitude), 64); err != nil || h.Amplitude < 0 {
+			return nil, fmt.Errorf("harmonic amplitude must be a non-negative number, got %q", amplitude)
+		}
+		if hasPhase {
+			degrees, err := strconv.ParseFloat(strings.TrimSpace(phase), 64)
+			if err != nil {
+				return nil, fmt.Errorf("invalid harmonic phase %q", phase)
+			}
+			h.Phase = degrees * math.Pi / 180
+		}
+		harmonics = append(harmonics, h)
+	}
+	sort.Slice(harmonics, func(i, j int) bool { return harmonics[i].Number < harmonics[j].Number })
+	return harmonics, nil
+}
+
+func (h Harmonics) Sample(phase, increment float64) float64 {
+	v := 0.0
+	for _, harmonic := range h {
+		if increment > 0 && float64(harmonic.Number)*increment >= 0.5 {
+			break
+		}
+		v += harmonic.Amplitude * math.Sin(2*math.Pi*float64(harmonic.Number)*phase+harmonic.Phase)
+	}
+	return v
+}
@@ -0,0 +1,73 @@
+package fsg
+
+import (
+	"math"
+	"testing"
+)
+
+func TestParseHarmonics(t *testing.T) {
+	h, err := ParseHarmonics("3:0.003@90, 1:1.0, 2:0.01")
+	if err != nil {
+		t.Fatalf("ParseHarmonics failed: %v", err)
+	}
+	want := Harmonics{{1, 1, 0}, {2, 0.01, 0}, {3, 0.003, math.Pi / 2}}
+	if len(h) != len(want) {
+		t.Fatalf("Incorrect harmonics: got %v, want %v", h, want)
+	}
+	for i := range want {
+		if h[i].Number != want[i].Number || h[i].Amplitude != want[i].Amplitude || math.Abs(h[i].Phase-want[i].Phase) > 1e-12 {
+			t.Errorf("Incorrect harmonic %d: got %v, want %v", i, h[i], want[i])
+		}
+	}
+
+	for _, spec := range []string{"", "1", "0:1", "x:1", "1:-1", "1:1,1:0.5", "2:0.1@x"} {
+		if _, err := ParseHarmonics(spec); err == nil {
+			t.Errorf("Expected an error for %q", spec)
+		}
+	}
+}
+
+func TestHarmonicsDropAboveNyquist(t *testing.T) {
+	h, _ := ParseHarmonics("1:1,3:0.5")
+	phase := 0.1
+	full := math.Sin(2*math.Pi*phase) + 0.5*math.Sin(6*math.Pi*phase)
+	if got := h.Sample(phase, 0.1); math.Abs(got-full) > 1e-12 {
+		t.Errorf("Below Nyquist: got %v, want %v", got, full)
+	}
+	// The third harmonic of 0.2 cycles per sample is at 0.6, above Nyquist.
+	if got, want := h.Sample(phase, 0.2), math.Sin(2*math.Pi*phase); math.Abs(got-want) > 1e-12 {
+		t.Errorf("Above Nyquist: got %v, want %v", got, want)
+	}
+}
+
+func TestGeneratorHarmonicContent(t *testing.T) {
+	h, _ := ParseHarmonics("1:1.0,2:0.01,3:0.003")
+	for _, channels := range []uint32{1, 2} {
+		gen := NewFrequencySweepGenerator(1000, 1000, 48000, channels)
+		gen.SetWaveform(h)
+		output := gen.Render(48000)
+
+		// The DFT of every channel at the harmonic frequencies.
+		level := func(channel int, freq float64) float64 {
+			var re, im float64
+			for n := 0; n < 48000; n++ {
+				s := float64(output[n*int(channels)+channel])
+				angle := 2 * math.Pi * freq * float64(n) / 48000
+				re += s * math.Cos(angle)
+				im += s * math.Sin(angle)
+			}
+			return math.Hypot(re, im)
+		}
+		for channel := 0; channel < int(channels); channel++ {
+			fundamental := level(channel, 1000)
+			if want := 24000.0; math.Abs(fundamental-want) > 1 {
+				t.Errorf("%d channels, channel %d: incorrect level at 1000 Hz: got %v, want %v", channels, channel, fundamental, want)
+			}
+			for harmonic, want := range map[float64]float64{2000: 0.01, 3000: 0.003} {
+				if got := level(channel, harmonic) / fundamental; math.Abs(got-want) > 1e-4 {
+					t.Errorf("%d channels, channel %d: incorrect level at %v Hz: got %v, want %v", channels, channel, harmonic, got, want)
+				}
+			}
+		}
+	}
+}
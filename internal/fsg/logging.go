@@ -0,0 +1,94 @@
+package fsg
+
+import (
+	"context"
+	"log/slog"
+	"time"
+)
+
+// Option configures a FrequencySweepGenerator at construction.
+type Option func(*FrequencySweepGenerator)
+
+// WithLogger sends the generator's log messages to logger. Without it
+// nothing is logged.
+func WithLogger(logger *slog.Logger) Option {
+	return func(g *FrequencySweepGenerator) {
+		if logger != nil {
+			g.logger = logger
+		}
+	}
+}
+
+// discardHandler drops every record; log/slog only gained one in Go 1.24.
+type discardHandler struct{}
+
+func (discardHandler) Enabled(context.Context, slog.Level) bool  { return false }
+func (discardHandler) Handle(context.Context, slog.Record) error { return nil }
+func (h discardHandler) WithAttrs([]slog.Attr) slog.Handler      { return h }
+func (h discardHandler) WithGroup(string) slog.Handler           { return h }
+
+var discardLogger = slog.New(discardHandler{})
+
+const (
+	// logQueueSize bounds the records waiting to leave the audio callback;
+	// further records are dropped and counted.
+	logQueueSize = 64
+	// callbackLogInterval rate-limits the callback's periodic status record.
+	callbackLogInterval = time.Second
+)
+
+// logEvent logs from the audio callback without blocking it: the record
+// is queued for a goroutine that owns the slow handler. The mutex must be
+// held.
+func (g *FrequencySweepGenerator) logEvent(level slog.Level, msg string, args ...any) {
+	if !g.logger.Enabled(context.Background(), level) {
+		return
+	}
+	if g.logQueue == nil {
+		g.logQueue = make(chan slog.Record, logQueueSize)
+		go drainLog(g.logger, g.logQueue)
+	}
+
+	record := slog.NewRecord(time.Now(), level, msg, 0)
+	record.Add(args...)
+	if g.logDropped > 0 {
+		record.Add("dropped", g.logDropped)
+	}
+	select {
+	case g.logQueue <- record:
+		g.logDropped = 0
+	default:
+		g.logDropped++
+	}
+}
+
+func drainLog(logger *slog.Logger, queue <-chan slog.Record) {
+	for record := range queue {
+		_ = logger.Handler().Handle(context.Background(), record)
+	}
+}
+
+// logCallback reports the callback status at most once per
+// callbackLogInterval. The mutex must be held.
+func (g *FrequencySweepGenerator) logCallback(frames uint32) {
+	g.callbackFrames += uint64(frames)
+	now := time.Now()
+	if now.Sub(g.lastCallbackLog) < callbackLogInterval {
+		return
+	}
+	g.lastCallbackLog = now
+	g.logEvent(slog.LevelDebug, "audio callback",
+		"frames", g.callbackFrames,
+		"freq", g.currentFreq,
+		"amplitude", g.currentAmplitude,
+		"missed", g.missedCallbacks.Load())
+}
+
+// closeLog stops the log goroutine once the queued records are written.
+// The mutex must be held.
+func (g *FrequencySweepGenerator) closeLog() {
+	if g.logQueue != nil {
+		close(g.logQueue)
+		g.logQueue = nil
+	}
+}
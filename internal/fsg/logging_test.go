@@ -0,0 +1,149 @@
+package fsg
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"testing"
+	"time"
+)
+
+// recordingHandler keeps every record it handles, optionally waiting on
+// gate first to simulate a slow sink.
+type recordingHandler struct {
+	mutex   sync.Mutex
+	records []slog.Record
+	gate    chan struct{}
+}
+
+func (h *recordingHandler) Enabled(context.Context, slog.Level) bool { return true }
+func (h *recordingHandler) WithAttrs([]slog.Attr) slog.Handler       { return h }
+func (h *recordingHandler) WithGroup(string) slog.Handler            { return h }
+
+func (h *recordingHandler) Handle(_ context.Context, r slog.Record) error {
+	if h.gate != nil {
+		<-h.gate
+	}
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+	h.records = append(h.records, r)
+	return nil
+}
+
+func (h *recordingHandler) find(msg string) (slog.Record, bool) {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+	for _, r := range h.records {
+		if r.Message == msg {
+			return r, true
+		}
+	}
+	return slog.Record{}, false
+}
+
+func recordAttrs(r slog.Record) map[string]slog.Value {
+	attrs := make(map[string]slog.Value)
+	r.Attrs(func(a slog.Attr) bool {
+		attrs[a.Key] = a.Value
+		return true
+	})
+	return attrs
+}
+
+func TestWithLogger(t *testing.T) {
+	handler := &recordingHandler{}
+	gen := NewFrequencySweepGenerator(220, 880, 1000, 1, WithLogger(slog.New(handler)))
+	device := NewMockDevice(1000, 1)
+	gen.SetMockDevice(device)
+	gen.SetFadeDurations(0, 0)
+
+	if err := gen.Start(); err != nil {
+		t.Fatalf("Start failed: %v", err)
+	}
+	device.GenerateSamples(100)
+	gen.Stop()
+	gen.Close()
+
+	started, ok := handler.find("playback started")
+	if !ok {
+		t.Fatal("Missing playback started record")
+	}
+	if attrs := recordAttrs(started); attrs["sample_rate"].Uint64() != 1000 || attrs["mode"].String() != "linear" {
+		t.Errorf("Incorrect playback started fields: %v", attrs)
+	}
+	if _, ok := handler.find("mock device generated samples"); !ok {
+		t.Error("Mock device should log to the generator's logger")
+	}
+
+	// The callback record is written by the log goroutine.
+	deadline := time.Now().Add(time.Second)
+	for {
+		if r, ok := handler.find("audio callback"); ok {
+			if attrs := recordAttrs(r); attrs["frames"].Uint64() != 100 {
+				t.Errorf("Incorrect callback frames: got %v, want 100", attrs["frames"])
+			}
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("Missing audio callback record")
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestLogEventDoesNotBlock(t *testing.T) {
+	handler := &recordingHandler{gate: make(chan struct{})}
+	gen := NewFrequencySweepGenerator(220, 880, 1000, 1, WithLogger(slog.New(handler)))
+
+	gen.mutex.Lock()
+	finished := make(chan struct{})
+	go func() {
+		for i := 0; i < 2*logQueueSize; i++ {
+			gen.logEvent(slog.LevelInfo, "event", "i", i)
+		}
+		close(finished)
+	}()
+	select {
+	case <-finished:
+	case <-time.After(time.Second):
+		t.Fatal("logEvent blocked on a slow handler")
+	}
+	if gen.logDropped == 0 {
+		t.Error("Records beyond the queue should be dropped and counted")
+	}
+	close(handler.gate)
+
+	// Once the queue drains, the next record reports the drops.
+	dropped := gen.logDropped
+	for len(gen.logQueue) > 0 {
+		gen.mutex.Unlock()
+		time.Sleep(time.Millisecond)
+		gen.mutex.Lock()
+	}
+	gen.logEvent(slog.LevelInfo, "after")
+	gen.closeLog()
+	gen.mutex.Unlock()
+
+	deadline := time.Now().Add(time.Second)
+	for {
+		if r, ok := handler.find("after"); ok {
+			if got := recordAttrs(r)["dropped"].Int64(); got != int64(dropped) {
+				t.Errorf("Incorrect dropped count: got %d, want %d", got, dropped)
+			}
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("Missing record after the queue drained")
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestNoLoggerNoQueue(t *testing.T) {
+	gen := NewFrequencySweepGenerator(220, 880, 1000, 1)
+	gen.SetFadeDurations(time.Millisecond, 0)
+	gen.Render(100)
+	if gen.logQueue != nil {
+		t.Error("A generator without a logger should not start a log goroutine")
+	}
+}
@@ -0,0 +1,209 @@
+package fsg
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math"
+	"math/bits"
+	"sync"
+)
+
+const (
+	MinMLSOrder = 2
+	MaxMLSOrder = 20
+)
+
+// Feedback taps of primitive polynomials, one set per MLS order.
+var mlsTaps = map[int][]uint{
+	2:  {2, 1},
+	3:  {3, 2},
+	4:  {4, 3},
+	5:  {5, 3},
+	6:  {6, 5},
+	7:  {7, 6},
+	8:  {8, 6, 5, 4},
+	9:  {9, 5},
+	10: {10, 7},
+	11: {11, 9},
+	12: {12, 6, 4, 1},
+	13: {13, 4, 3, 1},
+	14: {14, 5, 3, 1},
+	15: {15, 14},
+	16: {16, 15, 13, 4},
+	17: {17, 14},
+	18: {18, 11},
+	19: {19, 6, 2, 1},
+	20: {20, 17},
+}
+
+// lfsr is a Fibonacci linear feedback shift register whose output bit is
+// the lowest bit of its state.
+type lfsr struct {
+	order uint
+	mask  uint32
+	state uint32
+}
+
+func newLFSR(order int, state uint32) (*lfsr, error) {
+	taps, ok := mlsTaps[order]
+	if !ok {
+		return nil, fmt.Errorf("MLS order must be between %d and %d, got %d", MinMLSOrder, MaxMLSOrder, order)
+	}
+	var mask uint32
+	for _, tap := range taps {
+		mask |= 1 << (uint(order) - tap)
+	}
+	return &lfsr{order: uint(order), mask: mask, state: state}, nil
+}
+
+func (l *lfsr) next() uint32 {
+	out := l.state & 1
+	feedback := uint32(bits.OnesCount32(l.state&l.mask) & 1)
+	l.state = l.state>>1 | feedback<<(l.order-1)
+	return out
+}
+
+// GenerateMLS returns one period (2^order - 1 samples) of a maximum-length
+// sequence mapped to +1/-1.
+func GenerateMLS(order int) ([]float64, error) {
+	reg, err := newLFSR(order, 1)
+	if err != nil {
+		return nil, err
+	}
+	sequence := make([]float64, 1<<order-1)
+	for i := range sequence {
+		sequence[i] = 1 - 2*float64(reg.next())
+	}
+	return sequence, nil
+}
+
+// MLSSource plays a maximum-length sequence periodically on every channel.
+type MLSSource struct {
+	sequence  []float64
+	channels  uint32
+	amplitude float64
+	position  int
+	mutex     sync.Mutex
+}
+
+func NewMLSSource(order int, channels uint32) (*MLSSource, error) {
+	sequence, err := GenerateMLS(order)
+	if err != nil {
+		return nil, err
+	}
+	return &MLSSource{
+		sequence:  sequence,
+		channels:  channels,
+		amplitude: 1,
+	}, nil
+}
+
+func (s *MLSSource) SetAmplitude(amplitude float64) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.amplitude = math.Max(0, math.Min(1, amplitude))
+}
+
+// Len returns the period of the sequence in frames.
+func (s *MLSSource) Len() int {
+	return len(s.sequence)
+}
+
+func (s *MLSSource) DataCallback(pOutputSample, pInputSamples []byte, framecount uint32) {
+	output := s.Render(framecount)
+	for i, sample := range output {
+		binary.LittleEndian.PutUint32(pOutputSample[i*4:(i+1)*4], math.Float32bits(sample))
+	}
+}
+
+func (s *MLSSource) Render(frames uint32) []float32 {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	output := make([]float32, frames*s.channels)
+	for frame := uint32(0); frame < frames; frame++ {
+		sample := float32(s.sequence[s.position] * s.amplitude)
+		for c := uint32(0); c < s.channels; c++ {
+			output[frame*s.channels+c] = sample
+		}
+		s.position = (s.position + 1) % len(s.sequence)
+	}
+	return output
+}
+
+// MLSImpulseResponse recovers the impulse response of a system excited by
+// the periodic MLS of the given order, starting at sample 0 of captured.
+// The circular cross-correlation is computed with a fast Hadamard transform.
+// If captured holds two or more periods the first is treated as settling
+// time and the remaining whole periods are averaged. The response is scaled
+// for a unit-amplitude sequence and has 2^order - 1 samples.
+func MLSImpulseResponse(order int, captured []float64) ([]float64, error) {
+	length := 1<<order - 1
+	if order < MinMLSOrder || order > MaxMLSOrder {
+		return nil, fmt.Errorf("MLS order must be between %d and %d, got %d", MinMLSOrder, MaxMLSOrder, order)
+	}
+	if len(captured) < length {
+		return nil, fmt.Errorf("need at least %d captured samples, got %d", length, len(captured))
+	}
+
+	period := make([]float64, length)
+	periods := len(captured) / length
+	first := 0
+	if periods >= 2 {
+		first = 1
+	}
+	for p := first; p < periods; p++ {
+		for i := range period {
+			period[i] += captured[p*length+i]
+		}
+	}
+	for i := range period {
+		period[i] /= float64(periods - first)
+	}
+
+	// states[k] is the register state that emits sample k. The sample j
+	// steps later is the parity of states[k] & taps[j].
+	states := make([]uint32, length)
+	reg, _ := newLFSR(order, 1)
+	for k := range states {
+		states[k] = reg.state
+		reg.next()
+	}
+	taps := make([]uint32, length)
+	for b := 0; b < order; b++ {
+		reg, _ := newLFSR(order, 1<<b)
+		for j := range taps {
+			taps[j] |= reg.next() << b
+		}
+	}
+
+	transform := make([]float64, length+1)
+	for k, state := range states {
+		transform[state] = period[k]
+	}
+	fastHadamardTransform(transform)
+
+	response := make([]float64, length)
+	sum := 0.0
+	for m := range response {
+		response[m] = transform[taps[(length-m)%length]]
+		sum += response[m]
+	}
+	for m := range response {
+		response[m] = (response[m] + sum) / float64(length+1)
+	}
+	return response, nil
+}
+
+// fastHadamardTransform computes the unnormalised Walsh-Hadamard transform
+// of data in place; len(data) must be a power of two.
+func fastHadamardTransform(data []float64) {
+	for h := 1; h < len(data); h <<= 1 {
+		for i := 0; i < len(data); i += h << 1 {
+			for j := i; j < i+h; j++ {
+				a, b := data[j], data[j+h]
+				data[j], data[j+h] = a+b, a-b
+			}
+		}
+	}
+}
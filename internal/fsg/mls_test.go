@@ -0,0 +1,94 @@
+package fsg
+
+import (
+	"math"
+	"testing"
+)
+
+func TestGenerateMLSIsMaximalLength(t *testing.T) {
+	for order := MinMLSOrder; order <= 16; order++ {
+		sequence, err := GenerateMLS(order)
+		if err != nil {
+			t.Fatalf("Order %d: %v", order, err)
+		}
+		if len(sequence) != 1<<order-1 {
+			t.Fatalf("Order %d: got length %d, want %d", order, len(sequence), 1<<order-1)
+		}
+
+		// An m-sequence has one more -1 than +1 and a two-valued
+		// circular autocorrelation: L at lag 0 and -1 elsewhere.
+		sum := 0.0
+		for _, v := range sequence {
+			sum += v
+		}
+		if sum != -1 {
+			t.Errorf("Order %d: sequence sum is %v, want -1", order, sum)
+		}
+		for _, lag := range []int{1, 2, len(sequence) / 3, len(sequence) - 1} {
+			corr := 0.0
+			for i := range sequence {
+				corr += sequence[i] * sequence[(i+lag)%len(sequence)]
+			}
+			if corr != -1 {
+				t.Errorf("Order %d: autocorrelation at lag %d is %v, want -1", order, lag, corr)
+			}
+		}
+	}
+}
+
+func TestGenerateMLSInvalidOrder(t *testing.T) {
+	if _, err := GenerateMLS(1); err == nil {
+		t.Error("Expected an error for order 1")
+	}
+	if _, err := GenerateMLS(MaxMLSOrder + 1); err == nil {
+		t.Error("Expected an error for an order above the maximum")
+	}
+}
+
+func TestMLSImpulseResponse(t *testing.T) {
+	const order = 12
+	source, err := NewMLSSource(order, 1)
+	if err != nil {
+		t.Fatalf("Failed to create MLS source: %v", err)
+	}
+	source.SetAmplitude(0.5)
+
+	impulse := map[int]float64{0: 0, 3: 1.0, 10: -0.5, 200: 0.25}
+	length := source.Len()
+
+	// Two periods through the system: the first settles, the second is analysed.
+	excitation := source.Render(uint32(2 * length))
+	captured := make([]float64, len(excitation))
+	for n := range captured {
+		for delay, gain := range impulse {
+			if n-delay >= 0 {
+				captured[n] += gain * float64(excitation[n-delay]) / 0.5
+			}
+		}
+	}
+
+	response, err := MLSImpulseResponse(order, captured)
+	if err != nil {
+		t.Fatalf("MLSImpulseResponse failed: %v", err)
+	}
+	if len(response) != length {
+		t.Fatalf("Unexpected response length: got %d, want %d", len(response), length)
+	}
+	for i, v := range response {
+		if math.Abs(v-impulse[i]) > 1e-6 {
+			t.Errorf("Response[%d] = %v, want %v", i, v, impulse[i])
+		}
+	}
+}
+
+func TestGeneratorRender(t *testing.T) {
+	gen := NewFrequencySweepGenerator(440, 440, 44100, 1)
+	samples := gen.Render(44100)
+	if len(samples) != 44100 {
+		t.Fatalf("Unexpected number of samples: got %d, want 44100", len(samples))
+	}
+	freq := estimateFrequency(samples, 44100)
+	if math.Abs(freq-440) > 2 {
+		t.Errorf("Rendered frequency: got %v, want 440", freq)
+	}
+}
@@ -2,6 +2,7 @@ package fsg
 
 import (
 	"encoding/binary"
+	"log/slog"
 	"math"
 	"sync"
 
@@ -15,8 +16,11 @@ type MockDevice struct {
 	sampleRate      uint32
 	channels        uint32
 	capturedSamples []float32
+	logger          *slog.Logger
 }
 
+// NewMockDevice returns a device that only produces samples when asked to
+// by GenerateSamples. It logs to the logger of the generator it is given to.
 func NewMockDevice(sampleRate, channels uint32) *MockDevice {
 	return &MockDevice{
 		sampleRate: sampleRate,
@@ -36,7 +40,7 @@ func (m *MockDevice) Start() error {
 	}
 
 	m.isStarted = true
-	println("Mock device started.")
+	m.log().Debug("mock device started")
 	return nil
 }
 
@@ -44,12 +48,7 @@ func (m *MockDevice) SetCallback(dataCallback malgo.DataProc) {
 	m.mutex.Lock()
 	defer m.mutex.Unlock()
 	m.dataCallback = dataCallback
-	println("MockDevice: Callback set.")
-
-	if m.isStarted {
-		// If the device is already started, ensure callback is set
-		println("Callback set for running mock device.")
-	}
+	m.log().Debug("mock device callback set", "started", m.isStarted)
 }
 
 func (m *MockDevice) Stop() error {
@@ -68,26 +67,20 @@ func (m *MockDevice) GenerateSamples(frameCount uint32) {
 	defer m.mutex.Unlock()
 
 	if m.dataCallback == nil || !m.isStarted {
-		println("MockDevice: No callback or device not started.")
+		m.log().Warn("mock device has no callback or is not started")
 		return
 	}
 
 	byteCount := frameCount * m.channels * 4 // 4 bytes per float32
 	output := make([]byte, byteCount)
 
-	println("MockDevice generating samples.")
 	m.dataCallback(output, nil, frameCount)
 
 	m.capturedSamples = make([]float32, frameCount*m.channels)
 	for i := uint32(0); i < frameCount*m.channels; i++ {
 		m.capturedSamples[i] = math.Float32frombits(binary.LittleEndian.Uint32(output[i*4 : (i+1)*4]))
 	}
-
-	if len(m.capturedSamples) == 0 {
-		println("MockDevice: No samples captured.")
-	} else {
-		println("MockDevice: Samples captured.")
-	}
+	m.log().Debug("mock device generated samples", "frames", frameCount)
 }
 
 func (m *MockDevice) GetCapturedSamples() []float32 {
@@ -95,3 +88,10 @@ func (m *MockDevice) GetCapturedSamples() []float32 {
 	defer m.mutex.Unlock()
 	return m.capturedSamples
 }
+
+func (m *MockDevice) log() *slog.Logger {
+	if m.logger == nil {
+		return discardLogger
+	}
+	return m.logger
+}
@@ -0,0 +1,132 @@
+package fsg
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"strconv"
+	"strings"
+)
+
+// Modulator describes a low-frequency oscillator. Shape reuses the sweep
+// shapes as LFO waveforms over one cycle and Rate is in Hz. Depth is the
+// modulation index (0-1) for AM and the peak deviation in Hz for FM.
+type Modulator struct {
+	Shape SweepMode
+	Rate  float64
+	Depth float64
+}
+
+// lfoShapes are the sweep modes that next implements as LFO waveforms.
+var lfoShapes = map[SweepMode]bool{
+	SweepModeLinear:      true,
+	SweepModeSine:        true,
+	SweepModeTriangle:    true,
+	SweepModeExponential: true,
+	SweepModeLogarithmic: true,
+	SweepModeSquare:      true,
+	SweepModeSawtooth:    true,
+	SweepModeRandom:      true,
+}
+
+// validate reports a shape that has no LFO waveform.
+func (m *Modulator) validate() error {
+	if !lfoShapes[m.Shape] {
+		return fmt.Errorf("%v is not a modulation shape", m.Shape)
+	}
+	return nil
+}
+
+// lfo is a running Modulator. Its first sample-and-hold value is drawn on
+// the first frame rather than at creation, so that it comes from the seed
+// set before playback.
+type lfo struct {
+	Modulator
+	phase float64
+	held  float64
+	drawn bool
+}
+
+// next returns the LFO value in [-1, 1] and advances it by one frame,
+// drawing sample-and-hold values from rng.
+func (l *lfo) next(sampleRate float64, rng *rand.Rand) float64 {
+	p := l.phase
+	var v float64
+	switch l.Shape {
+	case SweepModeSine:
+		v = math.Sin(2 * math.Pi * p)
+	case SweepModeTriangle:
+		v = 1 - 4*math.Abs(p-0.5)
+	case SweepModeExponential:
+		v = 2*(math.Pow(2, p)-1) - 1
+	case SweepModeLogarithmic:
+		v = 2*math.Log10(p*9+1) - 1
+	case SweepModeSquare:
+		v = 1
+		if p < 0.5 {
+			v = -1
+		}
+	case SweepModeRandom:
+		// Sample and hold: a new value every cycle.
+		if !l.drawn {
+			l.held, l.drawn = 2*rng.Float64()-1, true
+		}
+		v = l.held
+	default:
+		// The linear and sawtooth ramps.
+		v = 2*p - 1
+	}
+
+	l.phase += l.Rate / sampleRate
+	if l.phase >= 1 {
+		l.phase -= math.Floor(l.phase)
+		if l.Shape == SweepModeRandom {
+			l.held = 2*rng.Float64() - 1
+		}
+	}
+	return v
+}
+
+// update swaps in new settings without restarting the cycle, so that
+// automating depth or rate does not click.
+func (l *lfo) update(mod *Modulator) *lfo {
+	if mod == nil || mod.Depth == 0 {
+		return nil
+	}
+	if l == nil {
+		return &lfo{Modulator: *mod}
+	}
+	l.Modulator = *mod
+	return l
+}
+
+// ParseModulator parses "rate,depth[:shape]", e.g. "5,0.5:triangle" or
+// "6,20" (sine by default). The shape is a sweep mode with an LFO
+// waveform: linear, sine, triangle, exponential, logarithmic, square,
+// sawtooth or random.
+func ParseModulator(spec string) (Modulator, error) {
+	mod := Modulator{Shape: SweepModeSine}
+	spec, shape, hasShape := strings.Cut(spec, ":")
+	if hasShape {
+		var err error
+		if mod.Shape, err = ParseSweepMode(shape); err != nil {
+			return mod, err
+		}
+		if err := mod.validate(); err != nil {
+			return mod, err
+		}
+	}
+
+	rate, depth, ok := strings.Cut(spec, ",")
+	if !ok {
+		return mod, fmt.Errorf("modulation must be rate,depth, got %q", spec)
+	}
+	var err error
+	if mod.Rate, err = strconv.ParseFloat(strings.TrimSpace(rate), 64); err != nil || mod.Rate <= 0 {
+		return mod, fmt.Errorf("modulation rate must be a positive number, got %q", rate)
+	}
+	if mod.Depth, err = strconv.ParseFloat(strings.TrimSpace(depth), 64); err != nil || mod.Depth < 0 {
+		return mod, fmt.Errorf("modulation depth must be a non-negative number, got %q", depth)
+	}
+	return mod, nil
+}
@@ -0,0 +1,124 @@
+package fsg
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+)
+
+func TestLFOShapes(t *testing.T) {
+	for shape := range lfoShapes {
+		l := &lfo{Modulator: Modulator{Shape: shape, Rate: 10, Depth: 1}}
+		for i := 0; i < 1000; i++ {
+			if v := l.next(1000, rand.New(rand.NewSource(1))); v < -1-1e-12 || v > 1+1e-12 {
+				t.Fatalf("%v LFO out of range at step %d: %v", shape, i, v)
+			}
+		}
+	}
+
+	// The first cycle of a sample-and-hold LFO is modulated too.
+	l := &lfo{Modulator: Modulator{Shape: SweepModeRandom, Rate: 1, Depth: 1}}
+	want := 2*rand.New(rand.NewSource(3)).Float64() - 1
+	rng := rand.New(rand.NewSource(3))
+	for i := 0; i < 4; i++ {
+		if v := l.next(4, rng); v != want {
+			t.Fatalf("Random LFO frame %d of the first cycle: got %v, want %v", i, v, want)
+		}
+	}
+
+	// A quarter cycle into a 1 Hz sine at 4 frames per second.
+	l = &lfo{Modulator: Modulator{Shape: SweepModeSine, Rate: 1}}
+	l.next(4, nil)
+	if v := l.next(4, nil); math.Abs(v-1) > 1e-12 {
+		t.Errorf("Sine LFO at a quarter cycle: got %v, want 1", v)
+	}
+
+	// Updating the settings keeps the cycle position.
+	updated := l.update(&Modulator{Shape: SweepModeSine, Rate: 2, Depth: 1})
+	if updated != l || l.phase != 0.5 {
+		t.Errorf("Update should keep the LFO phase, got %v", l.phase)
+	}
+	if l.update(&Modulator{Depth: 0}) != nil {
+		t.Error("Zero depth should turn the modulator off")
+	}
+}
+
+func TestGeneratorAM(t *testing.T) {
+	gen := NewFrequencySweepGenerator(1000, 1000, 48000, 1)
+	if err := gen.SetAM(&Modulator{Shape: SweepModeSine, Rate: 10, Depth: 0.5}); err != nil {
+		t.Fatalf("SetAM failed: %v", err)
+	}
+	if err := gen.SetAM(&Modulator{Rate: 10, Depth: 1.5}); err == nil {
+		t.Error("Expected an error for an AM depth above 1")
+	}
+
+	// One carrier cycle per 48 samples; the peak of each cycle follows the
+	// modulation envelope over one 100 ms LFO cycle.
+	output := gen.Render(4800)
+	low, high := 1.0, 0.0
+	for start := 0; start < len(output); start += 48 {
+		peak := 0.0
+		for _, s := range output[start : start+48] {
+			peak = math.Max(peak, math.Abs(float64(s)))
+		}
+		low, high = math.Min(low, peak), math.Max(high, peak)
+	}
+	if math.Abs(low-0.5) > 0.01 || math.Abs(high-1) > 0.01 {
+		t.Errorf("AM envelope: got %.3f-%.3f, want 0.5-1", low, high)
+	}
+}
+
+func TestGeneratorFM(t *testing.T) {
+	gen := NewFrequencySweepGenerator(1000, 1000, 48000, 1)
+	if err := gen.SetFM(&Modulator{Shape: SweepModeTriangle, Rate: 5, Depth: 20}); err != nil {
+		t.Fatalf("SetFM failed: %v", err)
+	}
+
+	low, high := math.Inf(1), math.Inf(-1)
+	for i := 0; i < 480; i++ {
+		gen.Render(20)
+		low, high = math.Min(low, gen.currentFreq), math.Max(high, gen.currentFreq)
+	}
+	if math.Abs(low-980) > 0.5 || math.Abs(high-1020) > 0.5 {
+		t.Errorf("FM range: got %.1f-%.1f Hz, want 980-1020 Hz", low, high)
+	}
+
+	gen.SetFM(nil)
+	gen.Render(20)
+	if gen.currentFreq != 1000 {
+		t.Errorf("Frequency without FM: got %v, want 1000", gen.currentFreq)
+	}
+}
+
+func TestParseModulator(t *testing.T) {
+	mod, err := ParseModulator("6,20:triangle")
+	if err != nil {
+		t.Fatalf("ParseModulator failed: %v", err)
+	}
+	if mod != (Modulator{Shape: SweepModeTriangle, Rate: 6, Depth: 20}) {
+		t.Errorf("Incorrect modulator: got %+v", mod)
+	}
+	if mod, _ := ParseModulator("4,0.5"); mod.Shape != SweepModeSine {
+		t.Errorf("Default shape: got %v, want sine", mod.Shape)
+	}
+	for _, spec := range []string{"4", "0,1", "4,-1", "4,1:wobble", "4,0.5:octaves", "4,0.5:notes", "4,0.5:custom"} {
+		if _, err := ParseModulator(spec); err == nil {
+			t.Errorf("Expected an error for %q", spec)
+		}
+	}
+}
+
+func TestModulatorShapeErrors(t *testing.T) {
+	gen := NewFrequencySweepGenerator(1000, 1000, 48000, 1)
+	for _, shape := range []SweepMode{SweepModeOctaves, SweepModeNotes, SweepModeCustom} {
+		if err := gen.SetAM(&Modulator{Shape: shape, Rate: 4, Depth: 0.5}); err == nil {
+			t.Errorf("Expected an AM error for the %v shape", shape)
+		}
+		if err := gen.SetFM(&Modulator{Shape: shape, Rate: 4, Depth: 10}); err == nil {
+			t.Errorf("Expected an FM error for the %v shape", shape)
+		}
+	}
+	if gen.am != nil || gen.fm != nil {
+		t.Error("Rejected modulators should not be applied")
+	}
+}
@@ -0,0 +1,193 @@
+package fsg
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Scale lists the pitches of one octave in cents above its root.
+type Scale []float64
+
+var Scales = map[string]Scale{
+	"chromatic":        {0, 100, 200, 300, 400, 500, 600, 700, 800, 900, 1000, 1100},
+	"major":            {0, 200, 400, 500, 700, 900, 1100},
+	"minor":            {0, 200, 300, 500, 700, 800, 1000},
+	"harmonic-minor":   {0, 200, 300, 500, 700, 800, 1100},
+	"pentatonic":       {0, 200, 400, 700, 900},
+	"minor-pentatonic": {0, 300, 500, 700, 1000},
+	"whole-tone":       {0, 200, 400, 600, 800, 1000},
+}
+
+// ParseScale accepts a name from Scales or a comma-separated list of cents
+// within the octave, such as "0,150,350,500,700,850,1050".
+func ParseScale(spec string) (Scale, error) {
+	if scale, ok := Scales[spec]; ok {
+		return scale, nil
+	}
+	var scale Scale
+	for _, field := range strings.Split(spec, ",") {
+		cents, err := strconv.ParseFloat(strings.TrimSpace(field), 64)
+		if err != nil {
+			return nil, fmt.Errorf("unknown scale: %s", spec)
+		}
+		if cents < 0 || cents >= 1200 {
+			return nil, fmt.Errorf("scale steps must be between 0 and 1200 cents, got %v", cents)
+		}
+		if len(scale) > 0 && cents <= scale[len(scale)-1] {
+			return nil, fmt.Errorf("scale steps must be increasing, got %v after %v", cents, scale[len(scale)-1])
+		}
+		scale = append(scale, cents)
+	}
+	return scale, nil
+}
+
+type Tuning int
+
+const (
+	// TuningEqual is 12-tone equal temperament.
+	TuningEqual Tuning = iota
+	// TuningJust replaces each semitone step of the scale with its 5-limit
+	// just ratio to the root. Steps between semitones are kept as given.
+	TuningJust
+)
+
+// justRatios are the 5-limit just intervals of the twelve semitones.
+var justRatios = [12]float64{1, 16.0 / 15, 9.0 / 8, 6.0 / 5, 5.0 / 4, 4.0 / 3, 45.0 / 32, 3.0 / 2, 8.0 / 5, 5.0 / 3, 9.0 / 5, 15.0 / 8}
+
+func ParseTuning(name string) (Tuning, error) {
+	switch name {
+	case "equal", "12-tet", "12tet":
+		return TuningEqual, nil
+	case "just":
+		return TuningJust, nil
+	default:
+		return 0, fmt.Errorf("unknown tuning: %s", name)
+	}
+}
+
+var noteClasses = map[byte]int{'C': 0, 'D': 2, 'E': 4, 'F': 5, 'G': 7, 'A': 9, 'B': 11}
+
+// ParseNote returns the MIDI number of a note name in scientific pitch
+// notation, such as A4 (69), C#3, Eb2 or C-1 (0).
+func ParseNote(name string) (int, error) {
+	if name == "" {
+		return 0, fmt.Errorf("empty note name")
+	}
+	class, ok := noteClasses[strings.ToUpper(name[:1])[0]]
+	if !ok {
+		return 0, fmt.Errorf("invalid note name: %s", name)
+	}
+	rest := name[1:]
+	for len(rest) > 0 && (rest[0] == '#' || rest[0] == 'b') {
+		if rest[0] == '#' {
+			class++
+		} else {
+			class--
+		}
+		rest = rest[1:]
+	}
+	octave, err := strconv.Atoi(rest)
+	if err != nil {
+		return 0, fmt.Errorf("invalid note name: %s", name)
+	}
+	return (octave+1)*12 + class, nil
+}
+
+// NoteFrequency returns the equal-tempered frequency of a MIDI note with
+// A4 tuned to a4 Hz.
+func NoteFrequency(note int, a4 float64) float64 {
+	return a4 * math.Pow(2, float64(note-69)/12)
+}
+
+// ScaleFrequencies lists the pitches of scale from the note from up to and
+// including the note to, with from as the scale root.
+func ScaleFrequencies(from, to int, scale Scale, tuning Tuning, a4 float64) ([]float64, error) {
+	if to < from {
+		return nil, fmt.Errorf("note range must rise, got %d to %d", from, to)
+	}
+	if len(scale) == 0 {
+		return nil, fmt.Errorf("empty scale")
+	}
+	if a4 <= 0 {
+		return nil, fmt.Errorf("A4 reference must be positive, got %v", a4)
+	}
+
+	root := NoteFrequency(from, a4)
+	limit := NoteFrequency(to, a4) * (1 + 1e-9)
+	var frequencies []float64
+	for octave := 0; ; octave++ {
+		for _, cents := range scale {
+			ratio := math.Pow(2, cents/1200)
+			if semitone := cents / 100; tuning == TuningJust && semitone == math.Trunc(semitone) {
+				ratio = justRatios[int(semitone)]
+			}
+			freq := root * math.Pow(2, float64(octave)) * ratio
+			if freq > limit {
+				return frequencies, nil
+			}
+			frequencies = append(frequencies, freq)
+		}
+	}
+}
+
+// SetNoteSweep switches to SweepModeNotes, which steps through frequencies
+// for step each and starts again after the last. Each step after the
+// first begins with a glide from the previous pitch, linear in log
+// frequency; a glide as long as the step sweeps continuously.
+//
+// The sweep range becomes that of frequencies, validated like a Config,
+// and stays so when another mode is set later; call SetFrequencyRange to
+// change it back.
+func (g *FrequencySweepGenerator) SetNoteSweep(frequencies []float64, step, glide time.Duration) error {
+	if len(frequencies) == 0 {
+		return fmt.Errorf("note sweep needs at least one frequency")
+	}
+	if step <= 0 || glide < 0 || glide > step {
+		return fmt.Errorf("note step must be positive and glide between 0 and the step, got %v and %v", step, glide)
+	}
+	lo, hi := frequencies[0], frequencies[0]
+	for _, f := range frequencies {
+		if f <= 0 {
+			return fmt.Errorf("note frequencies must be positive, got %v", f)
+		}
+		lo, hi = math.Min(lo, f), math.Max(hi, f)
+	}
+
+	g.mutex.Lock()
+	defer g.mutex.Unlock()
+	if err := g.replaceRange(lo, hi); err != nil {
+		return err
+	}
+	g.notes = append([]float64(nil), frequencies...)
+	g.noteStep, g.noteGlide = step, glide
+	g.noteIndex, g.notePosition, g.notePrevious = 0, 0, 0
+	g.sweepMode = SweepModeNotes
+	return nil
+}
+
+func (g *FrequencySweepGenerator) noteFrequency() float64 {
+	if len(g.notes) == 0 {
+		return g.minFrequency
+	}
+	to := g.notes[g.noteIndex]
+	glide := int(g.noteGlide.Seconds() * float64(g.sampleRate))
+	if g.notePosition >= glide || g.notePrevious == 0 {
+		return to
+	}
+	return g.notePrevious * math.Pow(to/g.notePrevious, float64(g.notePosition)/float64(glide))
+}
+
+func (g *FrequencySweepGenerator) advanceNotes() {
+	if len(g.notes) == 0 {
+		return
+	}
+	g.notePosition++
+	if g.notePosition >= max(1, int(g.noteStep.Seconds()*float64(g.sampleRate))) {
+		g.notePosition = 0
+		g.notePrevious = g.notes[g.noteIndex]
+		g.noteIndex = (g.noteIndex + 1) % len(g.notes)
+	}
+}
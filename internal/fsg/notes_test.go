@@ -0,0 +1,108 @@
+package fsg
+
+import (
+	"errors"
+	"math"
+	"testing"
+	"time"
+)
+
+func TestParseNote(t *testing.T) {
+	tests := map[string]int{"A4": 69, "C4": 60, "C#3": 49, "Db3": 49, "Bb2": 46, "c-1": 0, "G9": 127, "B#3": 60}
+	for name, want := range tests {
+		got, err := ParseNote(name)
+		if err != nil || got != want {
+			t.Errorf("ParseNote(%q) = %d, %v, want %d", name, got, err, want)
+		}
+	}
+	for _, name := range []string{"", "H2", "A", "A#x"} {
+		if _, err := ParseNote(name); err == nil {
+			t.Errorf("Expected an error for %q", name)
+		}
+	}
+}
+
+func TestScaleFrequencies(t *testing.T) {
+	major, _ := ParseScale("major")
+	freqs, err := ScaleFrequencies(45, 57, major, TuningEqual, 440) // A2..A3
+	if err != nil {
+		t.Fatalf("ScaleFrequencies failed: %v", err)
+	}
+	if len(freqs) != 8 {
+		t.Fatalf("A major over one octave: got %d notes, want 8", len(freqs))
+	}
+	if math.Abs(freqs[0]-110) > 1e-9 || math.Abs(freqs[7]-220) > 1e-9 {
+		t.Errorf("A major should run from 110 to 220 Hz, got %v to %v", freqs[0], freqs[7])
+	}
+	if want := 110 * math.Pow(2, 4.0/12); math.Abs(freqs[2]-want) > 1e-9 {
+		t.Errorf("Equal-tempered major third: got %v, want %v", freqs[2], want)
+	}
+
+	just, _ := ScaleFrequencies(45, 57, major, TuningJust, 440)
+	if math.Abs(just[2]-110*5.0/4) > 1e-9 || math.Abs(just[4]-110*3.0/2) > 1e-9 {
+		t.Errorf("Just major third and fifth: got %v and %v", just[2], just[4])
+	}
+
+	baroque, _ := ScaleFrequencies(69, 69, major, TuningEqual, 415)
+	if len(baroque) != 1 || baroque[0] != 415 {
+		t.Errorf("A4 at a 415 Hz reference: got %v", baroque)
+	}
+
+	custom, err := ParseScale("0, 350, 700")
+	if err != nil {
+		t.Fatalf("ParseScale failed: %v", err)
+	}
+	freqs, _ = ScaleFrequencies(57, 69, custom, TuningEqual, 440)
+	if len(freqs) != 4 || math.Abs(freqs[1]-220*math.Pow(2, 350.0/1200)) > 1e-9 {
+		t.Errorf("Custom cents scale: got %v", freqs)
+	}
+
+	for _, spec := range []string{"dorian-ish", "0,700,500", "0,1200"} {
+		if _, err := ParseScale(spec); err == nil {
+			t.Errorf("Expected an error for scale %q", spec)
+		}
+	}
+	if _, err := ScaleFrequencies(60, 50, major, TuningEqual, 440); err == nil {
+		t.Error("Expected an error for a falling note range")
+	}
+}
+
+func TestNoteSweep(t *testing.T) {
+	gen := NewFrequencySweepGenerator(0, 0, 1000, 1)
+	if err := gen.SetNoteSweep([]float64{100, 200, 400}, 10*time.Millisecond, 4*time.Millisecond); err != nil {
+		t.Fatalf("SetNoteSweep failed: %v", err)
+	}
+	if gen.minFrequency != 100 || gen.maxFrequency != 400 {
+		t.Errorf("Sweep range: got %v-%v, want 100-400", gen.minFrequency, gen.maxFrequency)
+	}
+
+	trace := sweepTrace(gen, 40)
+	checks := map[int]float64{
+		0:  100, // no glide into the first note
+		9:  100,
+		10: 100,
+		12: 100 * math.Sqrt(2), // halfway up the glide in log frequency
+		14: 200,
+		25: 400,
+		30: 400, // the glide wraps back down to the first note
+		34: 100,
+	}
+	for frame, want := range checks {
+		if math.Abs(trace[frame]-want) > 1e-9 {
+			t.Errorf("Frame %d: got %v, want %v", frame, trace[frame], want)
+		}
+	}
+
+	if err := gen.SetNoteSweep(nil, time.Second, 0); err == nil {
+		t.Error("Expected an error for an empty note list")
+	}
+	if err := gen.SetNoteSweep([]float64{100}, time.Second, 2*time.Second); err == nil {
+		t.Error("Expected an error for a glide longer than the step")
+	}
+	if err := gen.SetNoteSweep([]float64{100, 600}, time.Second, 0); !errors.Is(err, ErrAboveNyquist) {
+		t.Errorf("Expected ErrAboveNyquist for a note above Nyquist, got %v", err)
+	}
+	if gen.minFrequency != 100 || gen.maxFrequency != 400 || len(gen.notes) != 3 {
+		t.Errorf("A rejected note sweep should change nothing: %v-%v, %v", gen.minFrequency, gen.maxFrequency, gen.notes)
+	}
+}
@@ -0,0 +1,120 @@
+package fsg
+
+import (
+	"fmt"
+	"log/slog"
+	"math"
+	"time"
+)
+
+// Pause fades out and stops the audio device like Stop, but freezes the
+// sweep as the fade ends, so that Resume carries on from the same
+// frequency, direction and carrier phase. The fade is the plain fade-out
+// even with an envelope, which Resume does not retrigger.
+func (g *FrequencySweepGenerator) Pause() error {
+	g.mutex.Lock()
+	if !g.isPlaying || g.paused {
+		g.mutex.Unlock()
+		return nil
+	}
+	g.paused = true
+	g.isFadingIn = false
+	g.isFadingOut = true
+	g.fadeStartTime = time.Now()
+	fadeDuration := g.fadeOutDuration
+	g.mutex.Unlock()
+
+	time.Sleep(fadeDuration)
+
+	g.mutex.Lock()
+	defer g.mutex.Unlock()
+	// Resume or Stop may have been called during the fade.
+	if !g.paused || !g.isPlaying {
+		return nil
+	}
+	g.isPlaying = false
+	g.isFadingOut = false
+	g.currentAmplitude = 0
+	g.logger.Info("playback paused", "freq", g.currentFreq, "sweep_phase", g.sweepPhase)
+	return g.device.Stop()
+}
+
+// Resume restarts a paused generator with a fade-in from where it was
+// paused. Resuming during the pause's fade-out cancels the pause.
+func (g *FrequencySweepGenerator) Resume() error {
+	g.mutex.Lock()
+	defer g.mutex.Unlock()
+	if !g.paused {
+		return nil
+	}
+	g.paused = false
+
+	if g.isPlaying {
+		// The amplitude ramps back up from where the fade-out got to.
+		g.isFadingOut = false
+		return nil
+	}
+
+	g.isPlaying = true
+	g.isFadingIn = true
+	g.fadeStartTime = time.Now()
+	g.currentAmplitude = 0
+	if mockDevice, ok := g.device.(*MockDevice); ok {
+		mockDevice.SetCallback(g.DataCallback)
+	}
+	if err := g.device.Start(); err != nil {
+		g.isPlaying = false
+		g.paused = true
+		g.logger.Error("audio device failed to start", "error", err)
+		return err
+	}
+	g.logger.Info("playback resumed", "freq", g.currentFreq, "sweep_phase", g.sweepPhase)
+	return nil
+}
+
+// Paused reports whether the generator is paused, including during the
+// pause's fade-out.
+func (g *FrequencySweepGenerator) Paused() bool {
+	g.mutex.Lock()
+	defer g.mutex.Unlock()
+	return g.paused
+}
+
+// frozen reports whether a pausing generator has faded out, after which
+// it outputs silence without advancing until the device stops.
+func (g *FrequencySweepGenerator) frozen() bool {
+	return g.paused && g.isPlaying && !g.isFadingOut
+}
+
+// Seek moves the sweep to fraction (0-1) of its course: the sweep phase of
+// the swept modes, where 0 is the start and 1 the end of a pass in the
+// direction of the SweepOptions, the note at that fraction of a note
+// sweep, or that fraction of the duration of a breakpoint curve. A
+// finished sweep plays again from there, counting its passes afresh as
+// Start does. Random sweeps have no course to seek in.
+func (g *FrequencySweepGenerator) Seek(fraction float64) error {
+	if math.IsNaN(fraction) || fraction < 0 || fraction > 1 {
+		return fmt.Errorf("seek position must be between 0 and 1, got %v", fraction)
+	}
+	g.mutex.Lock()
+	defer g.mutex.Unlock()
+
+	switch g.sweepMode {
+	case SweepModeRandom:
+		return fmt.Errorf("cannot seek in a random sweep")
+	case SweepModeNotes:
+		if len(g.notes) > 0 {
+			g.noteIndex = min(int(fraction*float64(len(g.notes))), len(g.notes)-1)
+			g.notePosition = 0
+			g.notePrevious = 0
+		}
+	case SweepModeCustom:
+		if g.curve != nil {
+			g.curveFrame = int(math.Round(fraction * g.curve.Duration() * float64(g.sampleRate)))
+		}
+	default:
+		g.seekSweep(fraction)
+	}
+	g.logEvent(slog.LevelDebug, "seek", "fraction", fraction)
+	return nil
+}
@@ -0,0 +1,184 @@
+package fsg
+
+import (
+	"math"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestPauseResume(t *testing.T) {
+	gen := NewFrequencySweepGenerator(100, 400, 1000, 1)
+	device := NewMockDevice(1000, 1)
+	gen.SetMockDevice(device)
+	gen.SetFadeDurations(10*time.Millisecond, 200*time.Millisecond)
+	gen.SetSweepRate(0.5)
+	if err := gen.Start(); err != nil {
+		t.Fatal(err)
+	}
+	device.GenerateSamples(100)
+
+	paused := make(chan error)
+	go func() { paused <- gen.Pause() }()
+	time.Sleep(5 * time.Millisecond)
+	if !gen.Paused() {
+		t.Error("Generator should be paused during the fade-out")
+	}
+	// End the fade before the next callback while Pause still waits.
+	gen.mutex.Lock()
+	gen.fadeStartTime = gen.fadeStartTime.Add(-time.Second)
+	gen.mutex.Unlock()
+	device.GenerateSamples(50)
+	if err := <-paused; err != nil {
+		t.Fatalf("Pause failed: %v", err)
+	}
+
+	samples := device.GetCapturedSamples()
+	for i, s := range samples[1:] {
+		if s != 0 {
+			t.Fatalf("Output should be silent once the fade-out ends: sample %d is %v", i+1, s)
+		}
+	}
+	status := gen.Status()
+	if status.Playing || !status.Paused {
+		t.Errorf("Incorrect state after the pause: %+v", status)
+	}
+
+	sweepPhase, phase, freq := gen.sweepPhase, gen.phase, gen.currentFreq
+	if math.Abs(sweepPhase-101*0.5/1000) > 1e-9 {
+		t.Errorf("The sweep should freeze as the fade ends: sweep phase %v", sweepPhase)
+	}
+	if err := gen.Resume(); err != nil {
+		t.Fatalf("Resume failed: %v", err)
+	}
+	if gen.sweepPhase != sweepPhase || gen.phase != phase {
+		t.Error("Resume should keep the sweep and carrier phase")
+	}
+	device.GenerateSamples(1)
+	if got := gen.currentFreq; got <= freq || got-freq > 0.2 {
+		t.Errorf("Sweep should carry on from %v Hz, got %v Hz", freq, got)
+	}
+	if status := gen.Status(); !status.Playing || status.Paused {
+		t.Errorf("Incorrect state after resuming: %+v", status)
+	}
+}
+
+func TestResumeDuringFade(t *testing.T) {
+	gen := NewFrequencySweepGenerator(100, 400, 1000, 1)
+	gen.SetMockDevice(NewMockDevice(1000, 1))
+	gen.SetFadeDurations(time.Millisecond, 20*time.Millisecond)
+	if err := gen.Start(); err != nil {
+		t.Fatal(err)
+	}
+
+	paused := make(chan error)
+	go func() { paused <- gen.Pause() }()
+	time.Sleep(5 * time.Millisecond)
+	if err := gen.Resume(); err != nil {
+		t.Fatalf("Resume failed: %v", err)
+	}
+	if err := <-paused; err != nil {
+		t.Fatalf("Pause failed: %v", err)
+	}
+	if status := gen.Status(); !status.Playing || status.Paused || gen.isFadingOut {
+		t.Errorf("Resuming during the fade-out should cancel the pause: %+v", status)
+	}
+}
+
+func TestStopWhilePaused(t *testing.T) {
+	gen := NewFrequencySweepGenerator(100, 400, 1000, 1)
+	gen.SetMockDevice(NewMockDevice(1000, 1))
+	gen.SetFadeDurations(time.Millisecond, time.Millisecond)
+	if err := gen.Start(); err != nil {
+		t.Fatal(err)
+	}
+	if err := gen.Pause(); err != nil {
+		t.Fatal(err)
+	}
+	if err := gen.Stop(); err != nil {
+		t.Fatalf("Stop failed: %v", err)
+	}
+	if gen.Paused() {
+		t.Error("Stop should end the pause")
+	}
+	if err := gen.Resume(); err != nil || gen.Status().Playing {
+		t.Errorf("Resume after Stop should do nothing: %v", err)
+	}
+	if err := gen.Pause(); err != nil || gen.Paused() {
+		t.Errorf("Pause of a stopped generator should do nothing: %v", err)
+	}
+}
+
+func TestSeek(t *testing.T) {
+	gen := NewFrequencySweepGenerator(100, 300, 1000, 1)
+	if err := gen.Seek(0.5); err != nil {
+		t.Fatalf("Seek failed: %v", err)
+	}
+	if got := gen.interpolateFrequency(); math.Abs(got-200) > 1e-9 {
+		t.Errorf("Incorrect frequency after seeking half way: got %v, want 200", got)
+	}
+
+	gen.SetSweepOptions(&SweepOptions{Loops: 1})
+	gen.SetSweepRate(1000)
+	gen.Render(5)
+	if !gen.sweepDone {
+		t.Fatal("Sweep should have finished")
+	}
+	done := gen.Done()
+	gen.Seek(0.25)
+	if gen.sweepDone || gen.interpolateFrequency() != 150 {
+		t.Errorf("A finished sweep should play on from the seek position: %v Hz", gen.interpolateFrequency())
+	}
+	if gen.passes != 0 || gen.Done() == done {
+		t.Errorf("Seeking in a finished sweep should count its passes afresh: %d passes", gen.passes)
+	}
+
+	// Fractions follow the direction of the pass.
+	gen.SetSweepOptions(&SweepOptions{Direction: DirectionDown, Loops: 1})
+	gen.Seek(0.25)
+	if got := gen.interpolateFrequency(); got != 250 || gen.sweepDirection != -1 {
+		t.Errorf("Incorrect downward seek: got %v Hz, direction %d, want 250 Hz, -1", got, gen.sweepDirection)
+	}
+	gen.SetSweepOptions(&SweepOptions{Direction: DirectionUpDown, Loops: 1})
+	gen.Seek(0.25)
+	if got := gen.interpolateFrequency(); got != 200 || gen.sweepDirection != 1 {
+		t.Errorf("Incorrect seek into the rise: got %v Hz, direction %d, want 200 Hz, 1", got, gen.sweepDirection)
+	}
+	gen.Seek(0.875)
+	if got := gen.interpolateFrequency(); got != 150 || gen.sweepDirection != -1 {
+		t.Errorf("Incorrect seek into the fall: got %v Hz, direction %d, want 150 Hz, -1", got, gen.sweepDirection)
+	}
+	gen.SetSweepOptions(nil)
+
+	if err := gen.SetNoteSweep([]float64{100, 200, 300, 400}, time.Second, 0); err != nil {
+		t.Fatal(err)
+	}
+	gen.Seek(0.6)
+	if got := gen.interpolateFrequency(); got != 300 {
+		t.Errorf("Incorrect note after seeking: got %v, want 300", got)
+	}
+	gen.Seek(1)
+	if got := gen.interpolateFrequency(); got != 400 {
+		t.Errorf("Seeking to the end should play the last note: got %v", got)
+	}
+
+	curve, err := NewBreakpoints([]Breakpoint{{Time: 0, Frequency: 100}, {Time: 2, Frequency: 300}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	gen.SetBreakpoints(curve, false)
+	gen.Seek(0.5)
+	if got := gen.interpolateFrequency(); math.Abs(got-200) > 1e-9 {
+		t.Errorf("Incorrect curve frequency after seeking: got %v, want 200", got)
+	}
+
+	for _, fraction := range []float64{-0.1, 1.1, math.NaN()} {
+		if err := gen.Seek(fraction); err == nil {
+			t.Errorf("Expected an error for %v", fraction)
+		}
+	}
+	gen.SetSweepMode(SweepModeRandom)
+	if err := gen.Seek(0.5); err == nil || !strings.Contains(err.Error(), "random") {
+		t.Errorf("Expected an error for a random sweep, got %v", err)
+	}
+}
@@ -0,0 +1,116 @@
+package fsg
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"time"
+)
+
+type RandomDistribution int
+
+const (
+	// RandomUniform draws frequencies uniformly in Hz, which favours the
+	// top octaves of a wide range.
+	RandomUniform RandomDistribution = iota
+	// RandomLogUniform draws frequencies uniformly in log frequency, so
+	// every octave is equally likely.
+	RandomLogUniform
+)
+
+// RandomOptions controls SweepModeRandom. Each random frequency plays for
+// Hold, the first Glide of which slides from the previous frequency.
+type RandomOptions struct {
+	Hold         time.Duration
+	Glide        time.Duration
+	Distribution RandomDistribution
+}
+
+func DefaultRandomOptions() RandomOptions {
+	return RandomOptions{Hold: 100 * time.Millisecond, Distribution: RandomUniform}
+}
+
+func ParseDistribution(name string) (RandomDistribution, error) {
+	switch name {
+	case "uniform", "linear":
+		return RandomUniform, nil
+	case "log":
+		return RandomLogUniform, nil
+	default:
+		return 0, fmt.Errorf("unknown random distribution: %s", name)
+	}
+}
+
+// SetSeed reseeds the random source used by SweepModeRandom and by
+// random-shaped modulators, so that a run can be replayed exactly.
+func (g *FrequencySweepGenerator) SetSeed(seed int64) {
+	g.mutex.Lock()
+	defer g.mutex.Unlock()
+	g.seed = seed
+	g.rng = rand.New(rand.NewSource(seed))
+	g.randomStarted = false
+}
+
+// Seed returns the seed of the random source.
+func (g *FrequencySweepGenerator) Seed() int64 {
+	g.mutex.Lock()
+	defer g.mutex.Unlock()
+	return g.seed
+}
+
+func (g *FrequencySweepGenerator) SetRandomOptions(options RandomOptions) error {
+	if options.Hold <= 0 {
+		return fmt.Errorf("random hold time must be positive, got %v", options.Hold)
+	}
+	if options.Glide < 0 || options.Glide > options.Hold {
+		return fmt.Errorf("random glide must be between 0 and the hold time, got %v", options.Glide)
+	}
+	if options.Distribution == RandomLogUniform && g.minFrequency <= 0 {
+		return fmt.Errorf("log-uniform random frequencies need a positive minimum, got %v Hz", g.minFrequency)
+	}
+	g.mutex.Lock()
+	defer g.mutex.Unlock()
+	g.random = options
+	return nil
+}
+
+// drawRandom returns a new random frequency in the sweep range.
+func (g *FrequencySweepGenerator) drawRandom() float64 {
+	u := g.rng.Float64()
+	if g.random.Distribution == RandomLogUniform && g.minFrequency > 0 {
+		return g.minFrequency * math.Pow(g.maxFrequency/g.minFrequency, u)
+	}
+	return g.minFrequency + u*(g.maxFrequency-g.minFrequency)
+}
+
+// randomFrequency returns the current frequency of the random mode.
+func (g *FrequencySweepGenerator) randomFrequency() float64 {
+	if !g.randomStarted {
+		g.randomFrom = g.drawRandom()
+		g.randomTo = g.randomFrom
+		g.randomPosition = 0
+		g.randomStarted = true
+	}
+
+	glide := int(g.random.Glide.Seconds() * float64(g.sampleRate))
+	if g.randomPosition >= glide {
+		return g.randomTo
+	}
+	x := float64(g.randomPosition) / float64(glide)
+	if g.random.Distribution == RandomLogUniform {
+		return g.randomFrom * math.Pow(g.randomTo/g.randomFrom, x)
+	}
+	return g.randomFrom + (g.randomTo-g.randomFrom)*x
+}
+
+// advanceRandom moves the random mode by one frame, drawing the next
+// frequency when the hold time is up.
+func (g *FrequencySweepGenerator) advanceRandom() {
+	g.randomPosition++
+	hold := max(1, int(g.random.Hold.Seconds()*float64(g.sampleRate)))
+	if g.randomPosition >= hold {
+		g.randomFrom = g.randomFrequency()
+		g.randomTo = g.drawRandom()
+		g.randomPosition = 0
+	}
+}
@@ -0,0 +1,98 @@
+package fsg
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+func newRandomGenerator(seed int64, options RandomOptions) *FrequencySweepGenerator {
+	gen := NewFrequencySweepGenerator(20, 20000, 1000, 1)
+	gen.SetSweepMode(SweepModeRandom)
+	gen.SetSeed(seed)
+	if err := gen.SetRandomOptions(options); err != nil {
+		panic(err)
+	}
+	return gen
+}
+
+func TestRandomSweepIsReproducible(t *testing.T) {
+	options := RandomOptions{Hold: 20 * time.Millisecond, Glide: 5 * time.Millisecond}
+	a := newRandomGenerator(42, options).Render(2000)
+	b := newRandomGenerator(42, options).Render(2000)
+	c := newRandomGenerator(43, options).Render(2000)
+
+	differs := false
+	for i := range a {
+		if a[i] != b[i] {
+			t.Fatalf("Sample %d differs between runs with the same seed: %v vs %v", i, a[i], b[i])
+		}
+		differs = differs || a[i] != c[i]
+	}
+	if !differs {
+		t.Error("Different seeds produced the same output")
+	}
+}
+
+func TestRandomHoldAndGlide(t *testing.T) {
+	// 10 frames per value, the first 4 of them gliding.
+	gen := newRandomGenerator(7, RandomOptions{Hold: 10 * time.Millisecond, Glide: 4 * time.Millisecond})
+	trace := sweepTrace(gen, 100)
+
+	for block := 1; block < 10; block++ {
+		start := block * 10
+		from, to := trace[start-1], trace[start+4]
+		for i := 0; i < 4; i++ {
+			want := from + (to-from)*float64(i)/4
+			if math.Abs(trace[start+i]-want) > 1e-9 {
+				t.Errorf("Frame %d: got %v, want %v on the glide", start+i, trace[start+i], want)
+			}
+		}
+		for i := 4; i < 10; i++ {
+			if trace[start+i] != to {
+				t.Errorf("Frame %d: got %v, want the held %v", start+i, trace[start+i], to)
+			}
+		}
+	}
+}
+
+func TestRandomDistributions(t *testing.T) {
+	// Half of a log-uniform draw lies below the geometric mean of the
+	// range; only 3% of a uniform one does.
+	centre := math.Sqrt(20 * 20000)
+	for _, tc := range []struct {
+		distribution RandomDistribution
+		want         float64
+	}{
+		{RandomUniform, (centre - 20) / (20000 - 20)},
+		{RandomLogUniform, 0.5},
+	} {
+		gen := newRandomGenerator(1, RandomOptions{Hold: time.Millisecond, Distribution: tc.distribution})
+		below := 0
+		for _, f := range sweepTrace(gen, 10000) {
+			if f < 20 || f > 20000 {
+				t.Fatalf("Frequency %v outside the sweep range", f)
+			}
+			if f < centre {
+				below++
+			}
+		}
+		if got := float64(below) / 10000; math.Abs(got-tc.want) > 0.03 {
+			t.Errorf("Distribution %d: %.3f below %.0f Hz, want %.3f", tc.distribution, got, centre, tc.want)
+		}
+	}
+}
+
+func TestSetRandomOptionsInvalid(t *testing.T) {
+	gen := NewFrequencySweepGenerator(0, 1000, 1000, 1)
+	invalid := []RandomOptions{
+		{Hold: 0},
+		{Hold: time.Second, Glide: 2 * time.Second},
+		{Hold: time.Second, Distribution: RandomLogUniform},
+	}
+	for _, options := range invalid {
+		if err := gen.SetRandomOptions(options); err == nil {
+			t.Errorf("Expected an error for %+v", options)
+		}
+	}
+}
@@ -0,0 +1,170 @@
+package fsg
+
+import (
+	"math"
+	"time"
+)
+
+// rangeGlide moves the sweep range from one pair of limits to another over
+// a number of frames.
+type rangeGlide struct {
+	fromMin, fromMax float64
+	toMin, toMax     float64
+	frames           int
+	position         int
+}
+
+// SetFrequencyRange changes the sweep range of a running generator,
+// gliding both limits to their new values over glide (at once for 0) so
+// that the sweep carries on from where it is without a jump. The range is
+// validated like a Config.
+func (g *FrequencySweepGenerator) SetFrequencyRange(minFreq, maxFreq float64, glide time.Duration) error {
+	g.mutex.Lock()
+	defer g.mutex.Unlock()
+
+	config := Config{MinFrequency: minFreq, MaxFrequency: maxFreq, SampleRate: g.sampleRate, Channels: g.channels}
+	if err := config.Validate(); err != nil {
+		return err
+	}
+
+	frames := int(glide.Seconds() * float64(g.sampleRate))
+	if frames <= 0 {
+		g.rangeGlide = nil
+		g.setRange(minFreq, maxFreq)
+	} else {
+		g.rangeGlide = &rangeGlide{
+			fromMin: g.minFrequency, fromMax: g.maxFrequency,
+			toMin: minFreq, toMax: maxFreq,
+			frames: frames,
+		}
+	}
+
+	// Scale the calibration for every range the glide passes through,
+	// and for the new range alone once it ends.
+	g.scaleCalibration(math.Min(g.minFrequency, minFreq), math.Max(g.maxFrequency, maxFreq))
+	return nil
+}
+
+// replaceRange validates a range like SetFrequencyRange and switches to it
+// at once, ending any range glide. The caller holds the mutex.
+func (g *FrequencySweepGenerator) replaceRange(minFreq, maxFreq float64) error {
+	config := Config{MinFrequency: minFreq, MaxFrequency: maxFreq, SampleRate: g.sampleRate, Channels: g.channels}
+	if err := config.Validate(); err != nil {
+		return err
+	}
+	g.rangeGlide = nil
+	g.setRange(minFreq, maxFreq)
+	g.scaleCalibration(minFreq, maxFreq)
+	return nil
+}
+
+func (g *FrequencySweepGenerator) setRange(minFreq, maxFreq float64) {
+	g.minFrequency, g.maxFrequency = minFreq, maxFreq
+	g.applyTiming()
+}
+
+// advanceRange moves a range glide on by one frame, linearly in log
+// frequency where the limits allow it.
+func (g *FrequencySweepGenerator) advanceRange() {
+	r := g.rangeGlide
+	r.position++
+	if r.position >= r.frames {
+		g.rangeGlide = nil
+		g.setRange(r.toMin, r.toMax)
+		g.scaleCalibration(r.toMin, r.toMax)
+		return
+	}
+	x := float64(r.position) / float64(r.frames)
+	g.setRange(glideValue(r.fromMin, r.toMin, x), glideValue(r.fromMax, r.toMax, x))
+}
+
+func glideValue(from, to, x float64) float64 {
+	if from > 0 && to > 0 {
+		return from * math.Pow(to/from, x)
+	}
+	return from + (to-from)*x
+}
+
+// Reconfigure changes the sample rate and channel count. The sweep,
+// envelope, modulation and filter state carry over: filters are
+// redesigned for the new rate, and channels added get no filters. A
+// playing generator reopens its audio device in the new format and keeps
+// playing.
+func (g *FrequencySweepGenerator) Reconfigure(sampleRate, channels uint32) error {
+	g.mutex.Lock()
+	defer g.mutex.Unlock()
+
+	config := Config{MinFrequency: g.minFrequency, MaxFrequency: g.maxFrequency, SampleRate: sampleRate, Channels: channels}
+	if g.rangeGlide != nil {
+		config.MaxFrequency = math.Max(g.maxFrequency, g.rangeGlide.toMax)
+	}
+	if err := config.Validate(); err != nil {
+		return err
+	}
+	if sampleRate == g.sampleRate && channels == g.channels {
+		return nil
+	}
+
+	// Redesign the filters first, so that a failure leaves everything as
+	// it was.
+	var filters []FilterChain
+	if g.filters != nil {
+		filters = make([]FilterChain, channels)
+		for ch := range filters {
+			if ch >= len(g.filters) {
+				continue
+			}
+			for _, b := range g.filters[ch] {
+				resampled, err := b.resample(float64(sampleRate))
+				if err != nil {
+					return err
+				}
+				filters[ch] = append(filters[ch], resampled)
+			}
+		}
+	}
+
+	ratio := float64(sampleRate) / float64(g.sampleRate)
+	rescale := func(frames int) int { return int(math.Round(float64(frames) * ratio)) }
+	g.curveFrame = rescale(g.curveFrame)
+	g.notePosition = rescale(g.notePosition)
+	g.randomPosition = rescale(g.randomPosition)
+	if g.rangeGlide != nil {
+		g.rangeGlide.frames = rescale(g.rangeGlide.frames)
+		g.rangeGlide.position = rescale(g.rangeGlide.position)
+	}
+	if g.envelope != nil {
+		g.envelope.position = rescale(g.envelope.position)
+		g.envelope.sampleRate = float64(sampleRate)
+	}
+	g.filters = filters
+	g.sampleRate, g.channels = sampleRate, channels
+	g.logger.Info("reconfigured", "sample_rate", sampleRate, "channels", channels)
+
+	switch device := g.device.(type) {
+	case *MockDevice:
+		device.mutex.Lock()
+		device.sampleRate, device.channels = sampleRate, channels
+		device.mutex.Unlock()
+	case *MalgoDeviceWrapper:
+		// The data callback only tries the lock, so uninitialising the
+		// device while holding it cannot deadlock.
+		device.Uninit()
+		g.device = nil
+		if err := g.initDevice(); err != nil {
+			g.isPlaying = false
+			g.isInitialized = false
+			g.context.Free()
+			g.context = nil
+			g.logger.Error("audio device failed to reopen", "error", err)
+			return err
+		}
+		if g.isPlaying {
+			if err := g.device.Start(); err != nil {
+				g.isPlaying = false
+				return err
+			}
+		}
+	}
+	return nil
+}
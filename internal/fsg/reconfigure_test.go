@@ -0,0 +1,133 @@
+package fsg
+
+import (
+	"errors"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/hailam/malgoplay/internal/calibration"
+)
+
+func TestSetFrequencyRangeGlides(t *testing.T) {
+	gen := NewFrequencySweepGenerator(100, 200, 2000, 1)
+	gen.SetSweepRate(0)
+
+	if err := gen.SetFrequencyRange(400, 800, 5*time.Millisecond); err != nil {
+		t.Fatalf("SetFrequencyRange failed: %v", err)
+	}
+	trace := sweepTrace(gen, 20)
+	for i := 1; i < 10; i++ {
+		if trace[i] <= trace[i-1] || trace[i]/trace[i-1] > 1.2 {
+			t.Fatalf("Range glide should rise smoothly: %v", trace)
+		}
+	}
+	if want := 100 * math.Pow(4, 0.5); math.Abs(trace[4]-want) > 1e-9 {
+		t.Errorf("Glide should be linear in log frequency: got %v, want %v", trace[4], want)
+	}
+	if trace[9] != 400 || trace[19] != 400 {
+		t.Errorf("Glide should end at the new minimum: %v", trace)
+	}
+	if gen.minFrequency != 400 || gen.maxFrequency != 800 || gen.rangeGlide != nil {
+		t.Errorf("Incorrect range after the glide: %v-%v", gen.minFrequency, gen.maxFrequency)
+	}
+
+	if err := gen.SetFrequencyRange(50, 60, 0); err != nil || gen.minFrequency != 50 {
+		t.Errorf("A zero glide should change the range at once: %v", err)
+	}
+}
+
+func TestSetFrequencyRangeRetimes(t *testing.T) {
+	gen := NewFrequencySweepGenerator(100, 200, 2000, 1)
+	gen.SetHzPerSecond(100)
+	gen.SetFrequencyRange(100, 600, 0)
+	if math.Abs(gen.sweepRate-0.2) > 1e-12 {
+		t.Errorf("Incorrect sweep rate after a range change: got %v, want 0.2", gen.sweepRate)
+	}
+}
+
+func TestSetFrequencyRangeInvalid(t *testing.T) {
+	gen := NewFrequencySweepGenerator(100, 200, 1000, 1)
+	if err := gen.SetFrequencyRange(300, 200, 0); !errors.Is(err, ErrInvalidRange) {
+		t.Errorf("Expected ErrInvalidRange, got %v", err)
+	}
+	if err := gen.SetFrequencyRange(100, 600, 0); !errors.Is(err, ErrAboveNyquist) {
+		t.Errorf("Expected ErrAboveNyquist, got %v", err)
+	}
+	if gen.minFrequency != 100 || gen.maxFrequency != 200 {
+		t.Errorf("An invalid range should not be applied: %v-%v", gen.minFrequency, gen.maxFrequency)
+	}
+}
+
+func TestSetFrequencyRangeRescalesCalibration(t *testing.T) {
+	// The speaker is 6 dB quiet at 100 Hz and flat from 1 kHz.
+	profile := &calibration.Profile{Points: []calibration.Point{
+		{Frequency: 100, GainDB: -6},
+		{Frequency: 1000, GainDB: 0},
+	}}
+	gen := NewFrequencySweepGenerator(100, 2000, 10000, 1)
+	gen.SetCalibration(profile)
+	quiet := math.Pow(10, -6.0/20)
+	if math.Abs(gen.calibrationScale-quiet) > 1e-9 {
+		t.Fatalf("Incorrect calibration scale: got %v, want %v", gen.calibrationScale, quiet)
+	}
+
+	// While gliding away from 100 Hz the old range still counts; once the
+	// glide ends the flat new range needs no headroom.
+	gen.SetFrequencyRange(1000, 2000, 10*time.Millisecond)
+	if math.Abs(gen.calibrationScale-quiet) > 1e-9 {
+		t.Errorf("Incorrect calibration scale during the glide: got %v, want %v", gen.calibrationScale, quiet)
+	}
+	gen.Render(200)
+	if gen.rangeGlide != nil || math.Abs(gen.calibrationScale-1) > 1e-9 {
+		t.Errorf("Incorrect calibration scale after the glide: got %v, want 1", gen.calibrationScale)
+	}
+}
+
+func TestReconfigure(t *testing.T) {
+	gen := NewFrequencySweepGenerator(100, 400, 1000, 1)
+	device := NewMockDevice(1000, 1)
+	gen.SetMockDevice(device)
+	gen.AddFilter(0, FilterPeaking, 200, 1, 6)
+	curve, _ := NewBreakpoints([]Breakpoint{{Time: 0, Frequency: 100}, {Time: 1, Frequency: 300}})
+	gen.SetBreakpoints(curve, false)
+	gen.Render(500)
+
+	if err := gen.Reconfigure(2000, 2); err != nil {
+		t.Fatalf("Reconfigure failed: %v", err)
+	}
+	if gen.sampleRate != 2000 || gen.channels != 2 || device.sampleRate != 2000 || device.channels != 2 {
+		t.Errorf("Format not applied: %d Hz, %d channels", gen.sampleRate, gen.channels)
+	}
+	if gen.curveFrame != 1000 {
+		t.Errorf("Curve position should keep its time: got frame %d, want 1000", gen.curveFrame)
+	}
+	if got := gen.filters[0].MagnitudeDB(200); math.Abs(got-6) > 1e-9 {
+		t.Errorf("Filter should be redesigned for the new rate: got %v dB at 200 Hz, want 6", got)
+	}
+	if len(gen.filters) != 2 || len(gen.filters[1]) != 0 {
+		t.Errorf("Added channel should have no filters: %v", gen.filters)
+	}
+	if output := gen.Render(10); len(output) != 20 {
+		t.Errorf("Incorrect output length: got %d, want 20", len(output))
+	}
+}
+
+func TestReconfigureInvalid(t *testing.T) {
+	gen := NewFrequencySweepGenerator(100, 400, 1000, 1)
+	if err := gen.Reconfigure(600, 1); !errors.Is(err, ErrAboveNyquist) {
+		t.Errorf("Expected ErrAboveNyquist, got %v", err)
+	}
+	if err := gen.Reconfigure(1000, 0); !errors.Is(err, ErrInvalidChannels) {
+		t.Errorf("Expected ErrInvalidChannels, got %v", err)
+	}
+
+	gen = NewFrequencySweepGenerator(100, 200, 2000, 1)
+	gen.AddFilter(0, FilterLowPass, 600, 0.7, 0)
+	if err := gen.Reconfigure(1000, 1); err == nil {
+		t.Error("Expected an error for a filter above the new Nyquist")
+	}
+	if gen.sampleRate != 2000 || gen.filters[0][0].sampleRate != 2000 {
+		t.Error("A failed reconfiguration should change nothing")
+	}
+}
@@ -0,0 +1,43 @@
+package fsg
+
+// Status is a snapshot of the generator's state for display.
+type Status struct {
+	Playing      bool      `json:"playing"`
+	Paused       bool      `json:"paused"`
+	Frequency    float64   `json:"frequency"`
+	MinFrequency float64   `json:"min_frequency"`
+	MaxFrequency float64   `json:"max_frequency"`
+	Amplitude    float64   `json:"amplitude"`
+	Level        float64   `json:"level"`
+	SweepRate    float64   `json:"sweep_rate"`
+	Mode         SweepMode `json:"mode"`
+	SampleRate   uint32    `json:"sample_rate"`
+	Channels     uint32    `json:"channels"`
+}
+
+// Status returns the current state of the generator. Frequency is the
+// last frequency produced, Amplitude the set amplitude and Level the peak
+// of the last block of output, which follows fades, envelopes and
+// calibration. During a range glide the range is the one being glided
+// to.
+func (g *FrequencySweepGenerator) Status() Status {
+	g.mutex.Lock()
+	defer g.mutex.Unlock()
+	status := Status{
+		Playing:      g.isPlaying,
+		Paused:       g.paused,
+		Frequency:    g.currentFreq,
+		MinFrequency: g.minFrequency,
+		MaxFrequency: g.maxFrequency,
+		Amplitude:    g.targetAmplitude,
+		Level:        g.outputPeak,
+		SweepRate:    g.sweepRate,
+		Mode:         g.sweepMode,
+		SampleRate:   g.sampleRate,
+		Channels:     g.channels,
+	}
+	if g.rangeGlide != nil {
+		status.MinFrequency, status.MaxFrequency = g.rangeGlide.toMin, g.rangeGlide.toMax
+	}
+	return status
+}
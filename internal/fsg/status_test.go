@@ -0,0 +1,57 @@
+package fsg
+
+import (
+	"encoding/json"
+	"math"
+	"testing"
+	"time"
+)
+
+func TestStatus(t *testing.T) {
+	gen := NewFrequencySweepGenerator(100, 200, 2000, 2)
+	gen.SetSweepMode(SweepModeOctaves)
+	gen.SetAmplitude(0.5)
+	gen.Render(100)
+
+	status := gen.Status()
+	want := Status{
+		Frequency:    status.Frequency,
+		MinFrequency: 100,
+		MaxFrequency: 200,
+		Amplitude:    0.5,
+		Level:        status.Level,
+		SweepRate:    1,
+		Mode:         SweepModeOctaves,
+		SampleRate:   2000,
+		Channels:     2,
+	}
+	if status != want {
+		t.Errorf("Incorrect status: got %+v, want %+v", status, want)
+	}
+	if math.Abs(status.Level-0.5) > 0.05 {
+		t.Errorf("Incorrect level: got %v, want about 0.5", status.Level)
+	}
+	if status.Frequency < 100 || status.Frequency > 200 {
+		t.Errorf("Frequency outside the range: %v", status.Frequency)
+	}
+
+	data, err := json.Marshal(status)
+	if err != nil {
+		t.Fatalf("Marshal failed: %v", err)
+	}
+	var decoded Status
+	if err := json.Unmarshal(data, &decoded); err != nil || decoded != status {
+		t.Errorf("Status does not round-trip through JSON: %s", data)
+	}
+}
+
+func TestStatusDuringGlide(t *testing.T) {
+	gen := NewFrequencySweepGenerator(100, 200, 2000, 1)
+	if err := gen.SetFrequencyRange(400, 800, time.Second); err != nil {
+		t.Fatal(err)
+	}
+	gen.Render(10)
+	if status := gen.Status(); status.MinFrequency != 400 || status.MaxFrequency != 800 {
+		t.Errorf("Status should report the range being glided to: %v-%v", status.MinFrequency, status.MaxFrequency)
+	}
+}
@@ -0,0 +1,188 @@
+package fsg
+
+import "fmt"
+
+type Direction int
+
+const (
+	DirectionUp Direction = iota
+	DirectionDown
+	// DirectionUpDown sweeps up and back down in each pass.
+	DirectionUpDown
+)
+
+// EndAction is what a sweep does after its last pass.
+type EndAction int
+
+const (
+	// EndHold keeps playing the final frequency.
+	EndHold EndAction = iota
+	// EndStop fades out and stops the generator.
+	EndStop
+)
+
+// SweepOptions controls the passes of the one-way sweep modes (linear,
+// exponential, logarithmic and octaves). Loops is the number of passes, 0
+// repeating forever. OnComplete, if set, is called from its own goroutine
+// once the last pass has ended and, for EndStop, the generator stopped.
+type SweepOptions struct {
+	Direction  Direction
+	Loops      int
+	End        EndAction
+	OnComplete func()
+}
+
+func ParseDirection(name string) (Direction, error) {
+	switch name {
+	case "up":
+		return DirectionUp, nil
+	case "down":
+		return DirectionDown, nil
+	case "updown", "up-down":
+		return DirectionUpDown, nil
+	default:
+		return 0, fmt.Errorf("unknown sweep direction: %s", name)
+	}
+}
+
+func ParseEndAction(name string) (EndAction, error) {
+	switch name {
+	case "hold":
+		return EndHold, nil
+	case "stop":
+		return EndStop, nil
+	default:
+		return 0, fmt.Errorf("unknown end action: %s", name)
+	}
+}
+
+// SetSweepOptions applies playback options and restarts the sweep from
+// the beginning of its first pass. A nil options value restores the
+// default of sweeping up once and holding the maximum.
+func (g *FrequencySweepGenerator) SetSweepOptions(options *SweepOptions) error {
+	if options != nil && options.Loops < 0 {
+		return fmt.Errorf("sweep loops must not be negative, got %d", options.Loops)
+	}
+	g.mutex.Lock()
+	defer g.mutex.Unlock()
+	g.options = options
+	g.resetSweep()
+	return nil
+}
+
+// Done returns a channel that is closed when a sweep with a finite number
+// of loops completes. It is nil, and so never ready, without SweepOptions.
+func (g *FrequencySweepGenerator) Done() <-chan struct{} {
+	g.mutex.Lock()
+	defer g.mutex.Unlock()
+	return g.done
+}
+
+func (g *FrequencySweepGenerator) resetSweep() {
+	g.sweepPhase, g.sweepDirection = 0, 1
+	if g.options != nil && g.options.Direction == DirectionDown {
+		g.sweepPhase, g.sweepDirection = 1, -1
+	}
+	g.passes = 0
+	g.sweepDone = false
+	g.done = nil
+	if g.options != nil {
+		g.done = make(chan struct{})
+	}
+}
+
+// seekSweep moves a swept mode to fraction of its course. Under
+// SweepOptions that is one pass of a one-way sweep, which runs backwards
+// for DirectionDown and turns at its middle for DirectionUpDown.
+func (g *FrequencySweepGenerator) seekSweep(fraction float64) {
+	oneWay := false
+	switch g.sweepMode {
+	case SweepModeLinear, SweepModeExponential, SweepModeLogarithmic, SweepModeOctaves:
+		oneWay = g.options != nil
+	}
+	if !oneWay {
+		g.sweepPhase = fraction
+		return
+	}
+
+	if g.sweepDone {
+		g.resetSweep()
+	}
+	switch {
+	case g.options.Direction == DirectionDown:
+		g.sweepPhase, g.sweepDirection = 1-fraction, -1
+	case g.options.Direction == DirectionUpDown && fraction > 0.5:
+		g.sweepPhase, g.sweepDirection = 2-2*fraction, -1
+	case g.options.Direction == DirectionUpDown:
+		g.sweepPhase, g.sweepDirection = 2*fraction, 1
+	default:
+		g.sweepPhase, g.sweepDirection = fraction, 1
+	}
+}
+
+// advanceSweep moves a one-way sweep by one frame under SweepOptions.
+func (g *FrequencySweepGenerator) advanceSweep() {
+	if g.sweepDone {
+		return
+	}
+	g.sweepPhase += float64(g.sweepDirection) * g.sweepRate / float64(g.sampleRate)
+
+	switch {
+	case g.sweepPhase > 1 && g.options.Direction == DirectionUpDown:
+		g.sweepPhase, g.sweepDirection = 2-g.sweepPhase, -1
+	case g.sweepPhase > 1:
+		g.endPass(1)
+	case g.sweepPhase < 0:
+		g.endPass(0)
+	}
+}
+
+func (g *FrequencySweepGenerator) endPass(end float64) {
+	g.passes++
+	if g.options.Loops == 0 || g.passes < g.options.Loops {
+		passes := g.passes
+		done := g.done
+		g.resetSweep()
+		g.passes, g.done = passes, done
+		return
+	}
+
+	g.sweepPhase = end
+	g.sweepDone = true
+	if g.options.End == EndStop && g.isPlaying {
+		go func() {
+			_ = g.Stop()
+			g.mutex.Lock()
+			g.complete()
+			g.mutex.Unlock()
+		}()
+		return
+	}
+	g.complete()
+}
+
+// complete signals the end of the sweep; the mutex must be held.
+func (g *FrequencySweepGenerator) complete() {
+	if g.done == nil {
+		return
+	}
+	select {
+	case <-g.done:
+		return
+	default:
+	}
+	close(g.done)
+	if g.options == nil {
+		return
+	}
+	if callback := g.options.OnComplete; callback != nil {
+		go callback()
+	}
+}
+
+// silenced reports whether a stopped one-shot sweep should output silence,
+// which only happens when rendering offline; playing generators fade out
+// and stop instead.
+func (g *FrequencySweepGenerator) silenced() bool {
+	return g.sweepDone && g.options != nil && g.options.End == EndStop && !g.isPlaying
+}
@@ -0,0 +1,117 @@
+package fsg
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+// sweepTrace renders frames one at a time and returns the frequency of
+// each.
+func sweepTrace(gen *FrequencySweepGenerator, frames int) []float64 {
+	trace := make([]float64, frames)
+	for i := range trace {
+		gen.Render(1)
+		trace[i] = gen.currentFreq
+	}
+	return trace
+}
+
+func TestSweepDirections(t *testing.T) {
+	// 100 frames per pass at 1 kHz with a rate of 10.
+	tests := []struct {
+		name      string
+		options   SweepOptions
+		checks    map[int]float64
+		completes bool
+	}{
+		{"up once, hold", SweepOptions{Direction: DirectionUp, Loops: 1}, map[int]float64{0: 100, 50: 150, 99: 199, 150: 200, 299: 200}, true},
+		{"down once, hold", SweepOptions{Direction: DirectionDown, Loops: 1}, map[int]float64{0: 200, 50: 150, 150: 100}, true},
+		{"up-down once", SweepOptions{Direction: DirectionUpDown, Loops: 1}, map[int]float64{0: 100, 100: 199, 150: 149, 250: 100}, true},
+		{"up twice", SweepOptions{Direction: DirectionUp, Loops: 2}, map[int]float64{50: 150, 150: 150, 250: 200}, true},
+		{"up forever", SweepOptions{Direction: DirectionUp}, map[int]float64{50: 150, 250: 150, 950: 150}, false},
+	}
+	for _, tc := range tests {
+		gen := NewFrequencySweepGenerator(100, 200, 1000, 1)
+		gen.SetSweepRate(10)
+		if err := gen.SetSweepOptions(&tc.options); err != nil {
+			t.Fatalf("%s: %v", tc.name, err)
+		}
+		trace := sweepTrace(gen, 1000)
+		for frame, want := range tc.checks {
+			if math.Abs(trace[frame]-want) > 1.01 {
+				t.Errorf("%s: frequency at frame %d = %v, want %v", tc.name, frame, trace[frame], want)
+			}
+		}
+
+		select {
+		case <-gen.Done():
+			if !tc.completes {
+				t.Errorf("%s: should not complete", tc.name)
+			}
+		default:
+			if tc.completes {
+				t.Errorf("%s: should have completed", tc.name)
+			}
+		}
+	}
+}
+
+func TestOneShotRenderStops(t *testing.T) {
+	completed := make(chan struct{})
+	gen := NewFrequencySweepGenerator(100, 200, 1000, 1)
+	gen.SetSweepRate(10)
+	gen.SetSweepOptions(&SweepOptions{Loops: 1, End: EndStop, OnComplete: func() { close(completed) }})
+
+	output := gen.Render(200)
+	for i, s := range output[101:] {
+		if s != 0 {
+			t.Fatalf("Sample %d after the one-shot should be silent, got %v", 101+i, s)
+		}
+	}
+	select {
+	case <-completed:
+	case <-time.After(time.Second):
+		t.Error("OnComplete was not called")
+	}
+}
+
+func TestOneShotPlaybackStops(t *testing.T) {
+	gen := NewFrequencySweepGenerator(100, 200, 1000, 1)
+	device := NewMockDevice(1000, 1)
+	gen.SetMockDevice(device)
+	gen.SetSweepRate(10)
+	gen.SetFadeDurations(0, 10*time.Millisecond)
+	gen.SetSweepOptions(&SweepOptions{Loops: 1, End: EndStop})
+
+	if err := gen.Start(); err != nil {
+		t.Fatalf("Start failed: %v", err)
+	}
+	device.SetCallback(gen.DataCallback)
+	device.GenerateSamples(200)
+
+	select {
+	case <-gen.Done():
+	case <-time.After(time.Second):
+		t.Fatal("One-shot sweep did not complete")
+	}
+	gen.mutex.Lock()
+	playing := gen.isPlaying
+	gen.mutex.Unlock()
+	if playing {
+		t.Error("Generator should stop after a one-shot sweep")
+	}
+}
+
+func TestSetSweepOptionsInvalid(t *testing.T) {
+	gen := NewFrequencySweepGenerator(100, 200, 1000, 1)
+	if err := gen.SetSweepOptions(&SweepOptions{Loops: -1}); err == nil {
+		t.Error("Expected an error for negative loops")
+	}
+	if _, err := ParseDirection("sideways"); err == nil {
+		t.Error("Expected an error for an unknown direction")
+	}
+	if _, err := ParseEndAction("explode"); err == nil {
+		t.Error("Expected an error for an unknown end action")
+	}
+}
@@ -0,0 +1,134 @@
+package fsg
+
+import (
+	"fmt"
+	"math"
+	"time"
+)
+
+// sweepTiming records how the sweep rate was specified, so that it can be
+// recomputed when the frequency range changes.
+type sweepTiming int
+
+const (
+	timingRate sweepTiming = iota
+	timingHzPerSecond
+	timingOctavesPerSecond
+)
+
+// SetSweepDuration sets the time a one-way sweep takes from the minimum to
+// the maximum frequency.
+func (g *FrequencySweepGenerator) SetSweepDuration(d time.Duration) error {
+	if d <= 0 {
+		return fmt.Errorf("sweep duration must be positive, got %v", d)
+	}
+	g.mutex.Lock()
+	defer g.mutex.Unlock()
+	g.sweepRate = 1 / d.Seconds()
+	g.timing = timingRate
+	return nil
+}
+
+// SetHzPerSecond sets a constant sweep speed in Hz per second, exact for
+// the linear, triangle and sawtooth modes.
+func (g *FrequencySweepGenerator) SetHzPerSecond(hz float64) error {
+	if hz <= 0 {
+		return fmt.Errorf("sweep speed must be positive, got %v Hz/s", hz)
+	}
+	g.mutex.Lock()
+	defer g.mutex.Unlock()
+	if g.maxFrequency <= g.minFrequency {
+		return fmt.Errorf("sweep range %v-%v Hz is empty", g.minFrequency, g.maxFrequency)
+	}
+	g.timing, g.timingValue = timingHzPerSecond, hz
+	g.applyTiming()
+	return nil
+}
+
+// SetOctavesPerSecond sets a constant sweep speed in octaves per second,
+// exact for SweepModeOctaves.
+func (g *FrequencySweepGenerator) SetOctavesPerSecond(octaves float64) error {
+	if octaves <= 0 {
+		return fmt.Errorf("sweep speed must be positive, got %v octaves/s", octaves)
+	}
+	g.mutex.Lock()
+	defer g.mutex.Unlock()
+	if g.minFrequency <= 0 || g.maxFrequency <= g.minFrequency {
+		return fmt.Errorf("octave sweeps need 0 < min < max, got %v-%v Hz", g.minFrequency, g.maxFrequency)
+	}
+	g.timing, g.timingValue = timingOctavesPerSecond, octaves
+	g.applyTiming()
+	return nil
+}
+
+// applyTiming recomputes sweepRate from a physical sweep speed.
+func (g *FrequencySweepGenerator) applyTiming() {
+	switch g.timing {
+	case timingHzPerSecond:
+		g.sweepRate = g.timingValue / (g.maxFrequency - g.minFrequency)
+	case timingOctavesPerSecond:
+		g.sweepRate = g.timingValue / math.Log2(g.maxFrequency/g.minFrequency)
+	}
+}
+
+// stepFrequency returns the mean frequency over the next frame, so that the
+// phase advances by the exact integral of the frequency law instead of by
+// the frequency at the start of the step. The integral is analytic for
+// every swept mode; the random, note and custom modes use start, the
+// frequency at the start of the step.
+func (g *FrequencySweepGenerator) stepFrequency(start float64) float64 {
+	if g.sweepDone {
+		return start
+	}
+	direction := 1.0
+	if g.options != nil || g.sweepMode == SweepModeTriangle || g.sweepMode == SweepModeSine {
+		direction = float64(g.sweepDirection)
+	}
+	p0 := g.sweepPhase
+	p1 := math.Max(0, math.Min(1, p0+direction*g.sweepRate/float64(g.sampleRate)))
+	if p1 == p0 {
+		return start
+	}
+
+	switch g.sweepMode {
+	case SweepModeLinear, SweepModeSawtooth:
+		return g.minFrequency + (g.maxFrequency-g.minFrequency)*(p0+p1)/2
+	case SweepModeOctaves:
+		// f(p) = min * e^(k p) integrates to min * e^(k p) / k.
+		if g.minFrequency <= 0 || g.maxFrequency == g.minFrequency {
+			return start
+		}
+		k := math.Log(g.maxFrequency / g.minFrequency)
+		return g.minFrequency * (math.Exp(k*p1) - math.Exp(k*p0)) / (k * (p1 - p0))
+	case SweepModeRandom, SweepModeNotes, SweepModeCustom:
+		return start
+	}
+	mean := (shapeIntegral(g.sweepMode, p1) - shapeIntegral(g.sweepMode, p0)) / (p1 - p0)
+	return g.minFrequency + (g.maxFrequency-g.minFrequency)*mean
+}
+
+// shapeIntegral returns an antiderivative of the position t(p) between the
+// minimum and the maximum frequency that interpolateFrequency uses for
+// mode.
+func shapeIntegral(mode SweepMode, p float64) float64 {
+	switch mode {
+	case SweepModeSine:
+		// t = 1/2 + sin(pi (p - 1/2)) / 2
+		return p/2 - math.Cos(math.Pi*(p-0.5))/(2*math.Pi)
+	case SweepModeTriangle:
+		if p < 0.5 {
+			return p * p
+		}
+		return 2*p - p*p - 0.5
+	case SweepModeExponential:
+		// t = 2^p - 1
+		return math.Exp2(p)/math.Ln2 - p
+	case SweepModeLogarithmic:
+		// t = log10(9p + 1)
+		x := 9*p + 1
+		return (x*math.Log(x) - x) / (9 * math.Ln10)
+	case SweepModeSquare:
+		return math.Max(0, p-0.5)
+	}
+	return p * p / 2
+}
@@ -0,0 +1,139 @@
+package fsg
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+func TestSweepTiming(t *testing.T) {
+	gen := NewFrequencySweepGenerator(100, 1600, 48000, 1)
+	gen.SetSweepMode(SweepModeOctaves)
+	if err := gen.SetOctavesPerSecond(1); err != nil {
+		t.Fatalf("SetOctavesPerSecond failed: %v", err)
+	}
+	if gen.sweepRate != 0.25 {
+		t.Errorf("Incorrect sweep rate for 1 octave/s over 4 octaves: got %v, want 0.25", gen.sweepRate)
+	}
+	gen.Render(2 * 48000)
+	if math.Abs(gen.currentFreq-400) > 0.1 {
+		t.Errorf("Frequency after 2 s at 1 octave/s: got %v, want 400", gen.currentFreq)
+	}
+
+	if err := gen.SetHzPerSecond(300); err != nil {
+		t.Fatalf("SetHzPerSecond failed: %v", err)
+	}
+	if gen.sweepRate != 0.2 {
+		t.Errorf("Incorrect sweep rate for 300 Hz/s over 1500 Hz: got %v, want 0.2", gen.sweepRate)
+	}
+
+	if err := gen.SetSweepDuration(4 * time.Second); err != nil {
+		t.Fatalf("SetSweepDuration failed: %v", err)
+	}
+	if gen.sweepRate != 0.25 {
+		t.Errorf("Incorrect sweep rate for a 4 s sweep: got %v, want 0.25", gen.sweepRate)
+	}
+
+	if err := gen.SetSweepDuration(0); err == nil {
+		t.Error("Expected an error for a zero sweep duration")
+	}
+	if err := NewFrequencySweepGenerator(0, 1000, 48000, 1).SetOctavesPerSecond(1); err == nil {
+		t.Error("Expected an error for an octave sweep from 0 Hz")
+	}
+}
+
+func TestSweepPhaseIsExact(t *testing.T) {
+	const (
+		sampleRate = 48000
+		minFreq    = 20.0
+		maxFreq    = 20000.0
+		rate       = 0.5
+	)
+	tests := []struct {
+		mode  SweepMode
+		phase func(t float64) float64
+	}{
+		{SweepModeLinear, func(t float64) float64 {
+			return 2 * math.Pi * (minFreq*t + (maxFreq-minFreq)*rate*t*t/2)
+		}},
+		{SweepModeOctaves, func(t float64) float64 {
+			k := math.Log(maxFreq / minFreq)
+			return 2 * math.Pi * minFreq * (math.Exp(k*rate*t) - 1) / (k * rate)
+		}},
+	}
+	for _, tc := range tests {
+		for _, channels := range []uint32{1, 2} {
+			gen := NewFrequencySweepGenerator(minFreq, maxFreq, sampleRate, channels)
+			gen.SetSweepMode(tc.mode)
+			gen.SetSweepRate(rate)
+			output := gen.Render(2 * sampleRate)
+
+			// Frame n is taken after n+1 phase steps and every channel
+			// carries the same sample.
+			worst := 0.0
+			for i, s := range output {
+				n := i / int(channels)
+				want := math.Sin(tc.phase(float64(n+1) / sampleRate))
+				worst = math.Max(worst, math.Abs(float64(s)-want))
+			}
+			if worst > 1e-4 {
+				t.Errorf("Mode %v, %d channels: largest deviation from the analytic sweep is %v", tc.mode, channels, worst)
+			}
+		}
+	}
+}
+
+func TestSweepShapePhaseIsExact(t *testing.T) {
+	const (
+		sampleRate = 48000
+		minFreq    = 100.0
+		maxFreq    = 4000.0
+		rate       = 0.4
+		frames     = 2 * sampleRate
+	)
+	for _, mode := range []SweepMode{SweepModeSine, SweepModeTriangle, SweepModeExponential, SweepModeLogarithmic, SweepModeSawtooth} {
+		gen := NewFrequencySweepGenerator(minFreq, maxFreq, sampleRate, 1)
+		gen.SetSweepMode(mode)
+		gen.SetSweepRate(rate)
+		output := gen.Render(frames)
+
+		// Integrate the frequency law with Simpson's rule; the triangle's
+		// corner falls on a frame boundary.
+		law := NewFrequencySweepGenerator(minFreq, maxFreq, sampleRate, 1)
+		law.SetSweepMode(mode)
+		frequency := func(seconds float64) float64 {
+			law.sweepPhase = rate * seconds
+			return law.interpolateFrequency()
+		}
+		cycles, worst := 0.0, 0.0
+		for n := 0; n < frames; n++ {
+			t0, t1 := float64(n)/sampleRate, float64(n+1)/sampleRate
+			cycles += (frequency(t0) + 4*frequency((t0+t1)/2) + frequency(t1)) / 6 / sampleRate
+			want := math.Sin(2 * math.Pi * cycles)
+			worst = math.Max(worst, math.Abs(float64(output[n])-want))
+		}
+		if worst > 1e-4 {
+			t.Errorf("Mode %v: largest deviation from the integrated sweep is %v", mode, worst)
+		}
+	}
+}
+
+func TestStereoFrequency(t *testing.T) {
+	const sampleRate = 48000
+	gen := NewFrequencySweepGenerator(440, 440, sampleRate, 2)
+	output := gen.Render(sampleRate)
+
+	// Count the rising zero crossings of the left channel over one second.
+	crossings := 0
+	for n := 1; n < sampleRate; n++ {
+		if output[2*(n-1)] < 0 && output[2*n] >= 0 {
+			crossings++
+		}
+		if output[2*n] != output[2*n+1] {
+			t.Fatalf("Channels differ at frame %d: %v and %v", n, output[2*n], output[2*n+1])
+		}
+	}
+	if crossings < 439 || crossings > 441 {
+		t.Errorf("Incorrect stereo frequency: got %d cycles per second, want 440", crossings)
+	}
+}
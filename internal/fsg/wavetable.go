@@ -0,0 +1,133 @@
+package fsg
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/hailam/malgoplay/internal/audiofile"
+)
+
+// Waveform is a periodic carrier shape. Sample returns its value at phase,
+// in cycles from 0 to 1, when it advances by increment cycles per sample,
+// so that harmonics above the Nyquist frequency can be left out.
+type Waveform interface {
+	Sample(phase, increment float64) float64
+}
+
+// wavetableSize is the length of every mipmap level, enough for 1023
+// harmonics.
+const wavetableSize = 2048
+
+// Wavetable plays a single-cycle waveform from band-limited tables. Each
+// mipmap level halves the number of harmonics of the one before, and the
+// level used is the richest one whose top harmonic stays below Nyquist.
+type Wavetable struct {
+	levels    [][]float64
+	harmonics []int
+}
+
+// NewWavetable builds a wavetable from one cycle of a waveform. The DC
+// offset is removed and the result normalised to a peak of 1.
+func NewWavetable(cycle []float64) (*Wavetable, error) {
+	n := len(cycle)
+	if n < 4 {
+		return nil, fmt.Errorf("wavetable cycle needs at least 4 samples, got %d", n)
+	}
+
+	top := min(n/2-1, wavetableSize/2-1)
+	re := make([]float64, top+1)
+	im := make([]float64, top+1)
+	for k := 1; k <= top; k++ {
+		for j, v := range cycle {
+			angle := 2 * math.Pi * float64(k*j%n) / float64(n)
+			re[k] += v * math.Cos(angle)
+			im[k] += v * math.Sin(angle)
+		}
+	}
+
+	sine := make([]float64, wavetableSize)
+	for j := range sine {
+		sine[j] = math.Sin(2 * math.Pi * float64(j) / wavetableSize)
+	}
+
+	w := &Wavetable{}
+	for harmonics := top; ; harmonics /= 2 {
+		table := make([]float64, wavetableSize+1)
+		for k := 1; k <= harmonics; k++ {
+			a, b := 2*re[k]/float64(n), 2*im[k]/float64(n)
+			for j := 0; j < wavetableSize; j++ {
+				index := k * j % wavetableSize
+				table[j] += a*sine[(index+wavetableSize/4)%wavetableSize] + b*sine[index]
+			}
+		}
+		table[wavetableSize] = table[0]
+		w.levels = append(w.levels, table)
+		w.harmonics = append(w.harmonics, harmonics)
+		if harmonics <= 1 {
+			break
+		}
+	}
+
+	peak := 0.0
+	for _, v := range w.levels[0] {
+		peak = math.Max(peak, math.Abs(v))
+	}
+	if peak == 0 {
+		return nil, fmt.Errorf("wavetable cycle is silent")
+	}
+	for _, table := range w.levels {
+		for j := range table {
+			table[j] /= peak
+		}
+	}
+	return w, nil
+}
+
+// LoadWavetable reads a single-cycle waveform from the first channel of a
+// WAV or AIFF file.
+func LoadWavetable(path string) (*Wavetable, error) {
+	buf, err := audiofile.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	if buf.Channels() == 0 {
+		return nil, fmt.Errorf("%s has no audio", path)
+	}
+	return NewWavetable(buf.Data[0])
+}
+
+func (w *Wavetable) Sample(phase, increment float64) float64 {
+	level := len(w.levels) - 1
+	if increment > 0 {
+		nyquist := 0.5 / increment
+		for i, harmonics := range w.harmonics {
+			if float64(harmonics) < nyquist {
+				level = i
+				break
+			}
+		}
+	}
+
+	table := w.levels[level]
+	x := (phase - math.Floor(phase)) * wavetableSize
+	j := int(x)
+	frac := x - float64(j)
+	return table[j] + (table[j+1]-table[j])*frac
+}
+
+// SetWaveform replaces the sine carrier with waveform; nil restores the
+// sine.
+func (g *FrequencySweepGenerator) SetWaveform(waveform Waveform) {
+	g.mutex.Lock()
+	defer g.mutex.Unlock()
+	g.waveform = waveform
+}
+
+// carrier returns the carrier at the current phase, advancing by step Hz.
+func (g *FrequencySweepGenerator) carrier(step float64) float64 {
+	if g.waveform == nil {
+		return math.Sin(g.phase)
+	}
+	increment := step / float64(g.sampleRate)
+	return g.waveform.Sample(g.phase/(2*math.Pi), increment)
+}
@@ -0,0 +1,109 @@
+package fsg
+
+import (
+	"math"
+	"testing"
+)
+
+func TestWavetableSine(t *testing.T) {
+	cycle := make([]float64, 600)
+	for i := range cycle {
+		cycle[i] = 0.5*math.Sin(2*math.Pi*float64(i)/600) + 0.25
+	}
+	table, err := NewWavetable(cycle)
+	if err != nil {
+		t.Fatalf("NewWavetable failed: %v", err)
+	}
+
+	for _, phase := range []float64{0, 0.1, 0.25, 0.3333, 0.75, 0.9, 1.2} {
+		want := math.Sin(2 * math.Pi * phase)
+		if got := table.Sample(phase, 0.001); math.Abs(got-want) > 1e-4 {
+			t.Errorf("Incorrect sample at %v: got %v, want %v", phase, got, want)
+		}
+	}
+}
+
+func TestWavetableBandLimiting(t *testing.T) {
+	cycle := make([]float64, 256)
+	for i := range cycle {
+		cycle[i] = 1
+		if i >= 128 {
+			cycle[i] = -1
+		}
+	}
+	table, err := NewWavetable(cycle)
+	if err != nil {
+		t.Fatalf("NewWavetable failed: %v", err)
+	}
+	if table.harmonics[0] != 127 || table.harmonics[len(table.harmonics)-1] != 1 {
+		t.Errorf("Incorrect mipmap harmonics: %v", table.harmonics)
+	}
+
+	// The shape across the cycle must be that of a square with only the
+	// harmonics that fit below Nyquist.
+	tests := map[float64]int{0.0001: 127, 0.01: 31, 0.1: 3, 0.4: 1}
+	for increment, harmonics := range tests {
+		square := func(phase float64) float64 {
+			v := 0.0
+			for k := 1; k <= harmonics; k += 2 {
+				v += math.Sin(2*math.Pi*float64(k)*phase) / float64(k)
+			}
+			return v
+		}
+		want := square(0.25) / square(0.125)
+		got := table.Sample(0.25, increment) / table.Sample(0.125, increment)
+		if math.Abs(got-want) > 0.02*want {
+			t.Errorf("Increment %v: got a level ratio of %v, want %v for %d harmonics", increment, got, want, harmonics)
+		}
+	}
+}
+
+func TestWavetableErrors(t *testing.T) {
+	if _, err := NewWavetable([]float64{1, -1}); err == nil {
+		t.Error("Expected an error for a two-sample cycle")
+	}
+	if _, err := NewWavetable(make([]float64, 64)); err == nil {
+		t.Error("Expected an error for a silent cycle")
+	}
+}
+
+func TestGeneratorWaveform(t *testing.T) {
+	cycle := make([]float64, 512)
+	for i := range cycle {
+		cycle[i] = math.Sin(2 * math.Pi * float64(i) / 512)
+	}
+	table, _ := NewWavetable(cycle)
+
+	sine := NewFrequencySweepGenerator(200, 2000, 44100, 1)
+	wave := NewFrequencySweepGenerator(200, 2000, 44100, 1)
+	wave.SetWaveform(table)
+	want, got := sine.Render(4410), wave.Render(4410)
+	for i := range want {
+		if math.Abs(float64(got[i]-want[i])) > 1e-3 {
+			t.Fatalf("Sine wavetable differs from the sine carrier at %d: got %v, want %v", i, got[i], want[i])
+		}
+	}
+}
+
+func TestGeneratorWaveformStereo(t *testing.T) {
+	cycle := make([]float64, 256)
+	for i := range cycle {
+		cycle[i] = 1
+		if i >= 128 {
+			cycle[i] = -1
+		}
+	}
+	table, _ := NewWavetable(cycle)
+
+	// At 6 kHz the third harmonic fits below Nyquist in either format.
+	mono := NewFrequencySweepGenerator(6000, 6000, 48000, 1)
+	stereo := NewFrequencySweepGenerator(6000, 6000, 48000, 2)
+	mono.SetWaveform(table)
+	stereo.SetWaveform(table)
+	want, got := mono.Render(480), stereo.Render(480)
+	for n := range want {
+		if got[2*n] != want[n] || got[2*n+1] != want[n] {
+			t.Fatalf("Stereo frame %d differs from mono: got %v and %v, want %v", n, got[2*n], got[2*n+1], want[n])
+		}
+	}
+}
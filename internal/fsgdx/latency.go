@@ -0,0 +1,375 @@
+package fsgdx
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"sync"
+	"time"
+
+	"github.com/gen2brain/malgo"
+	"github.com/hailam/malgoplay/internal/fsg"
+	"gonum.org/v1/gonum/dsp/fourier"
+)
+
+type LatencyStimulus int
+
+const (
+	LatencyStimulusChirp LatencyStimulus = iota
+	LatencyStimulusMLS
+)
+
+// LatencyConfig describes the burst train played by a LatencyMeter.
+// Interval is the time between burst starts and bounds the largest
+// latency that can be measured (Interval - BurstDuration). MLS bursts are
+// one period of the sequence of order MLSOrder and ignore BurstDuration.
+type LatencyConfig struct {
+	SampleRate    uint32
+	Channels      uint32
+	Stimulus      LatencyStimulus
+	Repetitions   int
+	BurstDuration time.Duration
+	Interval      time.Duration
+	Amplitude     float64
+	StartFreq     float64
+	EndFreq       float64
+	MLSOrder      int
+	MinConfidence float64
+}
+
+func DefaultLatencyConfig() LatencyConfig {
+	return LatencyConfig{
+		SampleRate:    uint32(SampleRate),
+		Channels:      Channels,
+		Stimulus:      LatencyStimulusChirp,
+		Repetitions:   5,
+		BurstDuration: 50 * time.Millisecond,
+		Interval:      500 * time.Millisecond,
+		Amplitude:     0.5,
+		StartFreq:     200,
+		EndFreq:       8000,
+		MLSOrder:      12,
+		MinConfidence: 0.3,
+	}
+}
+
+type LatencyMeasurement struct {
+	Repetition int
+	LagFrames  float64
+	LatencyMs  float64
+	Confidence float64
+}
+
+// LatencyResult summarises the repetitions whose correlation peak reached
+// MinConfidence; Measurements holds every repetition, accepted or not.
+type LatencyResult struct {
+	Measurements []LatencyMeasurement
+	Accepted     int
+	MeanMs       float64
+	StdDevMs     float64
+	MinMs        float64
+	MaxMs        float64
+	Confidence   float64
+}
+
+type LatencyMeter struct {
+	config         LatencyConfig
+	stimulus       []float64
+	intervalFrames int
+	totalFrames    int
+	mutex          sync.Mutex
+	framesPlayed   int
+	captured       []float32
+	done           chan struct{}
+}
+
+func NewLatencyMeter(config LatencyConfig) (*LatencyMeter, error) {
+	if config.SampleRate == 0 || config.Channels == 0 {
+		return nil, errors.New("latency: sample rate and channels must be positive")
+	}
+	if config.Repetitions <= 0 {
+		return nil, errors.New("latency: repetitions must be positive")
+	}
+
+	var stimulus []float64
+	switch config.Stimulus {
+	case LatencyStimulusChirp:
+		if config.BurstDuration <= 0 {
+			return nil, errors.New("latency: burst duration must be positive")
+		}
+		stimulus = GenerateChirp(config.StartFreq, config.EndFreq, config.BurstDuration, float64(config.SampleRate))
+	case LatencyStimulusMLS:
+		var err error
+		stimulus, err = fsg.GenerateMLS(config.MLSOrder)
+		if err != nil {
+			return nil, fmt.Errorf("latency: %w", err)
+		}
+	default:
+		return nil, fmt.Errorf("latency: unknown stimulus %d", config.Stimulus)
+	}
+	for i := range stimulus {
+		stimulus[i] *= config.Amplitude
+	}
+
+	intervalFrames := int(config.Interval.Seconds() * float64(config.SampleRate))
+	if intervalFrames <= len(stimulus) {
+		return nil, errors.New("latency: interval must be longer than the burst")
+	}
+	return &LatencyMeter{
+		config:         config,
+		stimulus:       stimulus,
+		intervalFrames: intervalFrames,
+		totalFrames:    intervalFrames * config.Repetitions,
+		captured:       make([]float32, 0, intervalFrames*config.Repetitions),
+		done:           make(chan struct{}),
+	}, nil
+}
+
+// GenerateChirp returns a Hann-windowed exponential sine sweep.
+func GenerateChirp(startFreq, endFreq float64, duration time.Duration, sampleRate float64) []float64 {
+	n := int(duration.Seconds() * sampleRate)
+	chirp := make([]float64, n)
+	if n == 0 {
+		return chirp
+	}
+	window := HannWindow(n)
+	t1 := duration.Seconds()
+	k := math.Log(endFreq / startFreq)
+	for i := range chirp {
+		t := float64(i) / sampleRate
+		phase := 2 * math.Pi * startFreq * t1 / k * (math.Exp(t/t1*k) - 1)
+		chirp[i] = math.Sin(phase) * window[i]
+	}
+	return chirp
+}
+
+// DataCallback plays the burst train on every output channel and records
+// the first input channel until all repetitions have been captured.
+func (m *LatencyMeter) DataCallback(pOutputSample, pInputSamples []byte, framecount uint32) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	channels := int(m.config.Channels)
+	for frame := 0; frame < int(framecount); frame++ {
+		position := m.framesPlayed + frame
+		sample := float32(0)
+		if position < m.totalFrames {
+			offset := position % m.intervalFrames
+			if offset < len(m.stimulus) {
+				sample = float32(m.stimulus[offset])
+			}
+		}
+		for c := 0; c < channels; c++ {
+			i := (frame*channels + c) * 4
+			copy(pOutputSample[i:i+4], Float32ToBytes(sample))
+		}
+
+		if len(m.captured) < m.totalFrames && len(pInputSamples) >= (frame*channels+1)*4 {
+			m.captured = append(m.captured, BytesToFloat32(pInputSamples[frame*channels*4:]))
+		}
+	}
+	m.framesPlayed += int(framecount)
+
+	if len(m.captured) >= m.totalFrames {
+		select {
+		case <-m.done:
+		default:
+			close(m.done)
+		}
+	}
+}
+
+// Done is closed once the input of every repetition has been captured.
+func (m *LatencyMeter) Done() <-chan struct{} {
+	return m.done
+}
+
+func (m *LatencyMeter) Analyze() (LatencyResult, error) {
+	m.mutex.Lock()
+	captured := make([]float64, len(m.captured))
+	for i, v := range m.captured {
+		captured[i] = float64(v)
+	}
+	m.mutex.Unlock()
+
+	var result LatencyResult
+	var weights float64
+	for rep := 0; rep < m.config.Repetitions; rep++ {
+		start := rep * m.intervalFrames
+		end := start + m.intervalFrames
+		if end > len(captured) {
+			break
+		}
+
+		lag, confidence := correlationPeak(captured[start:end], m.stimulus)
+		measurement := LatencyMeasurement{
+			Repetition: rep,
+			LagFrames:  lag,
+			LatencyMs:  lag * 1000 / float64(m.config.SampleRate),
+			Confidence: confidence,
+		}
+		result.Measurements = append(result.Measurements, measurement)
+
+		if confidence < m.config.MinConfidence {
+			continue
+		}
+		if result.Accepted == 0 || measurement.LatencyMs < result.MinMs {
+			result.MinMs = measurement.LatencyMs
+		}
+		if result.Accepted == 0 || measurement.LatencyMs > result.MaxMs {
+			result.MaxMs = measurement.LatencyMs
+		}
+		result.Accepted++
+		result.MeanMs += measurement.LatencyMs
+		result.Confidence += confidence
+		weights++
+	}
+
+	if result.Accepted == 0 {
+		return result, errors.New("latency: no repetition produced a confident correlation peak")
+	}
+
+	result.MeanMs /= weights
+	result.Confidence /= weights
+	for _, measurement := range result.Measurements {
+		if measurement.Confidence < m.config.MinConfidence {
+			continue
+		}
+		d := measurement.LatencyMs - result.MeanMs
+		result.StdDevMs += d * d
+	}
+	result.StdDevMs = math.Sqrt(result.StdDevMs / weights)
+	return result, nil
+}
+
+// correlationPeak locates the stimulus inside the captured segment and
+// returns the lag in (sub-sample) frames together with the normalised
+// correlation coefficient at that lag.
+func correlationPeak(segment, stimulus []float64) (float64, float64) {
+	corr := CrossCorrelate(segment, stimulus)
+	maxLag := len(segment) - len(stimulus)
+	if maxLag < 0 {
+		return 0, 0
+	}
+
+	peak := 0
+	for lag := 1; lag <= maxLag; lag++ {
+		if math.Abs(corr[lag]) > math.Abs(corr[peak]) {
+			peak = lag
+		}
+	}
+
+	stimulusEnergy := 0.0
+	for _, v := range stimulus {
+		stimulusEnergy += v * v
+	}
+	segmentEnergy := 0.0
+	for _, v := range segment[peak : peak+len(stimulus)] {
+		segmentEnergy += v * v
+	}
+	confidence := 0.0
+	if stimulusEnergy > 0 && segmentEnergy > 0 {
+		confidence = math.Abs(corr[peak]) / math.Sqrt(stimulusEnergy*segmentEnergy)
+	}
+
+	lag := float64(peak)
+	if peak > 0 && peak < maxLag {
+		magnitude := []float64{math.Abs(corr[peak-1]), math.Abs(corr[peak]), math.Abs(corr[peak+1])}
+		if denom := magnitude[0] - 2*magnitude[1] + magnitude[2]; denom != 0 {
+			x, _ := ParabolicInterpolation(magnitude, 1)
+			lag += x - 1
+		}
+	}
+	return lag, confidence
+}
+
+// CrossCorrelate returns corr[lag] = sum(signal[lag+i] * reference[i]) for
+// every lag in [0, len(signal)), computed with the gonum FFT.
+func CrossCorrelate(signal, reference []float64) []float64 {
+	n := 1
+	for n < len(signal)+len(reference) {
+		n <<= 1
+	}
+
+	a := make([]float64, n)
+	copy(a, signal)
+	b := make([]float64, n)
+	copy(b, reference)
+
+	fft := fourier.NewFFT(n)
+	sa := fft.Coefficients(nil, a)
+	sb := fft.Coefficients(nil, b)
+	for i := range sa {
+		sa[i] *= complex(real(sb[i]), -imag(sb[i]))
+	}
+	full := fft.Sequence(nil, sa)
+
+	corr := make([]float64, len(signal))
+	for i := range corr {
+		corr[i] = full[i] / float64(n)
+	}
+	return corr
+}
+
+// InitDuplexDevice opens a duplex F32 device like InitDevice, but with an
+// explicit format and data callback. The caller owns the returned context.
+func InitDuplexDevice(sampleRate, channels uint32, callback malgo.DataProc) (*malgo.AllocatedContext, *malgo.Device, error) {
+	ctx, err := malgo.InitContext(nil, malgo.ContextConfig{}, nil)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	deviceConfig := malgo.DefaultDeviceConfig(malgo.Duplex)
+	deviceConfig.Capture.Format = malgo.FormatF32
+	deviceConfig.Capture.Channels = channels
+	deviceConfig.Playback.Format = malgo.FormatF32
+	deviceConfig.Playback.Channels = channels
+	deviceConfig.SampleRate = sampleRate
+	deviceConfig.Alsa.NoMMap = 1
+
+	device, err := malgo.InitDevice(ctx.Context, deviceConfig, malgo.DeviceCallbacks{
+		Data: callback,
+	})
+	if err != nil {
+		_ = ctx.Uninit()
+		ctx.Free()
+		return nil, nil, err
+	}
+
+	return ctx, device, nil
+}
+
+// MeasureLatency runs a LatencyMeter on the default duplex device.
+func MeasureLatency(config LatencyConfig) (LatencyResult, error) {
+	meter, err := NewLatencyMeter(config)
+	if err != nil {
+		return LatencyResult{}, err
+	}
+
+	ctx, device, err := InitDuplexDevice(config.SampleRate, config.Channels, meter.DataCallback)
+	if err != nil {
+		return LatencyResult{}, err
+	}
+	defer func() {
+		device.Uninit()
+		_ = ctx.Uninit()
+		ctx.Free()
+	}()
+
+	if err := device.Start(); err != nil {
+		return LatencyResult{}, err
+	}
+
+	timeout := time.Duration(config.Repetitions+1)*config.Interval + time.Second
+	select {
+	case <-meter.Done():
+	case <-time.After(timeout):
+		_ = device.Stop()
+		return LatencyResult{}, errors.New("latency: timed out waiting for captured input")
+	}
+
+	if err := device.Stop(); err != nil {
+		return LatencyResult{}, err
+	}
+	return meter.Analyze()
+}
@@ -0,0 +1,128 @@
+package fsgdx
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+func runLatencyMeter(t *testing.T, config LatencyConfig, device *MockLoopbackDevice) LatencyResult {
+	t.Helper()
+
+	meter, err := NewLatencyMeter(config)
+	if err != nil {
+		t.Fatalf("Failed to create latency meter: %v", err)
+	}
+	device.SetCallback(meter.DataCallback)
+	if err := device.Start(); err != nil {
+		t.Fatalf("Failed to start loopback device: %v", err)
+	}
+
+	for i := 0; i < 1000; i++ {
+		select {
+		case <-meter.Done():
+			result, err := meter.Analyze()
+			if err != nil {
+				t.Fatalf("Analyze failed: %v", err)
+			}
+			return result
+		default:
+			device.GenerateSamples(512)
+		}
+	}
+	t.Fatal("Latency meter never finished capturing")
+	return LatencyResult{}
+}
+
+func TestLatencyMeterLoopback(t *testing.T) {
+	config := DefaultLatencyConfig()
+	config.SampleRate = 48000
+	config.Channels = 2
+	config.Repetitions = 4
+
+	for _, delayFrames := range []int{512, 777, 4800, 9600} {
+		device := NewMockLoopbackDevice(config.Channels, delayFrames)
+		result := runLatencyMeter(t, config, device)
+
+		want := float64(delayFrames) * 1000 / float64(config.SampleRate)
+		if math.Abs(result.MeanMs-want) > 0.05 {
+			t.Errorf("Delay %d frames: got %.3f ms, want %.3f ms", delayFrames, result.MeanMs, want)
+		}
+		if result.Accepted != config.Repetitions {
+			t.Errorf("Delay %d frames: accepted %d of %d repetitions", delayFrames, result.Accepted, config.Repetitions)
+		}
+		if result.Confidence < 0.99 {
+			t.Errorf("Delay %d frames: confidence too low: %v", delayFrames, result.Confidence)
+		}
+	}
+}
+
+func TestLatencyMeterMLS(t *testing.T) {
+	config := DefaultLatencyConfig()
+	config.SampleRate = 48000
+	config.Stimulus = LatencyStimulusMLS
+	config.MLSOrder = 10
+	config.Repetitions = 3
+
+	device := NewMockLoopbackDevice(config.Channels, 2000)
+	device.SetLoopGain(0.5, 0.02)
+	result := runLatencyMeter(t, config, device)
+
+	want := 2000 * 1000 / float64(config.SampleRate)
+	if math.Abs(result.MeanMs-want) > 0.05 {
+		t.Errorf("Incorrect latency: got %.3f ms, want %.3f ms", result.MeanMs, want)
+	}
+}
+
+func TestLatencyMeterNoisyLoopback(t *testing.T) {
+	config := DefaultLatencyConfig()
+	config.SampleRate = 48000
+	config.Channels = 1
+
+	device := NewMockLoopbackDevice(config.Channels, 1200)
+	device.SetLoopGain(0.1, 0.05)
+	result := runLatencyMeter(t, config, device)
+
+	if math.Abs(result.MeanMs-25) > 0.1 {
+		t.Errorf("Incorrect latency: got %.3f ms, want 25 ms", result.MeanMs)
+	}
+	if result.Confidence >= 0.99 || result.Confidence < config.MinConfidence {
+		t.Errorf("Confidence should reflect the added noise: got %v", result.Confidence)
+	}
+}
+
+func TestLatencyMeterSilentInput(t *testing.T) {
+	config := DefaultLatencyConfig()
+	config.Repetitions = 2
+	config.Interval = 200 * time.Millisecond
+
+	meter, err := NewLatencyMeter(config)
+	if err != nil {
+		t.Fatalf("Failed to create latency meter: %v", err)
+	}
+	device := NewMockLoopbackDevice(config.Channels, 100)
+	device.SetLoopGain(0, 0)
+	device.SetCallback(meter.DataCallback)
+	_ = device.Start()
+	for i := 0; i < 100; i++ {
+		device.GenerateSamples(1024)
+	}
+
+	if _, err := meter.Analyze(); err == nil {
+		t.Error("Analyze should fail when nothing was looped back")
+	}
+}
+
+func TestNewLatencyMeterValidation(t *testing.T) {
+	config := DefaultLatencyConfig()
+	config.Interval = config.BurstDuration
+	if _, err := NewLatencyMeter(config); err == nil {
+		t.Error("Expected an error when the interval does not exceed the burst")
+	}
+
+	config = DefaultLatencyConfig()
+	config.Repetitions = 0
+	if _, err := NewLatencyMeter(config); err == nil {
+		t.Error("Expected an error for zero repetitions")
+	}
+}
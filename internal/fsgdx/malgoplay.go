@@ -3,11 +3,12 @@ package fsgdx
 import (
 	"fmt"
 	"math"
-	"sort"
 	"sync"
 	"unsafe"
 
 	"github.com/gen2brain/malgo"
+	"github.com/hailam/malgoplay/internal/calibration"
+	"github.com/hailam/malgoplay/internal/pitch"
 	"gonum.org/v1/gonum/dsp/fourier"
 )
 
@@ -19,25 +20,33 @@ var (
 	MaxFrequency    float64
 	Amplitude       = 0.5
 	Phase           float64
-	DetectedFreqs   = make([]float64, 0, 10)
 	DetectedFreqsMu sync.Mutex
-	SweepDirection  = 1   // 1 for increasing, -1 for decreasing
-	SweepRate       = 1.0 // Hz per second
-	FrequencyMu     sync.RWMutex
-	VolumeMu        sync.RWMutex
-	Volume          = 1.0
-	Calibration     = map[float64]float64{
-		20:    1.2,
-		100:   1.0,
-		300:   1.0,
-		1000:  1.1,
-		5000:  1.0,
-		10000: 1.0,
-		15000: 1.0,
-		20000: 1.0,
-	}
+	// Smoothing filters detections and Match decides their status; both
+	// are guarded by DetectedFreqsMu. The defaults are a 10-sample moving
+	// average and a 5% tolerance with DefaultHysteresis.
+	Smoothing      Smoother = NewMovingAverage(10)
+	Match                   = NewMatcher(RelativeTolerance(5), DefaultHysteresis)
+	SweepDirection          = 1   // 1 for increasing, -1 for decreasing
+	SweepRate               = 1.0 // Hz per second
+	FrequencyMu    sync.RWMutex
+	VolumeMu       sync.RWMutex
+	Volume         = 1.0
+	// OutputCalibration, when set, pre-emphasises the played tone with the
+	// inverse of the speaker response, normalised over the sweep range.
+	OutputCalibration *calibration.Profile
+	// InputCalibration, when set, removes the microphone response from the
+	// captured level of every analysed buffer.
+	InputCalibration *calibration.Profile
+	// Detector estimates the captured frequency in PlaybackAndAnalyzeCallback.
+	Detector FrequencyDetector = DetectFrequency
+	// Recorder, when set, logs every analysed buffer of the duplex run.
+	Recorder *VerificationRecorder
+	// Quiet suppresses the live status line.
+	Quiet bool
 )
 
+type FrequencyDetector func(inData []float32) float64
+
 /*
  - this was the original go code ported from the python poc
  - this code used duplex mode for audio input and output since detecting frequency was needed
@@ -47,29 +56,6 @@ var (
  since real tests shows it matches the expected frequency unlike in python code which came before this
 */
 
-func interpolateCalibration(freq float64) float64 {
-	keys := make([]float64, 0, len(Calibration))
-	for k := range Calibration {
-		keys = append(keys, k)
-	}
-	sort.Float64s(keys)
-
-	if freq <= keys[0] {
-		return Calibration[keys[0]]
-	}
-	if freq >= keys[len(keys)-1] {
-		return Calibration[keys[len(keys)-1]]
-	}
-
-	for i := 0; i < len(keys)-1; i++ {
-		if keys[i] <= freq && freq < keys[i+1] {
-			t := (freq - keys[i]) / (keys[i+1] - keys[i])
-			return Calibration[keys[i]]*(1-t) + Calibration[keys[i+1]]*t
-		}
-	}
-	return 1.0
-}
-
 func GenerateSineWave(frames uint32) []float32 {
 	data := make([]float32, frames)
 	FrequencyMu.RLock()
@@ -78,6 +64,9 @@ func GenerateSineWave(frames uint32) []float32 {
 	VolumeMu.RLock()
 	currentVolume := Volume
 	VolumeMu.RUnlock()
+	if OutputCalibration != nil {
+		currentVolume *= OutputCalibration.PreEmphasis(currentFrequency) / OutputCalibration.MaxPreEmphasis(MinFrequency, MaxFrequency)
+	}
 	for i := range data {
 		t := float64(i) / SampleRate
 		data[i] = float32(Amplitude * currentVolume * math.Sin(2*math.Pi*currentFrequency*t+Phase))
@@ -112,6 +101,12 @@ func ParabolicInterpolation(f []float64, x int) (float64, float64) {
 }
 
 func DetectFrequency(inData []float32) float64 {
+	return DetectFrequencyAt(inData, SampleRate)
+}
+
+// DetectFrequencyAt is DetectFrequency for buffers recorded at a sample
+// rate other than the duplex device's, such as decoded files.
+func DetectFrequencyAt(inData []float32, sampleRate float64) float64 {
 	data := make([]float64, len(inData))
 	for i, v := range inData {
 		data[i] = float64(v)
@@ -150,7 +145,7 @@ func DetectFrequency(inData []float32) float64 {
 		}
 
 		trueI, _ := ParabolicInterpolation(magnitude, maxPeak)
-		peakFrequency := trueI * SampleRate / float64(paddedLength)
+		peakFrequency := trueI * sampleRate / float64(paddedLength)
 
 		return peakFrequency
 	}
@@ -158,6 +153,46 @@ func DetectFrequency(inData []float32) float64 {
 	return 0
 }
 
+// NewPitchDetector returns a FrequencyDetector backed by a streaming pitch
+// tracker, for low or harmonic-rich tones that DetectFrequency misjudges.
+// Between completed windows it keeps returning the last estimate, and
+// estimates below minConfidence are reported as 0.
+func NewPitchDetector(method pitch.Method, minConfidence float64) (FrequencyDetector, error) {
+	config := pitch.DefaultConfig(SampleRate)
+	config.Method = method
+	if method == pitch.MethodMcLeod {
+		config.Threshold = 0.9
+	}
+	tracker, err := pitch.NewTracker(config)
+	if err != nil {
+		return nil, err
+	}
+
+	return func(inData []float32) float64 {
+		tracker.Write(inData)
+		estimate := tracker.Last()
+		if estimate.Confidence < minConfidence {
+			return 0
+		}
+		return estimate.Frequency
+	}, nil
+}
+
+// SilenceLevel is the Level of a silent buffer, which has no level in dB.
+const SilenceLevel = -200.0
+
+// Level returns the RMS level of samples in dB relative to full scale.
+func Level(samples []float32) float64 {
+	sum := 0.0
+	for _, v := range samples {
+		sum += float64(v) * float64(v)
+	}
+	if sum == 0 {
+		return SilenceLevel
+	}
+	return 10 * math.Log10(sum/float64(len(samples)))
+}
+
 func Float32ToBytes(f float32) []byte {
 	var buf [4]byte
 	*(*float32)(unsafe.Pointer(&buf[0])) = f
@@ -180,36 +215,32 @@ func PlaybackAndAnalyzeCallback(pOutputSample, pInputSample []byte, framecount u
 		inputFloat[i] = BytesToFloat32(pInputSample[i*4 : (i+1)*4])
 	}
 
-	detectedFreq := DetectFrequency(inputFloat)
-	CalibrationFactor := interpolateCalibration(detectedFreq)
-	calibratedFreq := detectedFreq / CalibrationFactor
-
-	DetectedFreqsMu.Lock()
-	DetectedFreqs = append(DetectedFreqs, calibratedFreq)
-	if len(DetectedFreqs) > 10 {
-		DetectedFreqs = DetectedFreqs[1:]
+	detectedFreq := Detector(inputFloat)
+	level := Level(inputFloat)
+	if InputCalibration != nil && detectedFreq > 0 {
+		level = InputCalibration.CorrectLevel(detectedFreq, level)
 	}
-	avgFreq := 0.0
-	for _, f := range DetectedFreqs {
-		avgFreq += f
-	}
-	avgFreq /= float64(len(DetectedFreqs))
-	DetectedFreqsMu.Unlock()
 
 	FrequencyMu.RLock()
 	currentFrequency := Frequency
 	FrequencyMu.RUnlock()
 
-	errorMargin := 0.05 * currentFrequency
-	status := "MISMATCH"
-	if math.Abs(avgFreq-currentFrequency) <= errorMargin {
-		status = "MATCH"
+	DetectedFreqsMu.Lock()
+	avgFreq := Smoothing.Add(detectedFreq)
+	status := Match.Update(currentFrequency, avgFreq)
+	DetectedFreqsMu.Unlock()
+
+	if Recorder != nil {
+		Recorder.Record(framecount, currentFrequency, avgFreq, level, status)
 	}
 
 	VolumeMu.RLock()
 	currentVolume := Volume
 	VolumeMu.RUnlock()
 
+	if Quiet {
+		return
+	}
 	fmt.Printf("\rPlayed: %.2f Hz, Detected: %.2f Hz, Status: %s, Volume: %.2f", currentFrequency, avgFreq, status, currentVolume)
 }
 
@@ -227,6 +258,18 @@ func UpdateFrequency() {
 	}
 }
 
+func SetTolerance(tolerance Tolerance, hysteresis float64) {
+	DetectedFreqsMu.Lock()
+	defer DetectedFreqsMu.Unlock()
+	Match = NewMatcher(tolerance, hysteresis)
+}
+
+func SetSmoothing(smoother Smoother) {
+	DetectedFreqsMu.Lock()
+	defer DetectedFreqsMu.Unlock()
+	Smoothing = smoother
+}
+
 func SetFrequency(freq float64) {
 	FrequencyMu.Lock()
 	defer FrequencyMu.Unlock()
@@ -0,0 +1,101 @@
+package fsgdx
+
+import (
+	"math/rand"
+	"sync"
+
+	"github.com/gen2brain/malgo"
+)
+
+// MockLoopbackDevice feeds everything written to the output back into the
+// input after a fixed number of frames, like a cable from speaker out to
+// mic in on a device with known latency. Input can only echo output that
+// has already been produced, so delayFrames must be at least the frame
+// count passed to GenerateSamples.
+type MockLoopbackDevice struct {
+	mutex        sync.Mutex
+	isStarted    bool
+	dataCallback malgo.DataProc
+	channels     uint32
+	gain         float64
+	noise        float64
+	random       *rand.Rand
+	pending      []float32
+}
+
+func NewMockLoopbackDevice(channels uint32, delayFrames int) *MockLoopbackDevice {
+	return &MockLoopbackDevice{
+		channels: channels,
+		gain:     1,
+		random:   rand.New(rand.NewSource(1)),
+		pending:  make([]float32, delayFrames*int(channels)),
+	}
+}
+
+// SetLoopGain scales the looped-back signal and adds uniform noise of the
+// given peak amplitude to the input.
+func (m *MockLoopbackDevice) SetLoopGain(gain, noise float64) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	m.gain = gain
+	m.noise = noise
+}
+
+func (m *MockLoopbackDevice) SetCallback(dataCallback malgo.DataProc) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	m.dataCallback = dataCallback
+}
+
+func (m *MockLoopbackDevice) Start() error {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	m.isStarted = true
+	return nil
+}
+
+func (m *MockLoopbackDevice) Stop() error {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	m.isStarted = false
+	return nil
+}
+
+func (m *MockLoopbackDevice) Uninit() error {
+	return nil
+}
+
+// GenerateSamples runs one duplex callback of frameCount frames.
+func (m *MockLoopbackDevice) GenerateSamples(frameCount uint32) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	if m.dataCallback == nil || !m.isStarted {
+		return
+	}
+
+	samples := int(frameCount * m.channels)
+	input := make([]byte, samples*4)
+	for i := 0; i < samples; i++ {
+		sample := 0.0
+		if i < len(m.pending) {
+			sample = float64(m.pending[i]) * m.gain
+		}
+		if m.noise > 0 {
+			sample += m.noise * (2*m.random.Float64() - 1)
+		}
+		copy(input[i*4:(i+1)*4], Float32ToBytes(float32(sample)))
+	}
+	if samples < len(m.pending) {
+		m.pending = m.pending[samples:]
+	} else {
+		m.pending = m.pending[:0]
+	}
+
+	output := make([]byte, samples*4)
+	m.dataCallback(output, input, frameCount)
+
+	for i := 0; i < samples; i++ {
+		m.pending = append(m.pending, BytesToFloat32(output[i*4:(i+1)*4]))
+	}
+}
@@ -0,0 +1,190 @@
+package fsgdx
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Smoother filters the stream of detected frequencies before matching.
+type Smoother interface {
+	Add(value float64) float64
+	Reset()
+}
+
+type MovingAverage struct {
+	size   int
+	values []float64
+}
+
+func NewMovingAverage(size int) *MovingAverage {
+	return &MovingAverage{size: size}
+}
+
+func (s *MovingAverage) Add(value float64) float64 {
+	s.values = append(s.values, value)
+	if len(s.values) > s.size {
+		s.values = s.values[1:]
+	}
+	sum := 0.0
+	for _, v := range s.values {
+		sum += v
+	}
+	return sum / float64(len(s.values))
+}
+
+func (s *MovingAverage) Reset() {
+	s.values = s.values[:0]
+}
+
+// Median rejects single outliers such as octave errors that a moving
+// average would smear over the whole window.
+type Median struct {
+	size   int
+	values []float64
+	sorted []float64
+}
+
+func NewMedian(size int) *Median {
+	return &Median{size: size}
+}
+
+func (s *Median) Add(value float64) float64 {
+	s.values = append(s.values, value)
+	if len(s.values) > s.size {
+		s.values = s.values[1:]
+	}
+	s.sorted = append(s.sorted[:0], s.values...)
+	sort.Float64s(s.sorted)
+
+	n := len(s.sorted)
+	if n%2 == 1 {
+		return s.sorted[n/2]
+	}
+	return (s.sorted[n/2-1] + s.sorted[n/2]) / 2
+}
+
+func (s *Median) Reset() {
+	s.values = s.values[:0]
+}
+
+// Exponential is an exponential moving average; Alpha in (0, 1] is the
+// weight of the newest value.
+type Exponential struct {
+	Alpha   float64
+	value   float64
+	started bool
+}
+
+func NewExponential(alpha float64) *Exponential {
+	return &Exponential{Alpha: alpha}
+}
+
+func (s *Exponential) Add(value float64) float64 {
+	if !s.started {
+		s.value, s.started = value, true
+		return value
+	}
+	s.value += s.Alpha * (value - s.value)
+	return s.value
+}
+
+func (s *Exponential) Reset() {
+	s.started = false
+}
+
+// Kalman is a one-dimensional Kalman filter for a slowly drifting
+// frequency. ProcessNoise is the expected variance of the true frequency
+// between updates and MeasurementNoise that of the detector, both in Hz².
+type Kalman struct {
+	ProcessNoise     float64
+	MeasurementNoise float64
+	estimate         float64
+	variance         float64
+	started          bool
+}
+
+func NewKalman(processNoise, measurementNoise float64) *Kalman {
+	return &Kalman{ProcessNoise: processNoise, MeasurementNoise: measurementNoise}
+}
+
+func (s *Kalman) Add(value float64) float64 {
+	if !s.started {
+		s.estimate, s.variance, s.started = value, s.MeasurementNoise, true
+		return value
+	}
+	s.variance += s.ProcessNoise
+	gain := s.variance / (s.variance + s.MeasurementNoise)
+	s.estimate += gain * (value - s.estimate)
+	s.variance *= 1 - gain
+	return s.estimate
+}
+
+func (s *Kalman) Reset() {
+	s.started = false
+}
+
+// NoSmoothing passes detections through unchanged.
+type NoSmoothing struct{}
+
+func (NoSmoothing) Add(value float64) float64 { return value }
+func (NoSmoothing) Reset()                    {}
+
+// ParseSmoother reads "none", "average:N", "median:N", "ema:ALPHA" or
+// "kalman:PROCESS,MEASUREMENT".
+func ParseSmoother(s string) (Smoother, error) {
+	name, args, _ := strings.Cut(strings.TrimSpace(strings.ToLower(s)), ":")
+	var params []float64
+	if args != "" {
+		for _, field := range strings.Split(args, ",") {
+			v, err := strconv.ParseFloat(strings.TrimSpace(field), 64)
+			if err != nil || v <= 0 || math.IsInf(v, 0) {
+				return nil, fmt.Errorf("invalid smoothing parameter %q in %q", field, s)
+			}
+			params = append(params, v)
+		}
+	}
+	param := func(i int, fallback float64) float64 {
+		if i < len(params) {
+			return params[i]
+		}
+		return fallback
+	}
+
+	size := func(fallback int) (int, error) {
+		n := int(param(0, float64(fallback)))
+		if n < 1 {
+			return 0, fmt.Errorf("smoothing window must be at least 1 in %q", s)
+		}
+		return n, nil
+	}
+
+	switch name {
+	case "none":
+		return NoSmoothing{}, nil
+	case "average", "mean":
+		n, err := size(10)
+		if err != nil {
+			return nil, err
+		}
+		return NewMovingAverage(n), nil
+	case "median":
+		n, err := size(5)
+		if err != nil {
+			return nil, err
+		}
+		return NewMedian(n), nil
+	case "ema", "exponential":
+		alpha := param(0, 0.3)
+		if alpha > 1 {
+			return nil, fmt.Errorf("exponential smoothing factor must be at most 1, got %v", alpha)
+		}
+		return NewExponential(alpha), nil
+	case "kalman":
+		return NewKalman(param(0, 1), param(1, 25)), nil
+	default:
+		return nil, fmt.Errorf("unknown smoothing %q", name)
+	}
+}
@@ -0,0 +1,59 @@
+package fsgdx
+
+import (
+	"math"
+	"testing"
+)
+
+func TestSmoothers(t *testing.T) {
+	input := []float64{100, 100, 500, 100, 100}
+	tests := []struct {
+		name     string
+		smoother Smoother
+		want     []float64
+	}{
+		{"average", NewMovingAverage(3), []float64{100, 100, 700.0 / 3, 700.0 / 3, 700.0 / 3}},
+		{"median", NewMedian(3), []float64{100, 100, 100, 100, 100}},
+		{"ema", NewExponential(0.5), []float64{100, 100, 300, 200, 150}},
+		{"none", NoSmoothing{}, input},
+	}
+	for _, tc := range tests {
+		for i, v := range input {
+			if got := tc.smoother.Add(v); math.Abs(got-tc.want[i]) > 1e-9 {
+				t.Errorf("%s step %d: got %v, want %v", tc.name, i, got, tc.want[i])
+			}
+		}
+		tc.smoother.Reset()
+		if got := tc.smoother.Add(42); got != 42 {
+			t.Errorf("%s after Reset: got %v, want 42", tc.name, got)
+		}
+	}
+}
+
+func TestKalmanConverges(t *testing.T) {
+	k := NewKalman(0.01, 100)
+	var got float64
+	for i := 0; i < 200; i++ {
+		noise := 10.0
+		if i%2 == 0 {
+			noise = -10
+		}
+		got = k.Add(440 + noise)
+	}
+	if math.Abs(got-440) > 2 {
+		t.Errorf("Kalman estimate: got %v, want close to 440", got)
+	}
+}
+
+func TestParseSmoother(t *testing.T) {
+	for _, input := range []string{"none", "average", "average:4", "median:7", "ema:0.2", "kalman:1,25"} {
+		if _, err := ParseSmoother(input); err != nil {
+			t.Errorf("ParseSmoother(%q) failed: %v", input, err)
+		}
+	}
+	for _, input := range []string{"boxcar", "median:0.5", "ema:2", "average:x"} {
+		if _, err := ParseSmoother(input); err == nil {
+			t.Errorf("ParseSmoother(%q) should fail", input)
+		}
+	}
+}
@@ -0,0 +1,117 @@
+package fsgdx
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// Tolerance decides how far a detected frequency may be from the played
+// one. Ratio is the deviation in units of the tolerance: at most 1 is a
+// match.
+type Tolerance interface {
+	Ratio(played, detected float64) float64
+	String() string
+}
+
+// AbsoluteTolerance allows a fixed deviation in Hz.
+type AbsoluteTolerance float64
+
+func (t AbsoluteTolerance) Ratio(played, detected float64) float64 {
+	return math.Abs(detected-played) / float64(t)
+}
+
+func (t AbsoluteTolerance) String() string {
+	return strconv.FormatFloat(float64(t), 'f', -1, 64) + "Hz"
+}
+
+// RelativeTolerance allows a deviation in percent of the played frequency.
+type RelativeTolerance float64
+
+func (t RelativeTolerance) Ratio(played, detected float64) float64 {
+	return math.Abs(detected-played) / (math.Abs(played) * float64(t) / 100)
+}
+
+func (t RelativeTolerance) String() string {
+	return strconv.FormatFloat(float64(t), 'f', -1, 64) + "%"
+}
+
+// CentsTolerance allows a pitch deviation in cents, which weighs errors
+// the same way across the whole audible range.
+type CentsTolerance float64
+
+func (t CentsTolerance) Ratio(played, detected float64) float64 {
+	if played <= 0 || detected <= 0 {
+		return math.Inf(1)
+	}
+	return math.Abs(1200*math.Log2(detected/played)) / float64(t)
+}
+
+func (t CentsTolerance) String() string {
+	return strconv.FormatFloat(float64(t), 'f', -1, 64) + "c"
+}
+
+// ParseTolerance reads "10Hz", "5%" or "20c"/"20cents".
+func ParseTolerance(s string) (Tolerance, error) {
+	s = strings.TrimSpace(strings.ToLower(s))
+	var (
+		number string
+		build  func(float64) Tolerance
+	)
+	switch {
+	case strings.HasSuffix(s, "hz"):
+		number, build = strings.TrimSuffix(s, "hz"), func(v float64) Tolerance { return AbsoluteTolerance(v) }
+	case strings.HasSuffix(s, "%"):
+		number, build = strings.TrimSuffix(s, "%"), func(v float64) Tolerance { return RelativeTolerance(v) }
+	case strings.HasSuffix(s, "cents"):
+		number, build = strings.TrimSuffix(s, "cents"), func(v float64) Tolerance { return CentsTolerance(v) }
+	case strings.HasSuffix(s, "c"):
+		number, build = strings.TrimSuffix(s, "c"), func(v float64) Tolerance { return CentsTolerance(v) }
+	default:
+		return nil, fmt.Errorf("tolerance %q needs a unit: Hz, %% or c", s)
+	}
+
+	value, err := strconv.ParseFloat(strings.TrimSpace(number), 64)
+	if err != nil {
+		return nil, fmt.Errorf("invalid tolerance %q: %w", s, err)
+	}
+	if value <= 0 {
+		return nil, fmt.Errorf("tolerance must be positive, got %q", s)
+	}
+	return build(value), nil
+}
+
+// Matcher turns deviations into MATCH/MISMATCH with hysteresis: a match is
+// entered at Ratio <= 1 but only left once Ratio exceeds 1+Hysteresis, so
+// detections hovering at the boundary do not make the status flicker.
+type Matcher struct {
+	Tolerance  Tolerance
+	Hysteresis float64
+	matched    bool
+}
+
+// DefaultHysteresis is the hysteresis of Match and of the verify command.
+const DefaultHysteresis = 0.2
+
+func NewMatcher(tolerance Tolerance, hysteresis float64) *Matcher {
+	return &Matcher{Tolerance: tolerance, Hysteresis: hysteresis}
+}
+
+func (m *Matcher) Update(played, detected float64) string {
+	ratio := m.Tolerance.Ratio(played, detected)
+	if m.matched {
+		m.matched = ratio <= 1+m.Hysteresis
+	} else {
+		m.matched = ratio <= 1
+	}
+
+	if m.matched {
+		return StatusMatch
+	}
+	return StatusMismatch
+}
+
+func (m *Matcher) Reset() {
+	m.matched = false
+}
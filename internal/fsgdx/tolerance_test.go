@@ -0,0 +1,72 @@
+package fsgdx
+
+import (
+	"math"
+	"testing"
+)
+
+func TestParseTolerance(t *testing.T) {
+	tests := []struct {
+		input string
+		want  Tolerance
+	}{
+		{"10Hz", AbsoluteTolerance(10)},
+		{"2.5 hz", AbsoluteTolerance(2.5)},
+		{"5%", RelativeTolerance(5)},
+		{"20c", CentsTolerance(20)},
+		{"50cents", CentsTolerance(50)},
+	}
+	for _, tc := range tests {
+		got, err := ParseTolerance(tc.input)
+		if err != nil {
+			t.Errorf("ParseTolerance(%q) failed: %v", tc.input, err)
+			continue
+		}
+		if got != tc.want {
+			t.Errorf("ParseTolerance(%q) = %v, want %v", tc.input, got, tc.want)
+		}
+	}
+
+	for _, input := range []string{"10", "-5%", "abcHz", "0c"} {
+		if _, err := ParseTolerance(input); err == nil {
+			t.Errorf("ParseTolerance(%q) should fail", input)
+		}
+	}
+}
+
+func TestToleranceRatio(t *testing.T) {
+	if r := AbsoluteTolerance(10).Ratio(1000, 1005); r != 0.5 {
+		t.Errorf("Absolute ratio: got %v, want 0.5", r)
+	}
+	if r := RelativeTolerance(5).Ratio(1000, 1050); math.Abs(r-1) > 1e-12 {
+		t.Errorf("Relative ratio: got %v, want 1", r)
+	}
+	// An octave is 1200 cents at any frequency.
+	if r := CentsTolerance(100).Ratio(55, 110); math.Abs(r-12) > 1e-9 {
+		t.Errorf("Cents ratio: got %v, want 12", r)
+	}
+	if r := CentsTolerance(100).Ratio(440, 0); !math.IsInf(r, 1) {
+		t.Errorf("Cents ratio for no detection: got %v, want +Inf", r)
+	}
+}
+
+func TestMatcherHysteresis(t *testing.T) {
+	m := NewMatcher(AbsoluteTolerance(10), 0.5)
+	steps := []struct {
+		detected float64
+		want     string
+	}{
+		{1012, StatusMismatch}, // outside, not yet matched
+		{1009, StatusMatch},    // enters the tolerance
+		{1012, StatusMatch},    // within the hysteresis band
+		{1014, StatusMatch},
+		{1016, StatusMismatch}, // beyond 1 + hysteresis
+		{1012, StatusMismatch}, // must re-enter the tolerance itself
+		{1010, StatusMatch},
+	}
+	for i, step := range steps {
+		if got := m.Update(1000, step.detected); got != step.want {
+			t.Errorf("Step %d (%v Hz): got %s, want %s", i, step.detected, got, step.want)
+		}
+	}
+}
@@ -0,0 +1,171 @@
+package fsgdx
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"io"
+	"math"
+	"sort"
+	"strconv"
+	"sync"
+)
+
+const (
+	StatusMatch    = "MATCH"
+	StatusMismatch = "MISMATCH"
+)
+
+// VerificationSample is one analysed callback of a duplex run. Time is the
+// start of the callback buffer in seconds, Deviation is Detected-Played
+// in Hz and Level is the captured level in dBFS.
+type VerificationSample struct {
+	Time      float64 `json:"time"`
+	Played    float64 `json:"played"`
+	Detected  float64 `json:"detected"`
+	Deviation float64 `json:"deviation"`
+	Level     float64 `json:"level"`
+	Status    string  `json:"status"`
+}
+
+// FrequencyRange is a span of played frequencies, in Hz.
+type FrequencyRange struct {
+	From float64 `json:"from"`
+	To   float64 `json:"to"`
+}
+
+type VerificationReport struct {
+	Samples             int                  `json:"samples"`
+	Matches             int                  `json:"matches"`
+	MatchPercent        float64              `json:"matchPercent"`
+	MaxDeviation        float64              `json:"maxDeviation"`
+	MaxDeviationPercent float64              `json:"maxDeviationPercent"`
+	MeanAbsDeviation    float64              `json:"meanAbsDeviation"`
+	FailedRanges        []FrequencyRange     `json:"failedRanges"`
+	Records             []VerificationSample `json:"records"`
+}
+
+// VerificationRecorder logs every sample of a duplex run. Assign one to
+// Recorder to have PlaybackAndAnalyzeCallback feed it.
+type VerificationRecorder struct {
+	sampleRate float64
+	mutex      sync.Mutex
+	frames     uint64
+	records    []VerificationSample
+}
+
+func NewVerificationRecorder(sampleRate float64) *VerificationRecorder {
+	return &VerificationRecorder{sampleRate: sampleRate}
+}
+
+// Record logs the analysis of a callback buffer of the given length.
+func (r *VerificationRecorder) Record(frames uint32, played, detected, level float64, status string) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	r.records = append(r.records, VerificationSample{
+		Time:      float64(r.frames) / r.sampleRate,
+		Played:    played,
+		Detected:  detected,
+		Deviation: detected - played,
+		Level:     level,
+		Status:    status,
+	})
+	r.frames += uint64(frames)
+}
+
+func (r *VerificationRecorder) Reset() {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	r.frames = 0
+	r.records = nil
+}
+
+func (r *VerificationRecorder) Report() VerificationReport {
+	r.mutex.Lock()
+	records := append([]VerificationSample(nil), r.records...)
+	r.mutex.Unlock()
+
+	report := VerificationReport{
+		Samples:      len(records),
+		FailedRanges: []FrequencyRange{},
+		Records:      records,
+	}
+	if len(records) == 0 {
+		return report
+	}
+
+	var failed []FrequencyRange
+	inFailure := false
+	for _, rec := range records {
+		deviation := math.Abs(rec.Deviation)
+		report.MeanAbsDeviation += deviation
+		if deviation > report.MaxDeviation {
+			report.MaxDeviation = deviation
+		}
+		if rec.Played != 0 {
+			report.MaxDeviationPercent = math.Max(report.MaxDeviationPercent, 100*deviation/rec.Played)
+		}
+
+		if rec.Status == StatusMatch {
+			report.Matches++
+			inFailure = false
+			continue
+		}
+		if !inFailure {
+			failed = append(failed, FrequencyRange{From: rec.Played, To: rec.Played})
+			inFailure = true
+		}
+		last := &failed[len(failed)-1]
+		last.From = math.Min(last.From, rec.Played)
+		last.To = math.Max(last.To, rec.Played)
+	}
+	report.MeanAbsDeviation /= float64(len(records))
+	report.MatchPercent = 100 * float64(report.Matches) / float64(len(records))
+	report.FailedRanges = mergeRanges(failed)
+	return report
+}
+
+// mergeRanges sorts ranges and joins the overlapping ones, so that the up
+// and down halves of a sweep failing at the same frequencies report once.
+func mergeRanges(ranges []FrequencyRange) []FrequencyRange {
+	merged := []FrequencyRange{}
+	sort.Slice(ranges, func(i, j int) bool { return ranges[i].From < ranges[j].From })
+	for _, rng := range ranges {
+		if n := len(merged); n > 0 && rng.From <= merged[n-1].To {
+			merged[n-1].To = math.Max(merged[n-1].To, rng.To)
+			continue
+		}
+		merged = append(merged, rng)
+	}
+	return merged
+}
+
+func (rep VerificationReport) WriteJSON(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(rep)
+}
+
+// WriteCSV writes one row per recorded sample; the summary is only part of
+// the JSON report.
+func (rep VerificationReport) WriteCSV(w io.Writer) error {
+	cw := csv.NewWriter(w)
+	if err := cw.Write([]string{"time", "played", "detected", "deviation", "level", "status"}); err != nil {
+		return err
+	}
+	for _, rec := range rep.Records {
+		row := []string{
+			strconv.FormatFloat(rec.Time, 'f', 4, 64),
+			strconv.FormatFloat(rec.Played, 'f', 2, 64),
+			strconv.FormatFloat(rec.Detected, 'f', 2, 64),
+			strconv.FormatFloat(rec.Deviation, 'f', 2, 64),
+			strconv.FormatFloat(rec.Level, 'f', 2, 64),
+			rec.Status,
+		}
+		if err := cw.Write(row); err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
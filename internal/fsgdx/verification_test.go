@@ -0,0 +1,120 @@
+package fsgdx
+
+import (
+	"bytes"
+	"encoding/json"
+	"math"
+	"strings"
+	"testing"
+
+	"github.com/hailam/malgoplay/internal/calibration"
+)
+
+func TestVerificationReport(t *testing.T) {
+	recorder := NewVerificationRecorder(1000)
+	samples := []struct {
+		played, detected float64
+		status           string
+	}{
+		{100, 101, StatusMatch},
+		{200, 150, StatusMismatch},
+		{300, 240, StatusMismatch},
+		{400, 401, StatusMatch},
+		{500, 600, StatusMismatch},
+		{450, 451, StatusMatch},
+		{250, 180, StatusMismatch},
+	}
+	for _, s := range samples {
+		recorder.Record(100, s.played, s.detected, -20, s.status)
+	}
+
+	report := recorder.Report()
+	if report.Samples != 7 || report.Matches != 3 {
+		t.Errorf("Got %d samples, %d matches, want 7, 3", report.Samples, report.Matches)
+	}
+	if report.MatchPercent < 42.8 || report.MatchPercent > 42.9 {
+		t.Errorf("Incorrect match percentage: %v", report.MatchPercent)
+	}
+	if report.MaxDeviation != 100 {
+		t.Errorf("Incorrect max deviation: got %v, want 100", report.MaxDeviation)
+	}
+	if report.MaxDeviationPercent != 28 {
+		t.Errorf("Incorrect max relative deviation: got %v, want 28", report.MaxDeviationPercent)
+	}
+	if report.Records[3].Time != 0.3 {
+		t.Errorf("Incorrect record time: got %v, want 0.3", report.Records[3].Time)
+	}
+
+	// 200-300 and the later 250 overlap and merge; 500 stays separate.
+	want := []FrequencyRange{{200, 300}, {500, 500}}
+	if len(report.FailedRanges) != len(want) {
+		t.Fatalf("Got failed ranges %v, want %v", report.FailedRanges, want)
+	}
+	for i := range want {
+		if report.FailedRanges[i] != want[i] {
+			t.Errorf("Failed range %d: got %v, want %v", i, report.FailedRanges[i], want[i])
+		}
+	}
+
+	var buf bytes.Buffer
+	if err := report.WriteJSON(&buf); err != nil {
+		t.Fatalf("WriteJSON failed: %v", err)
+	}
+	var decoded VerificationReport
+	if err := json.Unmarshal(buf.Bytes(), &decoded); err != nil || decoded.Matches != 3 {
+		t.Errorf("JSON report did not round-trip: %v", err)
+	}
+
+	buf.Reset()
+	if err := report.WriteCSV(&buf); err != nil {
+		t.Fatalf("WriteCSV failed: %v", err)
+	}
+	if lines := strings.Count(buf.String(), "\n"); lines != 8 {
+		t.Errorf("CSV should have a header and 7 rows, got %d lines", lines)
+	}
+}
+
+func TestPlaybackAndAnalyzeCallbackRecords(t *testing.T) {
+	SampleRate = 48000
+	Channels = 1
+	Quiet = true
+	SetFrequency(5000)
+	Smoothing.Reset()
+	Match.Reset()
+	Recorder = NewVerificationRecorder(SampleRate)
+	defer func() {
+		Recorder = nil
+		Quiet = false
+	}()
+
+	device := NewMockLoopbackDevice(Channels, 1024)
+	device.SetCallback(PlaybackAndAnalyzeCallback)
+	_ = device.Start()
+	for i := 0; i < 30; i++ {
+		device.GenerateSamples(1024)
+	}
+
+	report := Recorder.Report()
+	if report.Samples != 30 {
+		t.Fatalf("Got %d recorded samples, want 30", report.Samples)
+	}
+	if report.MatchPercent < 60 {
+		t.Errorf("Looped-back tone should mostly match, got %.1f%%", report.MatchPercent)
+	}
+	if last := report.Records[29]; last.Status != StatusMatch || last.Played != 5000 {
+		t.Errorf("Unexpected final record: %+v", last)
+	}
+	// The tone plays at an amplitude of 0.5, an RMS level of -9.03 dBFS.
+	if level := report.Records[29].Level; math.Abs(level-20*math.Log10(0.5/math.Sqrt2)) > 0.1 {
+		t.Errorf("Incorrect captured level: got %v", level)
+	}
+
+	// A microphone 6 dB hot at 5 kHz reads 6 dB less once corrected.
+	InputCalibration = &calibration.Profile{Points: []calibration.Point{{Frequency: 1000, GainDB: 0}, {Frequency: 5000, GainDB: 6}}}
+	defer func() { InputCalibration = nil }()
+	Recorder.Reset()
+	device.GenerateSamples(1024)
+	if got, want := Recorder.Report().Records[0].Level, report.Records[29].Level-6; math.Abs(got-want) > 0.1 {
+		t.Errorf("Incorrect calibrated level: got %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,293 @@
+package pitch
+
+import (
+	"errors"
+	"math"
+
+	"gonum.org/v1/gonum/dsp/fourier"
+)
+
+type Method int
+
+const (
+	MethodYIN Method = iota
+	MethodMcLeod
+)
+
+// Config controls a pitch Detector. Threshold is the YIN absolute
+// threshold on the cumulative mean normalised difference (typically
+// 0.1-0.2) or the McLeod key-maximum ratio k (typically 0.8-0.95).
+type Config struct {
+	Method       Method
+	SampleRate   float64
+	WindowSize   int
+	HopSize      int
+	MinFrequency float64
+	MaxFrequency float64
+	Threshold    float64
+}
+
+func DefaultConfig(sampleRate float64) Config {
+	return Config{
+		Method:       MethodYIN,
+		SampleRate:   sampleRate,
+		WindowSize:   2048,
+		HopSize:      512,
+		MinFrequency: 40,
+		MaxFrequency: 2000,
+		Threshold:    0.15,
+	}
+}
+
+// Estimate is one point of a pitch track. Time is the start of the
+// analysis window in seconds; Frequency is 0 when no period was found.
+type Estimate struct {
+	Time       float64
+	Frequency  float64
+	Confidence float64
+}
+
+type Detector struct {
+	config  Config
+	minLag  int
+	maxLag  int
+	fft     *fourier.FFT
+	padded  []float64
+	coeffs  []complex128
+	corr    []float64
+	curve   []float64
+	squares []float64
+}
+
+func NewDetector(config Config) (*Detector, error) {
+	if config.SampleRate <= 0 {
+		return nil, errors.New("pitch: sample rate must be positive")
+	}
+	if config.WindowSize < 4 || config.HopSize <= 0 {
+		return nil, errors.New("pitch: window must be at least 4 frames and hop positive")
+	}
+	if config.HopSize > config.WindowSize {
+		return nil, errors.New("pitch: hop must not be longer than the window")
+	}
+	if config.MinFrequency <= 0 || config.MaxFrequency <= config.MinFrequency {
+		return nil, errors.New("pitch: invalid frequency range")
+	}
+	if config.Method != MethodYIN && config.Method != MethodMcLeod {
+		return nil, errors.New("pitch: unknown method")
+	}
+
+	// YIN compares two half windows, so its longest lag is half the window.
+	maxLag := int(config.SampleRate / config.MinFrequency)
+	if limit := config.WindowSize / 2; maxLag > limit {
+		maxLag = limit
+	}
+	minLag := int(config.SampleRate / config.MaxFrequency)
+	if minLag < 2 {
+		minLag = 2
+	}
+	if minLag >= maxLag {
+		return nil, errors.New("pitch: window too short for the frequency range")
+	}
+
+	n := 1
+	for n < 2*config.WindowSize {
+		n <<= 1
+	}
+	return &Detector{
+		config:  config,
+		minLag:  minLag,
+		maxLag:  maxLag,
+		fft:     fourier.NewFFT(n),
+		padded:  make([]float64, n),
+		corr:    make([]float64, config.WindowSize),
+		curve:   make([]float64, maxLag+2),
+		squares: make([]float64, config.WindowSize+1),
+	}, nil
+}
+
+// Detect estimates the fundamental frequency of one window of
+// Config.WindowSize samples.
+func (d *Detector) Detect(window []float64) (float64, float64) {
+	if len(window) < d.config.WindowSize {
+		return 0, 0
+	}
+	window = window[:d.config.WindowSize]
+
+	for i, v := range window {
+		d.squares[i+1] = d.squares[i] + v*v
+	}
+	if d.squares[len(window)] == 0 {
+		return 0, 0
+	}
+
+	switch d.config.Method {
+	case MethodMcLeod:
+		return d.mcleod(window)
+	default:
+		return d.yin(window)
+	}
+}
+
+// autocorrelate fills corr[tau] with sum(x[j] * x[j+tau]) over the window.
+func (d *Detector) autocorrelate(window []float64) {
+	for i := range d.padded {
+		d.padded[i] = 0
+	}
+	copy(d.padded, window)
+	d.coeffs = d.fft.Coefficients(d.coeffs, d.padded)
+	for i, c := range d.coeffs {
+		d.coeffs[i] = complex(real(c)*real(c)+imag(c)*imag(c), 0)
+	}
+	full := d.fft.Sequence(d.padded, d.coeffs)
+	scale := float64(len(full))
+	for i := range d.corr {
+		d.corr[i] = full[i] / scale
+	}
+}
+
+func (d *Detector) yin(window []float64) (float64, float64) {
+	half := len(window) / 2
+
+	// Cross term over the first half window via FFT of the half window
+	// against the full window.
+	for i := range d.padded {
+		d.padded[i] = 0
+	}
+	copy(d.padded, window)
+	full := d.fft.Coefficients(nil, d.padded)
+	for i := range d.padded {
+		d.padded[i] = 0
+	}
+	copy(d.padded, window[:half])
+	head := d.fft.Coefficients(d.coeffs, d.padded)
+	for i := range head {
+		head[i] = full[i] * complex(real(head[i]), -imag(head[i]))
+	}
+	d.coeffs = head
+	cross := d.fft.Sequence(d.padded, head)
+	scale := float64(len(cross))
+
+	// Cumulative mean normalised difference d'(tau).
+	d.curve[0] = 1
+	running := 0.0
+	for tau := 1; tau <= d.maxLag; tau++ {
+		diff := d.squares[half] + (d.squares[tau+half] - d.squares[tau]) - 2*cross[tau]/scale
+		running += diff
+		if running == 0 {
+			d.curve[tau] = 1
+			continue
+		}
+		d.curve[tau] = diff * float64(tau) / running
+	}
+
+	best := -1
+	for tau := d.minLag; tau <= d.maxLag; tau++ {
+		if d.curve[tau] < d.config.Threshold {
+			for tau+1 <= d.maxLag && d.curve[tau+1] < d.curve[tau] {
+				tau++
+			}
+			best = tau
+			break
+		}
+	}
+	if best < 0 {
+		best = d.minLag
+		for tau := d.minLag; tau <= d.maxLag; tau++ {
+			if d.curve[tau] < d.curve[best] {
+				best = tau
+			}
+		}
+	}
+
+	confidence := math.Max(0, math.Min(1, 1-d.curve[best]))
+	period := refine(d.curve, best, d.maxLag)
+	return d.config.SampleRate / period, confidence
+}
+
+func (d *Detector) mcleod(window []float64) (float64, float64) {
+	d.autocorrelate(window)
+	n := len(window)
+
+	// Normalised square difference function n'(tau) in [-1, 1].
+	for tau := 0; tau <= d.maxLag; tau++ {
+		m := d.squares[n-tau] + (d.squares[n] - d.squares[tau])
+		if m == 0 {
+			d.curve[tau] = 0
+			continue
+		}
+		d.curve[tau] = 2 * d.corr[tau] / m
+	}
+
+	// Key maxima: the highest point between each positive-going zero
+	// crossing and the following negative-going one.
+	var keyMaxima []int
+	highest := 0.0
+	tau := 1
+	for tau < d.maxLag && d.curve[tau] > 0 {
+		tau++
+	}
+	for tau < d.maxLag {
+		for tau < d.maxLag && d.curve[tau] <= 0 {
+			tau++
+		}
+		peak := -1
+		for tau < d.maxLag && d.curve[tau] > 0 {
+			if tau >= d.minLag && (peak < 0 || d.curve[tau] > d.curve[peak]) {
+				peak = tau
+			}
+			tau++
+		}
+		if peak >= 0 {
+			keyMaxima = append(keyMaxima, peak)
+			highest = math.Max(highest, d.curve[peak])
+		}
+	}
+	if len(keyMaxima) == 0 {
+		return 0, 0
+	}
+
+	for _, peak := range keyMaxima {
+		if d.curve[peak] >= d.config.Threshold*highest {
+			inverted := make([]float64, 3)
+			for i := range inverted {
+				inverted[i] = -d.curve[peak-1+i]
+			}
+			period := refine(inverted, 1, 2) + float64(peak-1)
+			return d.config.SampleRate / period, math.Max(0, math.Min(1, d.curve[peak]))
+		}
+	}
+	return 0, 0
+}
+
+// refine returns the sub-sample position of the minimum of curve near i
+// by parabolic interpolation.
+func refine(curve []float64, i, last int) float64 {
+	if i <= 0 || i >= last {
+		return float64(i)
+	}
+	a, b, c := curve[i-1], curve[i], curve[i+1]
+	denom := a - 2*b + c
+	if denom == 0 {
+		return float64(i)
+	}
+	return float64(i) + 0.5*(a-c)/denom
+}
+
+// Track runs a Detector over samples with the configured window and hop.
+func Track(samples []float64, config Config) ([]Estimate, error) {
+	detector, err := NewDetector(config)
+	if err != nil {
+		return nil, err
+	}
+
+	var track []Estimate
+	for start := 0; start+config.WindowSize <= len(samples); start += config.HopSize {
+		freq, confidence := detector.Detect(samples[start : start+config.WindowSize])
+		track = append(track, Estimate{
+			Time:       float64(start) / config.SampleRate,
+			Frequency:  freq,
+			Confidence: confidence,
+		})
+	}
+	return track, nil
+}
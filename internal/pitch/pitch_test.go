@@ -0,0 +1,142 @@
+package pitch
+
+import (
+	"math"
+	"testing"
+)
+
+func tone(freq float64, harmonics []float64, seconds, sampleRate float64) []float64 {
+	samples := make([]float64, int(seconds*sampleRate))
+	for i := range samples {
+		t := float64(i) / sampleRate
+		for h, amplitude := range harmonics {
+			samples[i] += amplitude * math.Sin(2*math.Pi*freq*float64(h+1)*t)
+		}
+	}
+	return samples
+}
+
+func TestDetectLowFrequencies(t *testing.T) {
+	const sampleRate = 44100
+	for _, method := range []Method{MethodYIN, MethodMcLeod} {
+		config := DefaultConfig(sampleRate)
+		config.Method = method
+		if method == MethodMcLeod {
+			config.Threshold = 0.9
+		}
+		detector, err := NewDetector(config)
+		if err != nil {
+			t.Fatalf("NewDetector failed: %v", err)
+		}
+
+		for _, freq := range []float64{55, 82.41, 110, 440, 1500} {
+			window := tone(freq, []float64{1}, 0.1, sampleRate)
+			got, confidence := detector.Detect(window)
+			if math.Abs(got-freq)/freq > 0.005 {
+				t.Errorf("Method %d: got %.2f Hz, want %.2f Hz", method, got, freq)
+			}
+			if confidence < 0.9 {
+				t.Errorf("Method %d at %.2f Hz: low confidence %v", method, freq, confidence)
+			}
+		}
+	}
+}
+
+func TestDetectHarmonicRichTone(t *testing.T) {
+	const sampleRate = 48000
+	// A weak fundamental under strong upper harmonics defeats a plain
+	// spectral peak picker.
+	harmonics := []float64{0.2, 1.0, 0.8, 0.6, 0.4}
+	window := tone(98, harmonics, 0.1, sampleRate)
+
+	for _, method := range []Method{MethodYIN, MethodMcLeod} {
+		config := DefaultConfig(sampleRate)
+		config.Method = method
+		if method == MethodMcLeod {
+			config.Threshold = 0.9
+		}
+		detector, _ := NewDetector(config)
+		got, _ := detector.Detect(window)
+		if math.Abs(got-98)/98 > 0.005 {
+			t.Errorf("Method %d: got %.2f Hz, want 98 Hz", method, got)
+		}
+	}
+}
+
+func TestDetectSilence(t *testing.T) {
+	detector, _ := NewDetector(DefaultConfig(44100))
+	freq, confidence := detector.Detect(make([]float64, 2048))
+	if freq != 0 || confidence != 0 {
+		t.Errorf("Silence: got %v Hz with confidence %v, want 0, 0", freq, confidence)
+	}
+}
+
+func TestTrackerMatchesTrack(t *testing.T) {
+	config := DefaultConfig(44100)
+	samples := append(tone(220, []float64{1}, 0.5, 44100), tone(330, []float64{1}, 0.5, 44100)...)
+
+	offline, err := Track(samples, config)
+	if err != nil {
+		t.Fatalf("Track failed: %v", err)
+	}
+
+	tracker, err := NewTracker(config)
+	if err != nil {
+		t.Fatalf("NewTracker failed: %v", err)
+	}
+	var streamed []Estimate
+	block := make([]float32, 300)
+	for start := 0; start+len(block) <= len(samples); start += len(block) {
+		for i := range block {
+			block[i] = float32(samples[start+i])
+		}
+		streamed = append(streamed, tracker.Write(block)...)
+	}
+
+	if len(streamed) == 0 || len(streamed) > len(offline) {
+		t.Fatalf("Streamed %d estimates, offline %d", len(streamed), len(offline))
+	}
+	for i, e := range streamed {
+		if e.Time != offline[i].Time || math.Abs(e.Frequency-offline[i].Frequency) > 0.01 {
+			t.Errorf("Estimate %d: streamed %+v, offline %+v", i, e, offline[i])
+		}
+	}
+
+	first, last := offline[0], offline[len(offline)-1]
+	if math.Abs(first.Frequency-220) > 1 || math.Abs(last.Frequency-330) > 1 {
+		t.Errorf("Track should follow 220 Hz -> 330 Hz, got %.2f -> %.2f", first.Frequency, last.Frequency)
+	}
+	if tracker.Last() != streamed[len(streamed)-1] {
+		t.Error("Last should return the most recent estimate")
+	}
+}
+
+func TestNewDetectorValidation(t *testing.T) {
+	config := DefaultConfig(44100)
+	config.MinFrequency = 10
+	config.WindowSize = 64
+	config.MaxFrequency = 20
+	if _, err := NewDetector(config); err == nil {
+		t.Error("Expected an error when the window cannot hold the frequency range")
+	}
+}
+
+func TestHopLongerThanWindow(t *testing.T) {
+	config := DefaultConfig(44100)
+	config.HopSize = config.WindowSize + 1
+	if _, err := NewDetector(config); err == nil {
+		t.Error("Expected an error for a hop longer than the window")
+	}
+	if _, err := NewTracker(config); err == nil {
+		t.Error("Expected NewTracker to reject a hop longer than the window")
+	}
+
+	config.HopSize = config.WindowSize
+	tracker, err := NewTracker(config)
+	if err != nil {
+		t.Fatalf("A hop of one window should be allowed: %v", err)
+	}
+	if got := len(tracker.Write(make([]float32, 3*config.WindowSize))); got != 3 {
+		t.Errorf("Incorrect number of estimates: got %d, want 3", got)
+	}
+}
@@ -0,0 +1,68 @@
+package pitch
+
+import "sync"
+
+// Tracker is a streaming pitch tracker for live input, such as the capture
+// side of a duplex callback. Samples are buffered until a full window is
+// available and an estimate is produced every HopSize samples.
+type Tracker struct {
+	detector *Detector
+	config   Config
+	mutex    sync.Mutex
+	pending  []float64
+	consumed int64
+	last     Estimate
+}
+
+func NewTracker(config Config) (*Tracker, error) {
+	detector, err := NewDetector(config)
+	if err != nil {
+		return nil, err
+	}
+	return &Tracker{
+		detector: detector,
+		config:   config,
+		pending:  make([]float64, 0, 2*config.WindowSize),
+	}, nil
+}
+
+// Write appends mono samples and returns the estimates for every window
+// completed by them.
+func (t *Tracker) Write(samples []float32) []Estimate {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	for _, v := range samples {
+		t.pending = append(t.pending, float64(v))
+	}
+
+	var estimates []Estimate
+	for len(t.pending) >= t.config.WindowSize {
+		freq, confidence := t.detector.Detect(t.pending[:t.config.WindowSize])
+		t.last = Estimate{
+			Time:       float64(t.consumed) / t.config.SampleRate,
+			Frequency:  freq,
+			Confidence: confidence,
+		}
+		estimates = append(estimates, t.last)
+
+		t.pending = append(t.pending[:0], t.pending[t.config.HopSize:]...)
+		t.consumed += int64(t.config.HopSize)
+	}
+	return estimates
+}
+
+// Last returns the most recent estimate.
+func (t *Tracker) Last() Estimate {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	return t.last
+}
+
+func (t *Tracker) Reset() {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	t.pending = t.pending[:0]
+	t.consumed = 0
+	t.last = Estimate{}
+}
@@ -0,0 +1,276 @@
+// Package preset stores named generator settings as JSON.
+package preset
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/hailam/malgoplay/internal/fsg"
+)
+
+var (
+	ErrNotFound = errors.New("preset not found")
+	ErrBuiltin  = errors.New("built-in preset")
+)
+
+// FileName is the file a Store keeps its presets in.
+const FileName = "presets.json"
+
+// Preset is a named set of sweep settings. Mode is a sweep mode name as
+// accepted by fsg.ParseSweepMode and SweepRate is in sweep cycles per
+// second, as for the CLI's --sweep flag.
+type Preset struct {
+	Name         string  `json:"name"`
+	Description  string  `json:"description,omitempty"`
+	MinFrequency float64 `json:"min_frequency"`
+	MaxFrequency float64 `json:"max_frequency"`
+	Mode         string  `json:"mode"`
+	SweepRate    float64 `json:"sweep_rate"`
+	Amplitude    float64 `json:"amplitude"`
+	Builtin      bool    `json:"builtin,omitempty"`
+}
+
+// Builtins are always available and cannot be overwritten or deleted.
+var Builtins = []Preset{
+	{
+		Name:         "speaker break-in",
+		Description:  "Slow low-frequency sweep to loosen new drivers",
+		MinFrequency: 20,
+		MaxFrequency: 200,
+		Mode:         "sine",
+		SweepRate:    0.1,
+		Amplitude:    0.5,
+	},
+	{
+		Name:         "subwoofer sweep",
+		Description:  "Octave sweep through the subwoofer band to find rattles and room modes",
+		MinFrequency: 20,
+		MaxFrequency: 120,
+		Mode:         "octaves",
+		SweepRate:    0.05,
+		Amplitude:    0.7,
+	},
+	{
+		Name:         "hearing range",
+		Description:  "Quiet octave sweep across the range of human hearing",
+		MinFrequency: 20,
+		MaxFrequency: 20000,
+		Mode:         "octaves",
+		SweepRate:    0.05,
+		Amplitude:    0.2,
+	},
+}
+
+// Validate checks the preset against the generator's limits at sampleRate.
+func (p Preset) Validate(sampleRate uint32) error {
+	if strings.TrimSpace(p.Name) == "" {
+		return fmt.Errorf("preset name must not be empty")
+	}
+	if _, err := p.sweepMode(); err != nil {
+		return err
+	}
+	if p.SweepRate < 0 {
+		return fmt.Errorf("sweep rate must not be negative, got %v", p.SweepRate)
+	}
+	if p.Amplitude < 0 || p.Amplitude > 1 {
+		return fmt.Errorf("amplitude must be between 0 and 1, got %v", p.Amplitude)
+	}
+	config := fsg.Config{MinFrequency: p.MinFrequency, MaxFrequency: p.MaxFrequency, SampleRate: sampleRate, Channels: 1}
+	return config.Validate()
+}
+
+// sweepMode parses the preset's mode. The notes and custom modes need a
+// note list or curve, which a preset cannot hold.
+func (p Preset) sweepMode() (fsg.SweepMode, error) {
+	mode, err := fsg.ParseSweepMode(p.Mode)
+	if err != nil {
+		return mode, err
+	}
+	if mode == fsg.SweepModeNotes || mode == fsg.SweepModeCustom {
+		return mode, fmt.Errorf("presets cannot use the %v mode", mode)
+	}
+	return mode, nil
+}
+
+// Apply sets the preset's range, mode, rate and amplitude on gen.
+func (p Preset) Apply(gen *fsg.FrequencySweepGenerator) error {
+	mode, err := p.sweepMode()
+	if err != nil {
+		return err
+	}
+	if err := gen.SetFrequencyRange(p.MinFrequency, p.MaxFrequency, 0); err != nil {
+		return err
+	}
+	gen.SetSweepMode(mode)
+	gen.SetSweepRate(p.SweepRate)
+	gen.SetAmplitude(p.Amplitude)
+	return nil
+}
+
+// Store keeps user presets in a JSON file in a directory.
+type Store struct {
+	dir string
+}
+
+func NewStore(dir string) *Store {
+	return &Store{dir: dir}
+}
+
+// DefaultDir returns the per-user configuration directory for presets.
+func DefaultDir() (string, error) {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "malgoplay"), nil
+}
+
+// List returns the built-in presets followed by the saved ones, each
+// group sorted by name.
+func (s *Store) List() ([]Preset, error) {
+	saved, err := s.read()
+	if err != nil {
+		return nil, err
+	}
+	presets := builtins()
+	names := make([]string, 0, len(saved))
+	for name := range saved {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		presets = append(presets, saved[name])
+	}
+	return presets, nil
+}
+
+// Load returns the preset called name, built-in or saved. Names are not
+// case-sensitive.
+func (s *Store) Load(name string) (Preset, error) {
+	if p, ok := builtin(name); ok {
+		return p, nil
+	}
+	saved, err := s.read()
+	if err != nil {
+		return Preset{}, err
+	}
+	p, ok := saved[key(name)]
+	if !ok {
+		return Preset{}, fmt.Errorf("%w: %s", ErrNotFound, name)
+	}
+	return p, nil
+}
+
+// Save adds p to the store, replacing a saved preset of the same name.
+// Presets are validated at 48 kHz, so that they play at common rates.
+func (s *Store) Save(p Preset) error {
+	if _, ok := builtin(p.Name); ok {
+		return fmt.Errorf("%w: %s cannot be overwritten", ErrBuiltin, p.Name)
+	}
+	if err := p.Validate(48000); err != nil {
+		return err
+	}
+	saved, err := s.read()
+	if err != nil {
+		return err
+	}
+	p.Name = strings.TrimSpace(p.Name)
+	p.Builtin = false
+	saved[key(p.Name)] = p
+	return s.write(saved)
+}
+
+func (s *Store) Delete(name string) error {
+	if _, ok := builtin(name); ok {
+		return fmt.Errorf("%w: %s cannot be deleted", ErrBuiltin, name)
+	}
+	saved, err := s.read()
+	if err != nil {
+		return err
+	}
+	if _, ok := saved[key(name)]; !ok {
+		return fmt.Errorf("%w: %s", ErrNotFound, name)
+	}
+	delete(saved, key(name))
+	return s.write(saved)
+}
+
+func key(name string) string {
+	return strings.ToLower(strings.TrimSpace(name))
+}
+
+func builtins() []Preset {
+	presets := make([]Preset, len(Builtins))
+	for i, p := range Builtins {
+		p.Builtin = true
+		presets[i] = p
+	}
+	sort.Slice(presets, func(i, j int) bool { return presets[i].Name < presets[j].Name })
+	return presets
+}
+
+func builtin(name string) (Preset, bool) {
+	for _, p := range builtins() {
+		if key(p.Name) == key(name) {
+			return p, true
+		}
+	}
+	return Preset{}, false
+}
+
+func (s *Store) read() (map[string]Preset, error) {
+	saved := make(map[string]Preset)
+	data, err := os.ReadFile(filepath.Join(s.dir, FileName))
+	if errors.Is(err, os.ErrNotExist) {
+		return saved, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var presets []Preset
+	if err := json.Unmarshal(data, &presets); err != nil {
+		return nil, fmt.Errorf("%s: %w", FileName, err)
+	}
+	for _, p := range presets {
+		p.Builtin = false
+		saved[key(p.Name)] = p
+	}
+	return saved, nil
+}
+
+// write replaces the preset file through a temporary file, so that a
+// failed write never leaves it truncated.
+func (s *Store) write(saved map[string]Preset) error {
+	presets := make([]Preset, 0, len(saved))
+	for _, p := range saved {
+		presets = append(presets, p)
+	}
+	sort.Slice(presets, func(i, j int) bool { return key(presets[i].Name) < key(presets[j].Name) })
+	data, err := json.MarshalIndent(presets, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(s.dir, 0o755); err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(s.dir, FileName+".*")
+	if err != nil {
+		return err
+	}
+	if _, err := tmp.Write(append(data, '\n')); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	return os.Rename(tmp.Name(), filepath.Join(s.dir, FileName))
+}
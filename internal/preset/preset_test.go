@@ -0,0 +1,116 @@
+package preset
+
+import (
+	"errors"
+	"math"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/hailam/malgoplay/internal/fsg"
+)
+
+func TestStoreRoundTrip(t *testing.T) {
+	store := NewStore(filepath.Join(t.TempDir(), "nested"))
+	want := Preset{Name: "Tweeter check", MinFrequency: 2000, MaxFrequency: 20000, Mode: "octaves", SweepRate: 0.2, Amplitude: 0.3}
+	if err := store.Save(want); err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+
+	got, err := NewStore(store.dir).Load("tweeter CHECK")
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	if got != want {
+		t.Errorf("Incorrect preset: got %+v, want %+v", got, want)
+	}
+
+	want.Amplitude = 0.5
+	if err := store.Save(want); err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+	presets, err := store.List()
+	if err != nil {
+		t.Fatalf("List failed: %v", err)
+	}
+	if len(presets) != len(Builtins)+1 || presets[len(presets)-1].Amplitude != 0.5 {
+		t.Errorf("Saving again should replace the preset: %+v", presets)
+	}
+	for _, p := range presets[:len(Builtins)] {
+		if !p.Builtin {
+			t.Errorf("%s should be marked built-in", p.Name)
+		}
+	}
+
+	if err := store.Delete("tweeter check"); err != nil {
+		t.Fatalf("Delete failed: %v", err)
+	}
+	if _, err := store.Load("tweeter check"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Expected ErrNotFound after delete, got %v", err)
+	}
+	if err := store.Delete("tweeter check"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Expected ErrNotFound deleting twice, got %v", err)
+	}
+}
+
+func TestBuiltins(t *testing.T) {
+	store := NewStore(t.TempDir())
+	for _, b := range Builtins {
+		if err := b.Validate(44100); err != nil {
+			t.Errorf("Built-in %s is invalid: %v", b.Name, err)
+		}
+		if _, err := store.Load(b.Name); err != nil {
+			t.Errorf("Load(%q) failed: %v", b.Name, err)
+		}
+	}
+	if err := store.Save(Preset{Name: "Hearing Range", MinFrequency: 1, MaxFrequency: 2, Mode: "linear"}); !errors.Is(err, ErrBuiltin) {
+		t.Errorf("Expected ErrBuiltin overwriting a built-in, got %v", err)
+	}
+	if err := store.Delete("subwoofer sweep"); !errors.Is(err, ErrBuiltin) {
+		t.Errorf("Expected ErrBuiltin deleting a built-in, got %v", err)
+	}
+}
+
+func TestSaveInvalid(t *testing.T) {
+	store := NewStore(t.TempDir())
+	for _, p := range []Preset{
+		{Name: " ", MinFrequency: 20, MaxFrequency: 200, Mode: "linear"},
+		{Name: "a", MinFrequency: 200, MaxFrequency: 20, Mode: "linear"},
+		{Name: "a", MinFrequency: 20, MaxFrequency: 200, Mode: "wobble"},
+		{Name: "a", MinFrequency: 20, MaxFrequency: 200, Mode: "notes"},
+		{Name: "a", MinFrequency: 20, MaxFrequency: 200, Mode: "custom"},
+		{Name: "a", MinFrequency: 20, MaxFrequency: 200, Mode: "linear", Amplitude: 2},
+		{Name: "a", MinFrequency: 20, MaxFrequency: 30000, Mode: "linear"},
+	} {
+		if err := store.Save(p); err == nil {
+			t.Errorf("Expected an error saving %+v", p)
+		}
+	}
+	if _, err := os.Stat(filepath.Join(store.dir, FileName)); !errors.Is(err, os.ErrNotExist) {
+		t.Error("Invalid presets should not create the preset file")
+	}
+}
+
+func TestApply(t *testing.T) {
+	p, _ := NewStore(t.TempDir()).Load("subwoofer sweep")
+	gen := fsg.NewFrequencySweepGenerator(220, 880, 44100, 1)
+	if err := p.Apply(gen); err != nil {
+		t.Fatalf("Apply failed: %v", err)
+	}
+	peak := 0.0
+	for _, s := range gen.Render(4410) {
+		peak = math.Max(peak, math.Abs(float64(s)))
+	}
+	if math.Abs(peak-p.Amplitude) > 0.01 {
+		t.Errorf("Incorrect peak level: got %v, want %v", peak, p.Amplitude)
+	}
+
+	bad := Preset{Name: "x", MinFrequency: 20, MaxFrequency: 30000, Mode: "linear"}
+	if err := bad.Apply(gen); !errors.Is(err, fsg.ErrAboveNyquist) {
+		t.Errorf("Expected ErrAboveNyquist, got %v", err)
+	}
+	notes := Preset{Name: "x", MinFrequency: 20, MaxFrequency: 200, Mode: "notes"}
+	if err := notes.Apply(gen); err == nil || gen.Status().Mode == fsg.SweepModeNotes {
+		t.Errorf("A notes preset should not be applied: %v", err)
+	}
+}
@@ -0,0 +1,79 @@
+package room
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"text/tabwriter"
+)
+
+func formatMetric(m Metric, precision int) string {
+	if !m.Valid() {
+		return ""
+	}
+	return strconv.FormatFloat(float64(m), 'f', precision, 64)
+}
+
+func bandLabel(band float64) string {
+	switch {
+	case band == 0:
+		return "broadband"
+	case band >= 1000:
+		return strconv.FormatFloat(band/1000, 'f', -1, 64) + "k"
+	default:
+		return strconv.FormatFloat(band, 'f', -1, 64)
+	}
+}
+
+func (r Result) row(b BandResult) []string {
+	return []string{
+		bandLabel(b.Band),
+		formatMetric(b.EDT, 3),
+		formatMetric(b.T20, 3),
+		formatMetric(b.T30, 3),
+		formatMetric(b.C50, 2),
+		formatMetric(b.C80, 2),
+		formatMetric(b.D50, 3),
+	}
+}
+
+var header = []string{"band", "edt", "t20", "t30", "c50", "c80", "d50"}
+
+// WriteText writes a human-readable per-octave table; undefined values
+// are shown as "-".
+func (r Result) WriteText(w io.Writer) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprintln(tw, "Band (Hz)\tEDT (s)\tT20 (s)\tT30 (s)\tC50 (dB)\tC80 (dB)\tD50\t")
+	for _, b := range r.Bands {
+		for _, field := range r.row(b) {
+			if field == "" {
+				field = "-"
+			}
+			fmt.Fprintf(tw, "%s\t", field)
+		}
+		fmt.Fprintln(tw)
+	}
+	return tw.Flush()
+}
+
+func (r Result) WriteCSV(w io.Writer) error {
+	cw := csv.NewWriter(w)
+	if err := cw.Write(header); err != nil {
+		return err
+	}
+	for _, b := range r.Bands {
+		if err := cw.Write(r.row(b)); err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+func (r Result) WriteJSON(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(r)
+}
@@ -0,0 +1,260 @@
+package room
+
+import (
+	"errors"
+	"math"
+
+	"gonum.org/v1/gonum/dsp/fourier"
+)
+
+// OctaveBands are the nominal ISO 266 octave-band centre frequencies used
+// for room acoustic parameters.
+var OctaveBands = []float64{63, 125, 250, 500, 1000, 2000, 4000, 8000, 16000}
+
+// Metric is a room acoustic parameter that may be undefined, for example a
+// T30 when the decay curve never falls 35 dB. Undefined values are NaN and
+// encode as null in JSON.
+type Metric float64
+
+func (m Metric) Valid() bool {
+	return !math.IsNaN(float64(m))
+}
+
+func (m Metric) MarshalJSON() ([]byte, error) {
+	if !m.Valid() {
+		return []byte("null"), nil
+	}
+	return []byte(formatMetric(m, 4)), nil
+}
+
+// BandResult holds the parameters of one octave band. A Band of 0 is the
+// unfiltered broadband response. Reverberation times are in seconds,
+// clarity in dB and definition as a fraction.
+type BandResult struct {
+	Band float64 `json:"band"`
+	EDT  Metric  `json:"edt"`
+	T20  Metric  `json:"t20"`
+	T30  Metric  `json:"t30"`
+	C50  Metric  `json:"c50"`
+	C80  Metric  `json:"c80"`
+	D50  Metric  `json:"d50"`
+}
+
+type Result struct {
+	SampleRate uint32       `json:"sampleRate"`
+	Bands      []BandResult `json:"bands"`
+}
+
+// Analyze computes EDT, T20, T30, C50, C80 and D50 for every octave band
+// below Nyquist and for the broadband response.
+func Analyze(ir []float64, sampleRate uint32) (Result, error) {
+	if sampleRate == 0 {
+		return Result{}, errors.New("room: sample rate must be positive")
+	}
+	if len(ir) == 0 {
+		return Result{}, errors.New("room: empty impulse response")
+	}
+
+	result := Result{SampleRate: sampleRate}
+	for _, band := range OctaveBands {
+		if band*math.Sqrt2 >= float64(sampleRate)/2 {
+			break
+		}
+		filtered := OctaveFilter(ir, float64(sampleRate), band)
+		metrics := analyzeBand(filtered, float64(sampleRate))
+		metrics.Band = band
+		result.Bands = append(result.Bands, metrics)
+	}
+	result.Bands = append(result.Bands, analyzeBand(ir, float64(sampleRate)))
+	return result, nil
+}
+
+func analyzeBand(ir []float64, sampleRate float64) BandResult {
+	onset := Onset(ir)
+	ir = ir[onset:]
+	ir = ir[:truncationPoint(ir, sampleRate)]
+
+	decay := Schroeder(ir)
+	return BandResult{
+		EDT: decayTime(decay, sampleRate, 0, -10),
+		T20: decayTime(decay, sampleRate, -5, -25),
+		T30: decayTime(decay, sampleRate, -5, -35),
+		C50: clarity(ir, sampleRate, 0.05),
+		C80: clarity(ir, sampleRate, 0.08),
+		D50: definition(ir, sampleRate),
+	}
+}
+
+// Onset returns the index where the response first rises to within 20 dB
+// of its peak, as ISO 3382-1 prescribes for the start of the decay.
+func Onset(ir []float64) int {
+	peak := 0.0
+	for _, v := range ir {
+		peak = math.Max(peak, math.Abs(v))
+	}
+	threshold := peak * 0.1
+	for i, v := range ir {
+		if math.Abs(v) >= threshold {
+			return i
+		}
+	}
+	return 0
+}
+
+// truncationPoint estimates the background noise from the last tenth of
+// the response and returns the end of the last 10 ms window whose energy
+// is still 10 dB above it, so that noise does not flatten the Schroeder
+// curve.
+func truncationPoint(ir []float64, sampleRate float64) int {
+	window := int(0.01 * sampleRate)
+	if window < 1 || len(ir) < 20*window {
+		return len(ir)
+	}
+
+	tail := ir[len(ir)-len(ir)/10:]
+	noise := 0.0
+	for _, v := range tail {
+		noise += v * v
+	}
+	noise /= float64(len(tail))
+	if noise == 0 {
+		return len(ir)
+	}
+
+	for end := len(ir) - len(tail); end >= window; end -= window {
+		energy := 0.0
+		for _, v := range ir[end-window : end] {
+			energy += v * v
+		}
+		if energy/float64(window) > 10*noise {
+			return end
+		}
+	}
+	return len(ir)
+}
+
+// Schroeder returns the backward-integrated energy decay curve in dB,
+// normalised to 0 dB at the first sample.
+func Schroeder(ir []float64) []float64 {
+	decay := make([]float64, len(ir))
+	energy := 0.0
+	for i := len(ir) - 1; i >= 0; i-- {
+		energy += ir[i] * ir[i]
+		decay[i] = energy
+	}
+	total := decay[0]
+	for i, e := range decay {
+		if total == 0 || e == 0 {
+			decay[i] = math.Inf(-1)
+			continue
+		}
+		decay[i] = 10 * math.Log10(e/total)
+	}
+	return decay
+}
+
+// decayTime fits a line to the decay curve between the two levels and
+// extrapolates it to a 60 dB decay.
+func decayTime(decay []float64, sampleRate, upper, lower float64) Metric {
+	start, end := -1, -1
+	for i, level := range decay {
+		if start < 0 && level <= upper {
+			start = i
+		}
+		if level <= lower {
+			end = i
+			break
+		}
+	}
+	if start < 0 || end <= start+1 {
+		return Metric(math.NaN())
+	}
+
+	var sumX, sumY, sumXY, sumXX float64
+	n := float64(end - start + 1)
+	for i := start; i <= end; i++ {
+		x := float64(i) / sampleRate
+		sumX += x
+		sumY += decay[i]
+		sumXY += x * decay[i]
+		sumXX += x * x
+	}
+	slope := (n*sumXY - sumX*sumY) / (n*sumXX - sumX*sumX)
+	if slope >= 0 {
+		return Metric(math.NaN())
+	}
+	return Metric(-60 / slope)
+}
+
+func energySplit(ir []float64, sampleRate, boundary float64) (float64, float64) {
+	split := int(math.Round(boundary * sampleRate))
+	if split > len(ir) {
+		split = len(ir)
+	}
+	var early, late float64
+	for i, v := range ir {
+		if i < split {
+			early += v * v
+		} else {
+			late += v * v
+		}
+	}
+	return early, late
+}
+
+func clarity(ir []float64, sampleRate, boundary float64) Metric {
+	early, late := energySplit(ir, sampleRate, boundary)
+	if early == 0 || late == 0 {
+		return Metric(math.NaN())
+	}
+	return Metric(10 * math.Log10(early/late))
+}
+
+func definition(ir []float64, sampleRate float64) Metric {
+	early, late := energySplit(ir, sampleRate, 0.05)
+	if early+late == 0 {
+		return Metric(math.NaN())
+	}
+	return Metric(early / (early + late))
+}
+
+// OctaveFilter applies a zero-phase octave band-pass filter centred on
+// center using the gonum FFT. Band edges are raised-cosine crossfades
+// across a fifth of an octave so that adjacent bands sum to unity.
+func OctaveFilter(ir []float64, sampleRate, center float64) []float64 {
+	n := 1
+	for n < 2*len(ir) {
+		n <<= 1
+	}
+	padded := make([]float64, n)
+	copy(padded, ir)
+
+	fft := fourier.NewFFT(n)
+	coeffs := fft.Coefficients(nil, padded)
+	for i := range coeffs {
+		coeffs[i] *= complex(octaveWeight(fft.Freq(i)*sampleRate, center), 0)
+	}
+	filtered := fft.Sequence(nil, coeffs)
+
+	out := make([]float64, len(ir))
+	for i := range out {
+		out[i] = filtered[i] / float64(n)
+	}
+	return out
+}
+
+func octaveWeight(freq, center float64) float64 {
+	const transition = 0.1
+	if freq <= 0 {
+		return 0
+	}
+	x := math.Abs(math.Log2(freq / center))
+	switch {
+	case x <= 0.5-transition:
+		return 1
+	case x >= 0.5+transition:
+		return 0
+	}
+	c := math.Cos(math.Pi / 2 * (x - (0.5 - transition)) / (2 * transition))
+	return c * c
+}
@@ -0,0 +1,130 @@
+package room
+
+import (
+	"bytes"
+	"encoding/json"
+	"math"
+	"math/rand"
+	"strings"
+	"testing"
+)
+
+// syntheticIR returns exponentially decaying noise with the given RT60,
+// preceded by a few milliseconds of silence.
+func syntheticIR(rt60, length float64, sampleRate int) []float64 {
+	random := rand.New(rand.NewSource(1))
+	delay := sampleRate / 200
+	ir := make([]float64, delay+int(length*float64(sampleRate)))
+	for i := delay; i < len(ir); i++ {
+		t := float64(i-delay) / float64(sampleRate)
+		ir[i] = random.NormFloat64() * math.Exp(-3*math.Ln10*t/rt60)
+	}
+	return ir
+}
+
+func TestAnalyzeSyntheticDecay(t *testing.T) {
+	const (
+		rt60       = 0.8
+		sampleRate = 48000
+	)
+	result, err := Analyze(syntheticIR(rt60, 2, sampleRate), sampleRate)
+	if err != nil {
+		t.Fatalf("Analyze failed: %v", err)
+	}
+
+	if len(result.Bands) != len(OctaveBands)+1 {
+		t.Fatalf("Unexpected number of bands: got %d", len(result.Bands))
+	}
+
+	// Energy of an exponential decay splits analytically at 50 ms.
+	early := 1 - math.Exp(-6*math.Ln10*0.05/rt60)
+	wantC50 := 10 * math.Log10(early/(1-early))
+
+	for _, band := range result.Bands {
+		if band.Band != 0 && band.Band < 500 {
+			// Few noise cycles per band make low-frequency decays too noisy to compare.
+			continue
+		}
+		for name, value := range map[string]Metric{"EDT": band.EDT, "T20": band.T20, "T30": band.T30} {
+			if !value.Valid() || math.Abs(float64(value)-rt60) > 0.08 {
+				t.Errorf("Band %v: %s = %v, want %v", band.Band, name, value, rt60)
+			}
+		}
+		if band.Band == 0 {
+			if math.Abs(float64(band.C50)-wantC50) > 0.5 {
+				t.Errorf("Broadband C50 = %v, want %v", band.C50, wantC50)
+			}
+			if math.Abs(float64(band.D50)-early) > 0.05 {
+				t.Errorf("Broadband D50 = %v, want %v", band.D50, early)
+			}
+			if band.C80 <= band.C50 {
+				t.Errorf("C80 (%v) should exceed C50 (%v)", band.C80, band.C50)
+			}
+		}
+	}
+}
+
+func TestSchroeder(t *testing.T) {
+	decay := Schroeder([]float64{1, 1, 1, 1})
+	want := []float64{0, 10 * math.Log10(0.75), 10 * math.Log10(0.5), 10 * math.Log10(0.25)}
+	for i := range want {
+		if math.Abs(decay[i]-want[i]) > 1e-9 {
+			t.Errorf("decay[%d] = %v, want %v", i, decay[i], want[i])
+		}
+	}
+}
+
+func TestOctaveBandsSumToUnity(t *testing.T) {
+	for freq := 100.0; freq < 10000; freq *= 1.07 {
+		sum := 0.0
+		for _, band := range OctaveBands {
+			sum += octaveWeight(freq, band)
+		}
+		if math.Abs(sum-1) > 1e-9 {
+			t.Errorf("Band weights at %.1f Hz sum to %v", freq, sum)
+		}
+	}
+}
+
+func TestUndefinedMetrics(t *testing.T) {
+	// A single impulse has no decay to fit.
+	ir := make([]float64, 1000)
+	ir[10] = 1
+	result, err := Analyze(ir, 8000)
+	if err != nil {
+		t.Fatalf("Analyze failed: %v", err)
+	}
+	broadband := result.Bands[len(result.Bands)-1]
+	if broadband.T30.Valid() {
+		t.Errorf("T30 should be undefined, got %v", broadband.T30)
+	}
+
+	var buf bytes.Buffer
+	if err := result.WriteJSON(&buf); err != nil {
+		t.Fatalf("WriteJSON failed: %v", err)
+	}
+	var decoded map[string]any
+	if err := json.Unmarshal(buf.Bytes(), &decoded); err != nil {
+		t.Fatalf("Invalid JSON: %v\n%s", err, buf.String())
+	}
+	if !strings.Contains(buf.String(), `"t30": null`) {
+		t.Errorf("Undefined T30 should encode as null:\n%s", buf.String())
+	}
+
+	buf.Reset()
+	if err := result.WriteCSV(&buf); err != nil {
+		t.Fatalf("WriteCSV failed: %v", err)
+	}
+	if lines := strings.Count(buf.String(), "\n"); lines != len(result.Bands)+1 {
+		t.Errorf("CSV should have a header and one row per band, got %d lines", lines)
+	}
+}
+
+func TestAnalyzeErrors(t *testing.T) {
+	if _, err := Analyze(nil, 48000); err == nil {
+		t.Error("Expected an error for an empty response")
+	}
+	if _, err := Analyze([]float64{1}, 0); err == nil {
+		t.Error("Expected an error for a zero sample rate")
+	}
+}
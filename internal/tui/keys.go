@@ -0,0 +1,139 @@
+package tui
+
+import (
+	"bufio"
+	"io"
+	"strings"
+	"sync"
+)
+
+// Key is a key press: the typed rune, or one of the special keys below.
+type Key rune
+
+// Special keys use code points of the Unicode private use area, which no
+// keyboard types.
+const (
+	KeyUp Key = 0xE000 + iota
+	KeyDown
+	KeyRight
+	KeyLeft
+	KeyEscape
+	KeyCtrlC = Key(0x03)
+)
+
+var keyNames = map[Key]string{
+	KeyUp:     "up",
+	KeyDown:   "down",
+	KeyRight:  "right",
+	KeyLeft:   "left",
+	KeyEscape: "esc",
+	KeyCtrlC:  "ctrl+c",
+	' ':       "space",
+}
+
+func (k Key) String() string {
+	if name, ok := keyNames[k]; ok {
+		return name
+	}
+	return string(rune(k))
+}
+
+// KeySource delivers key presses. ReadKey blocks until a key is pressed
+// and returns io.EOF when there are no more keys.
+type KeySource interface {
+	ReadKey() (Key, error)
+}
+
+// Script is a KeySource that replays a fixed sequence of keys.
+type Script struct {
+	mutex sync.Mutex
+	keys  []Key
+}
+
+// NewScript returns a script of the keys in spec: runes stand for
+// themselves and <name> for a special key, e.g. "++<up><space>q".
+func NewScript(spec string) *Script {
+	s := &Script{}
+	for spec != "" {
+		if strings.HasPrefix(spec, "<") {
+			if end := strings.Index(spec, ">"); end > 0 {
+				if key, ok := keyByName(spec[1:end]); ok {
+					s.keys = append(s.keys, key)
+					spec = spec[end+1:]
+					continue
+				}
+			}
+		}
+		r := []rune(spec)[0]
+		s.keys = append(s.keys, Key(r))
+		spec = spec[len(string(r)):]
+	}
+	return s
+}
+
+func keyByName(name string) (Key, bool) {
+	for key, n := range keyNames {
+		if n == name {
+			return key, true
+		}
+	}
+	return 0, false
+}
+
+func (s *Script) ReadKey() (Key, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if len(s.keys) == 0 {
+		return 0, io.EOF
+	}
+	key := s.keys[0]
+	s.keys = s.keys[1:]
+	return key, nil
+}
+
+// terminalKeys decodes the bytes a terminal sends for key presses,
+// including the ANSI escape sequences of the arrow keys.
+type terminalKeys struct {
+	r *bufio.Reader
+}
+
+// NewTerminalKeys reads keys from r, typically os.Stdin with the terminal
+// in non-canonical mode so that keys arrive without Enter.
+func NewTerminalKeys(r io.Reader) KeySource {
+	return &terminalKeys{r: bufio.NewReader(r)}
+}
+
+func (t *terminalKeys) ReadKey() (Key, error) {
+	r, _, err := t.r.ReadRune()
+	if err != nil {
+		return 0, err
+	}
+	if r != 0x1b {
+		return Key(r), nil
+	}
+
+	// An escape sequence arrives at once, a lone Esc on its own.
+	if t.r.Buffered() < 2 {
+		return KeyEscape, nil
+	}
+	if b, _ := t.r.Peek(1); b[0] != '[' && b[0] != 'O' {
+		return KeyEscape, nil
+	}
+	seq, _ := t.r.Peek(2)
+	t.r.Discard(2)
+	switch seq[1] {
+	case 'A':
+		return KeyUp, nil
+	case 'B':
+		return KeyDown, nil
+	case 'C':
+		return KeyRight, nil
+	case 'D':
+		return KeyLeft, nil
+	}
+	// Skip the rest of an unknown sequence such as "\x1b[3~".
+	for c := seq[1]; (c < '@' || c > '~') && t.r.Buffered() > 0; {
+		c, _ = t.r.ReadByte()
+	}
+	return t.ReadKey()
+}
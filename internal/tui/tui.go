@@ -0,0 +1,239 @@
+// Package tui is an interactive terminal interface that adjusts a playing
+// sweep from key presses and shows its state on a status line.
+package tui
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"strings"
+	"time"
+
+	"github.com/hailam/malgoplay/internal/fsg"
+)
+
+// Generator is the part of fsg.FrequencySweepGenerator the UI drives.
+type Generator interface {
+	Status() fsg.Status
+	Pause() error
+	Resume() error
+	Seek(fraction float64) error
+	SetAmplitude(amplitude float64)
+	SetSweepRate(rate float64)
+	SetSweepMode(mode fsg.SweepMode)
+	SetFrequencyRange(minFreq, maxFreq float64, glide time.Duration) error
+}
+
+const (
+	amplitudeStep = 0.05
+	rateFactor    = 1.25
+	// rangeStep moves or widens the range by a third of an octave.
+	rangeStep  = 1.0 / 3
+	rangeGlide = 100 * time.Millisecond
+	meterWidth = 20
+	// The spectrum meter spans the audible range on a log scale.
+	spectrumMin   = 20.0
+	spectrumMax   = 20000.0
+	spectrumWidth = 30
+)
+
+// Modes are the sweep modes cycled by m and M; notes and custom need
+// settings of their own.
+var Modes = []fsg.SweepMode{
+	fsg.SweepModeLinear,
+	fsg.SweepModeSine,
+	fsg.SweepModeTriangle,
+	fsg.SweepModeExponential,
+	fsg.SweepModeLogarithmic,
+	fsg.SweepModeSquare,
+	fsg.SweepModeSawtooth,
+	fsg.SweepModeRandom,
+	fsg.SweepModeOctaves,
+}
+
+// Help lists the key bindings.
+const Help = "up/down amplitude  left/right sweep rate  [/] shift range  {/} range width  m/M mode  0-9 seek  space pause  q quit"
+
+// UI reads keys from a KeySource, applies them to a Generator and redraws
+// a status line on out.
+type UI struct {
+	gen     Generator
+	keys    KeySource
+	out     io.Writer
+	refresh time.Duration
+	message string
+}
+
+func New(gen Generator, keys KeySource, out io.Writer) *UI {
+	return &UI{gen: gen, keys: keys, out: out, refresh: 100 * time.Millisecond}
+}
+
+// Run handles keys until q, Esc or Ctrl+C is pressed, the keys run out or
+// done is closed, redrawing the status line after every key and
+// periodically in between. Run does not start or stop the generator, but
+// leaves it paused if it was paused.
+func (u *UI) Run(done <-chan struct{}) error {
+	type keyEvent struct {
+		key Key
+		err error
+	}
+	events := make(chan keyEvent)
+	stop := make(chan struct{})
+	defer close(stop)
+	go func() {
+		for {
+			key, err := u.keys.ReadKey()
+			select {
+			case events <- keyEvent{key, err}:
+			case <-stop:
+				return
+			}
+			if err != nil {
+				return
+			}
+		}
+	}()
+
+	ticker := time.NewTicker(u.refresh)
+	defer ticker.Stop()
+	fmt.Fprintln(u.out, Help)
+	u.draw()
+	defer fmt.Fprintln(u.out)
+	for {
+		select {
+		case <-done:
+			return nil
+		case <-ticker.C:
+			u.draw()
+		case event := <-events:
+			if errors.Is(event.err, io.EOF) {
+				return nil
+			}
+			if event.err != nil {
+				return event.err
+			}
+			if u.Handle(event.key) {
+				u.draw()
+				return nil
+			}
+			u.draw()
+		}
+	}
+}
+
+// Handle applies one key press and reports whether it asks to quit.
+// Errors, such as a range above Nyquist, are shown on the status line.
+func (u *UI) Handle(key Key) (quit bool) {
+	status := u.gen.Status()
+	u.message = ""
+	var err error
+	switch key {
+	case 'q', 'Q', KeyEscape, KeyCtrlC:
+		return true
+	case KeyUp, '+', '=':
+		u.gen.SetAmplitude(math.Min(1, status.Amplitude+amplitudeStep))
+	case KeyDown, '-', '_':
+		u.gen.SetAmplitude(math.Max(0, status.Amplitude-amplitudeStep))
+	case KeyRight:
+		u.gen.SetSweepRate(status.SweepRate * rateFactor)
+	case KeyLeft:
+		u.gen.SetSweepRate(status.SweepRate / rateFactor)
+	case ']':
+		err = u.setRange(status.MinFrequency*octaves(rangeStep), status.MaxFrequency*octaves(rangeStep))
+	case '[':
+		err = u.setRange(status.MinFrequency/octaves(rangeStep), status.MaxFrequency/octaves(rangeStep))
+	case '}':
+		err = u.setRange(status.MinFrequency, status.MaxFrequency*octaves(rangeStep))
+	case '{':
+		maxFreq := math.Max(status.MinFrequency, status.MaxFrequency/octaves(rangeStep))
+		err = u.setRange(status.MinFrequency, maxFreq)
+	case 'm':
+		u.gen.SetSweepMode(nextMode(status.Mode, 1))
+	case 'M':
+		u.gen.SetSweepMode(nextMode(status.Mode, -1))
+	case ' ', 'p':
+		if status.Paused {
+			err = u.gen.Resume()
+		} else {
+			err = u.gen.Pause()
+		}
+	case '0', '1', '2', '3', '4', '5', '6', '7', '8', '9':
+		err = u.gen.Seek(float64(key-'0') / 10)
+	case 'h', '?':
+		u.message = Help
+	default:
+		u.message = fmt.Sprintf("unbound key %s (h for help)", key)
+	}
+	if err != nil {
+		u.message = err.Error()
+	}
+	return false
+}
+
+func (u *UI) setRange(minFreq, maxFreq float64) error {
+	return u.gen.SetFrequencyRange(minFreq, maxFreq, rangeGlide)
+}
+
+func octaves(n float64) float64 {
+	return math.Pow(2, n)
+}
+
+// nextMode returns the mode after (or, for a negative step, before) mode in
+// Modes; a mode outside Modes moves to the first one.
+func nextMode(mode fsg.SweepMode, step int) fsg.SweepMode {
+	for i, m := range Modes {
+		if m == mode {
+			return Modes[(i+step+len(Modes))%len(Modes)]
+		}
+	}
+	return Modes[0]
+}
+
+func (u *UI) draw() {
+	fmt.Fprintf(u.out, "\r\x1b[2K%s", StatusLine(u.gen.Status(), u.message))
+}
+
+// StatusLine formats the state of the generator: frequency, range, mode,
+// rate, amplitude, a level meter and a spectrum meter placing the
+// frequency between 20 Hz and 20 kHz.
+func StatusLine(status fsg.Status, message string) string {
+	state := "playing"
+	switch {
+	case status.Paused:
+		state = "paused"
+	case !status.Playing:
+		state = "stopped"
+	}
+	line := fmt.Sprintf("%-7s %8.1f Hz  %g-%g Hz  %-11s rate %.3g  amp %3.0f%%  %s  %s",
+		state, status.Frequency, round(status.MinFrequency), round(status.MaxFrequency),
+		status.Mode, status.SweepRate, status.Amplitude*100, LevelMeter(status.Level), SpectrumMeter(status.Frequency, status.Level))
+	if message != "" {
+		line += "  " + message
+	}
+	return line
+}
+
+func round(freq float64) float64 {
+	return math.Round(freq*10) / 10
+}
+
+// LevelMeter draws a peak level from 0 to 1 as a bar.
+func LevelMeter(level float64) string {
+	filled := int(math.Round(math.Max(0, math.Min(1, level)) * meterWidth))
+	return "[" + strings.Repeat("#", filled) + strings.Repeat(" ", meterWidth-filled) + "]"
+}
+
+// SpectrumMeter draws the frequency on a log scale from 20 Hz to 20 kHz,
+// with a mark that is heavier the louder the level; silence leaves the
+// scale empty.
+func SpectrumMeter(freq, level float64) string {
+	cells := []rune(strings.Repeat(".", spectrumWidth))
+	if freq > 0 && level > 0 {
+		position := math.Log(freq/spectrumMin) / math.Log(spectrumMax/spectrumMin)
+		i := int(math.Round(math.Max(0, math.Min(1, position)) * (spectrumWidth - 1)))
+		marks := []rune("-=#")
+		cells[i] = marks[int(math.Min(2, level*3))]
+	}
+	return "20|" + string(cells) + "|20k"
+}
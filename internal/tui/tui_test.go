@@ -0,0 +1,175 @@
+package tui
+
+import (
+	"bytes"
+	"io"
+	"math"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/hailam/malgoplay/internal/fsg"
+)
+
+func newGenerator(t *testing.T) *fsg.FrequencySweepGenerator {
+	t.Helper()
+	gen := fsg.NewFrequencySweepGenerator(100, 200, 48000, 1)
+	gen.SetMockDevice(fsg.NewMockDevice(48000, 1))
+	gen.SetFadeDurations(time.Millisecond, time.Millisecond)
+	gen.SetAmplitude(0.5)
+	return gen
+}
+
+func TestScript(t *testing.T) {
+	script := NewScript("a<up><space><bogus>é")
+	want := []Key{'a', KeyUp, ' ', '<', 'b', 'o', 'g', 'u', 's', '>', 'é'}
+	for i, w := range want {
+		key, err := script.ReadKey()
+		if err != nil || key != w {
+			t.Fatalf("Incorrect key %d: got %v (%v), want %v", i, key, err, w)
+		}
+	}
+	if _, err := script.ReadKey(); err != io.EOF {
+		t.Errorf("Expected io.EOF at the end of the script, got %v", err)
+	}
+}
+
+func TestTerminalKeys(t *testing.T) {
+	keys := NewTerminalKeys(strings.NewReader("x\x1b[A\x1b[B\x1bOC\x1b[D\x1b[3~q\x1b"))
+	want := []Key{'x', KeyUp, KeyDown, KeyRight, KeyLeft, 'q', KeyEscape}
+	for i, w := range want {
+		key, err := keys.ReadKey()
+		if err != nil || key != w {
+			t.Fatalf("Incorrect key %d: got %v (%v), want %v", i, key, err, w)
+		}
+	}
+	if _, err := keys.ReadKey(); err != io.EOF {
+		t.Errorf("Expected io.EOF, got %v", err)
+	}
+}
+
+func TestRunScript(t *testing.T) {
+	gen := newGenerator(t)
+	var out bytes.Buffer
+	ui := New(gen, NewScript("<up><up><down><right>]}mmMq+"), &out)
+	if err := ui.Run(nil); err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+
+	status := gen.Status()
+	if math.Abs(status.Amplitude-0.55) > 1e-9 {
+		t.Errorf("Incorrect amplitude: got %v, want 0.55", status.Amplitude)
+	}
+	if math.Abs(status.SweepRate-rateFactor) > 1e-9 {
+		t.Errorf("Incorrect sweep rate: got %v, want %v", status.SweepRate, rateFactor)
+	}
+	if status.Mode != fsg.SweepModeSine {
+		t.Errorf("Incorrect mode: got %v, want sine", status.Mode)
+	}
+	third := math.Pow(2, 1.0/3)
+	if math.Abs(status.MinFrequency-100*third) > 1e-9 || math.Abs(status.MaxFrequency-200*third*third) > 1e-9 {
+		t.Errorf("Incorrect range: %v-%v", status.MinFrequency, status.MaxFrequency)
+	}
+	if !strings.Contains(out.String(), Help) || !strings.Contains(out.String(), "sine") {
+		t.Errorf("Missing help or status line in output: %q", out.String())
+	}
+}
+
+func TestRangeErrors(t *testing.T) {
+	gen := newGenerator(t)
+	ui := New(gen, nil, io.Discard)
+	for i := 0; i < 30; i++ {
+		ui.Handle('}')
+	}
+	if !strings.Contains(ui.message, "Nyquist") {
+		t.Errorf("Expected a Nyquist error on the status line, got %q", ui.message)
+	}
+	if max := gen.Status().MaxFrequency; max > 24000 {
+		t.Errorf("Range should stay below Nyquist: %v", max)
+	}
+
+	for i := 0; i < 40; i++ {
+		ui.Handle('{')
+	}
+	if status := gen.Status(); status.MaxFrequency != status.MinFrequency {
+		t.Errorf("Narrowing should stop at a fixed frequency: %v-%v", status.MinFrequency, status.MaxFrequency)
+	}
+}
+
+func TestPause(t *testing.T) {
+	gen := newGenerator(t)
+	if err := gen.Start(); err != nil {
+		t.Fatal(err)
+	}
+	ui := New(gen, nil, io.Discard)
+	ui.Handle(' ')
+	status := gen.Status()
+	if status.Playing || !status.Paused {
+		t.Error("Space should pause playback")
+	}
+	if !strings.HasPrefix(StatusLine(status, ""), "paused") {
+		t.Error("Status line should show the pause")
+	}
+	ui.Handle(' ')
+	if status := gen.Status(); !status.Playing || status.Paused {
+		t.Error("Space should resume playback")
+	}
+}
+
+func TestSeekKeys(t *testing.T) {
+	gen := newGenerator(t)
+	ui := New(gen, NewScript("5"), io.Discard)
+	if err := ui.Run(nil); err != nil {
+		t.Fatal(err)
+	}
+	gen.Render(1)
+	if freq := gen.Status().Frequency; math.Abs(freq-150) > 0.1 {
+		t.Errorf("5 should seek half way: got %v Hz, want 150", freq)
+	}
+
+	gen.SetSweepMode(fsg.SweepModeRandom)
+	ui.Handle('3')
+	if !strings.Contains(ui.message, "random") {
+		t.Errorf("Expected a seek error on the status line, got %q", ui.message)
+	}
+}
+
+func TestMeters(t *testing.T) {
+	if got := LevelMeter(0.5); got != "["+strings.Repeat("#", 10)+strings.Repeat(" ", 10)+"]" {
+		t.Errorf("Incorrect level meter: %q", got)
+	}
+	if got := LevelMeter(2); strings.Contains(got, " ") {
+		t.Errorf("Level meter should clip at full scale: %q", got)
+	}
+
+	tests := []struct {
+		freq, level float64
+		index       int
+		mark        rune
+	}{
+		{20, 0.2, 0, '-'},
+		{20000, 1, spectrumWidth - 1, '#'},
+		{math.Sqrt(20 * 20000), 0.5, spectrumWidth / 2, '='},
+	}
+	for _, tc := range tests {
+		cells := []rune(strings.TrimSuffix(strings.TrimPrefix(SpectrumMeter(tc.freq, tc.level), "20|"), "|20k"))
+		if cells[tc.index] != tc.mark {
+			t.Errorf("Incorrect spectrum meter for %v Hz at %v: %q", tc.freq, tc.level, string(cells))
+		}
+	}
+	if strings.ContainsAny(SpectrumMeter(1000, 0), "-=#") {
+		t.Error("Silence should leave the spectrum meter empty")
+	}
+}
+
+func TestNextMode(t *testing.T) {
+	if got := nextMode(fsg.SweepModeOctaves, 1); got != fsg.SweepModeLinear {
+		t.Errorf("Incorrect mode after octaves: got %v, want linear", got)
+	}
+	if got := nextMode(fsg.SweepModeLinear, -1); got != fsg.SweepModeOctaves {
+		t.Errorf("Incorrect mode before linear: got %v, want octaves", got)
+	}
+	if got := nextMode(fsg.SweepModeCustom, 1); got != fsg.SweepModeLinear {
+		t.Errorf("Incorrect mode after custom: got %v, want linear", got)
+	}
+}
@@ -1,5 +1,6 @@
 package com.hailam.malgoplay
 
+import android.util.Log
 import androidx.lifecycle.ViewModel
 import kotlinx.coroutines.CoroutineScope
 import kotlinx.coroutines.Dispatchers
@@ -10,11 +11,14 @@ import mobile_fsg_main.Mobile_fsg_main
 class MainViewModel : ViewModel() {
 
     companion object {
+        private const val TAG = "MainViewModel"
         const val DEFAULT_MAX_FREQUENCY = 1000.0
         const val DEFAULT_MIN_FREQUENCY = 600.0
         const val DEFAULT_AMPLITUDE = 2.0
         const val DEFAULT_SWEEP_RATE = 1.0
         const val DEFAULT_CHANNELS = 1L
+        const val SAMPLE_RATE = 44100L
+        const val RANGE_GLIDE_MS = 50.0
     }
 
     private var isPlaying = false
@@ -64,8 +68,56 @@ class MainViewModel : ViewModel() {
         }
     }
 
+    // Updates the range of a running sweep without re-initializing audio;
+    // the glide keeps edits from clicking. A range the generator rejects,
+    // such as a minimum above the maximum while typing, is ignored.
+    fun updateFrequencyRange(minFrequency: Double, maxFrequency: Double) {
+        if (!isPlaying) return
+        CoroutineScope(Dispatchers.IO).launch {
+            try {
+                Mobile_fsg_main.setFrequencyRange(minFrequency, maxFrequency, RANGE_GLIDE_MS)
+            } catch (e: Exception) {
+                Log.w(TAG, "Frequency range not applied: ${e.message}")
+            }
+        }
+    }
+
+    // Pausing keeps the sweep position, so resuming carries on where it
+    // stopped rather than restarting the sweep.
+    fun pausePlaying() {
+        if (!isPlaying) return
+        CoroutineScope(Dispatchers.IO).launch {
+            Mobile_fsg_main.pauseAudio()
+        }
+    }
+
+    fun resumePlaying() {
+        if (!isPlaying) return
+        CoroutineScope(Dispatchers.IO).launch {
+            Mobile_fsg_main.resumeAudio()
+        }
+    }
+
+    // Jumps to a point of the sweep, from 0 (start) to 1 (end).
+    fun seek(fraction: Double) {
+        if (!isPlaying) return
+        CoroutineScope(Dispatchers.IO).launch {
+            Mobile_fsg_main.seek(fraction)
+        }
+    }
+
+    fun updateChannels(channels: Long) {
+        if (!isPlaying) return
+        CoroutineScope(Dispatchers.IO).launch {
+            try {
+                Mobile_fsg_main.reconfigure(SAMPLE_RATE, channels)
+            } catch (e: Exception) {
+                Log.w(TAG, "Channels not applied: ${e.message}")
+            }
+        }
+    }
+
     private fun initAudioDevice(minFrequency: Double, maxFrequency: Double, channels: Long) {
-        val sampleRate = 44100L
-        Mobile_fsg_main.initializeAudio(minFrequency, maxFrequency, sampleRate, channels)
+        Mobile_fsg_main.initializeAudio(minFrequency, maxFrequency, SAMPLE_RATE, channels)
     }
 }
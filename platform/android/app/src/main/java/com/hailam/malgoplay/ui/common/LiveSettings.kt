@@ -0,0 +1,15 @@
+package com.hailam.malgoplay.ui.common
+
+import com.hailam.malgoplay.MainViewModel
+
+// Passes an edited range to a running sweep once both fields hold numbers.
+fun updateRange(viewModel: MainViewModel, minFrequency: String, maxFrequency: String) {
+    val min = minFrequency.toDoubleOrNull() ?: return
+    val max = maxFrequency.toDoubleOrNull() ?: return
+    viewModel.updateFrequencyRange(min, max)
+}
+
+// Passes an edited channel count to a running sweep once it is a number.
+fun updateChannels(viewModel: MainViewModel, channels: String) {
+    channels.toLongOrNull()?.let(viewModel::updateChannels)
+}
@@ -14,6 +14,8 @@ import androidx.compose.ui.draw.scale
 import androidx.compose.ui.text.input.KeyboardType
 import androidx.compose.ui.unit.dp
 import com.hailam.malgoplay.MainViewModel
+import com.hailam.malgoplay.ui.common.updateChannels
+import com.hailam.malgoplay.ui.common.updateRange
 
 @OptIn(ExperimentalMaterial3Api::class)
 @Composable
@@ -46,6 +48,7 @@ fun MobileUI(viewModel: MainViewModel) {
             exit = fadeOut() + shrinkVertically()
         ) {
             Column(
+                modifier = Modifier.padding(bottom = 16.dp),
                 horizontalAlignment = Alignment.CenterHorizontally,
                 verticalArrangement = Arrangement.spacedBy(16.dp)
             ) {
@@ -78,13 +81,6 @@ fun MobileUI(viewModel: MainViewModel) {
                     }
                 }
 
-                OutlinedTextField(
-                    value = maxFrequency,
-                    onValueChange = { maxFrequency = it },
-                    label = { Text("Enter Max Frequency (Hz)") },
-                    keyboardOptions = KeyboardOptions.Default.copy(keyboardType = KeyboardType.Number)
-                )
-
                 OutlinedTextField(
                     value = amplitude,
                     onValueChange = { amplitude = it },
@@ -92,29 +88,52 @@ fun MobileUI(viewModel: MainViewModel) {
                     keyboardOptions = KeyboardOptions.Default.copy(keyboardType = KeyboardType.Number)
                 )
 
-                OutlinedTextField(
-                    value = minFrequency,
-                    onValueChange = { minFrequency = it },
-                    label = { Text("Enter Min Frequency (Hz)") },
-                    keyboardOptions = KeyboardOptions.Default.copy(keyboardType = KeyboardType.Number)
-                )
-
                 OutlinedTextField(
                     value = sweepRate,
                     onValueChange = { sweepRate = it },
                     label = { Text("Enter Sweep Rate (Hz per second)") },
                     keyboardOptions = KeyboardOptions.Default.copy(keyboardType = KeyboardType.Number)
                 )
-
-                OutlinedTextField(
-                    value = channels,
-                    onValueChange = { channels = it },
-                    label = { Text("Channels") },
-                    keyboardOptions = KeyboardOptions.Default.copy(keyboardType = KeyboardType.Number)
-                )
             }
         }
 
+        // The range and channels stay editable while playing and apply to
+        // the running sweep.
+        Column(
+            horizontalAlignment = Alignment.CenterHorizontally,
+            verticalArrangement = Arrangement.spacedBy(16.dp)
+        ) {
+            OutlinedTextField(
+                value = maxFrequency,
+                onValueChange = {
+                    maxFrequency = it
+                    updateRange(viewModel, minFrequency, maxFrequency)
+                },
+                label = { Text("Enter Max Frequency (Hz)") },
+                keyboardOptions = KeyboardOptions.Default.copy(keyboardType = KeyboardType.Number)
+            )
+
+            OutlinedTextField(
+                value = minFrequency,
+                onValueChange = {
+                    minFrequency = it
+                    updateRange(viewModel, minFrequency, maxFrequency)
+                },
+                label = { Text("Enter Min Frequency (Hz)") },
+                keyboardOptions = KeyboardOptions.Default.copy(keyboardType = KeyboardType.Number)
+            )
+
+            OutlinedTextField(
+                value = channels,
+                onValueChange = {
+                    channels = it
+                    updateChannels(viewModel, channels)
+                },
+                label = { Text("Channels") },
+                keyboardOptions = KeyboardOptions.Default.copy(keyboardType = KeyboardType.Number)
+            )
+        }
+
         Spacer(modifier = Modifier.height(24.dp))
 
         Column(
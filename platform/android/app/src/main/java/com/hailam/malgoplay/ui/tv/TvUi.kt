@@ -16,6 +16,8 @@ import androidx.compose.ui.unit.dp
 import androidx.tv.material3.*
 import androidx.tv.material3.MaterialTheme
 import com.hailam.malgoplay.MainViewModel
+import com.hailam.malgoplay.ui.common.updateChannels
+import com.hailam.malgoplay.ui.common.updateRange
 
 @OptIn(ExperimentalMaterial3Api::class)
 @Composable
@@ -44,13 +46,19 @@ fun TvUI(viewModel: MainViewModel) {
             Column(modifier = Modifier.weight(1f)) {
                 OutlinedTextField(
                     value = maxFrequency,
-                    onValueChange = { maxFrequency = it },
+                    onValueChange = {
+                        maxFrequency = it
+                        updateRange(viewModel, minFrequency, maxFrequency)
+                    },
                     label = { Text("Max Frequency (Hz)") },
                     modifier = Modifier.fillMaxWidth()
                 )
                 OutlinedTextField(
                     value = minFrequency,
-                    onValueChange = { minFrequency = it },
+                    onValueChange = {
+                        minFrequency = it
+                        updateRange(viewModel, minFrequency, maxFrequency)
+                    },
                     label = { Text("Min Frequency (Hz)") },
                     modifier = Modifier.fillMaxWidth()
                 )
@@ -70,7 +78,10 @@ fun TvUI(viewModel: MainViewModel) {
                 )
                 OutlinedTextField(
                     value = channels,
-                    onValueChange = { channels = it },
+                    onValueChange = {
+                        channels = it
+                        updateChannels(viewModel, channels)
+                    },
                     label = { Text("Channels") },
                     modifier = Modifier.fillMaxWidth()
                 )
@@ -128,4 +139,4 @@ fun TvUI(viewModel: MainViewModel) {
     LaunchedEffect(Unit) {
         focusRequester.requestFocus()
     }
-}
\ No newline at end of file
+}